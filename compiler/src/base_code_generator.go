@@ -2,18 +2,28 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
 type BaseCodeGenerator struct {
 	descriptor *ProtocolDescriptor
 	newLineStr string
+	// place output files in directories mirroring namespace
+	useNamespaceDir bool
+	// files written by last Generate()
+	outputFiles []*OutputFile
 }
 
 func (this *BaseCodeGenerator) init(
-	descriptor *ProtocolDescriptor, newLineType NewLineType) {
+	descriptor *ProtocolDescriptor, newLineType NewLineType,
+	useNamespaceDir bool) {
 
 	this.descriptor = descriptor
+	this.useNamespaceDir = useNamespaceDir
+	this.outputFiles = make([]*OutputFile, 0)
 	if newLineType == NewLineType_Dos {
 		this.newLineStr = "\r\n"
 	} else {
@@ -25,6 +35,62 @@ func (this *BaseCodeGenerator) close() {
 	this.descriptor = nil
 }
 
+func (this *BaseCodeGenerator) OutputFiles() []*OutputFile {
+	return this.outputFiles
+}
+
+// namespace of language as slash separated relative directory,
+// e.g. Protocol/Client, empty if namespace directory is not used
+func (this *BaseCodeGenerator) getNamespaceDir(
+	protoDef *ProtocolDef, language string) string {
+
+	if this.useNamespaceDir == false {
+		return ""
+	}
+
+	namespaceDef, ok := protoDef.Namespaces[language]
+	if ok == false {
+		return ""
+	}
+
+	return path.Join(namespaceDef.NamespaceParts...)
+}
+
+func (this *BaseCodeGenerator) getOutputFilePath(
+	outputDir string, language string, fileName string) string {
+
+	return filepath.Join(outputDir,
+		filepath.FromSlash(this.getNamespaceDir(
+			this.descriptor.ProtoDef, language)),
+		fileName)
+}
+
+func (this *BaseCodeGenerator) writeOutputFile(
+	filePath string, fileContent string) bool {
+
+	if this.useNamespaceDir {
+		dirPath := filepath.Dir(filePath)
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			fmt.Fprintf(os.Stderr,
+				"error: create directory %s failed: %s\n",
+				dirPath, err.Error())
+			return false
+		}
+	}
+
+	result := UtilWriteAllTextIfChanged(filePath, fileContent)
+	if result == WriteFileResult_None {
+		return false
+	}
+
+	outputFile := new(OutputFile)
+	outputFile.FilePath = filePath
+	outputFile.Result = result
+	this.outputFiles = append(this.outputFiles, outputFile)
+
+	return true
+}
+
 func (this *BaseCodeGenerator) writeLine(
 	sb *strings.Builder, line string) {
 
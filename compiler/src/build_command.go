@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printBuildUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"generate code of the whole project by project file\n"+
+		"usage: %s build"+
+		"\n"+
+		"    [-c <project_file>] default is brexc.json\n"+
+		"    [compiler options] override the project file, "+
+		"see `%s -h`\n",
+		filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+}
+
+func runBuildCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProjectFilePath string
+	flagOptions := NewCompileOptions()
+
+	flagSet := flag.NewFlagSet("build", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProjectFilePath, "project", "c", "", "")
+	flagOptions.AddFlags(flagSet)
+
+	if flagSet.Parse(args) != nil {
+		printBuildUsage()
+		return 1
+	}
+	if optHelp {
+		printBuildUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- option default value
+	if optProjectFilePath == "" {
+		optProjectFilePath = "brexc.json"
+	}
+
+	// -- check option project_file
+	if UtilCheckFileExists(optProjectFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find project file `%s`\n",
+			optProjectFilePath)
+		return 1
+	}
+
+	// load project file
+	projectFile := LoadProjectFile(optProjectFilePath)
+	if projectFile == nil {
+		return 1
+	}
+	options := projectFile.ToCompileOptions()
+	options.OverrideByFlags(flagOptions, flagSet)
+
+	// -- required options
+	if len(options.ProtoFilePaths) == 0 {
+		fmt.Fprintf(os.Stderr,
+			"error: no protocol file in project file `%s`\n",
+			optProjectFilePath)
+		return 1
+	}
+	if options.Language == "" {
+		fmt.Fprintf(os.Stderr,
+			"error: no language in project file `%s`\n",
+			optProjectFilePath)
+		return 1
+	}
+
+	return runCompile(options)
+}
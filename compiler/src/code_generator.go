@@ -11,5 +11,12 @@ const (
 type CodeGenerator interface {
 	Close()
 	Generate(descriptor *ProtocolDescriptor,
-		outputDir string, newLineType NewLineType) bool
+		outputDir string, newLineType NewLineType,
+		useNamespaceDir bool) bool
+	OutputFiles() []*OutputFile
+}
+
+type OutputFile struct {
+	FilePath string
+	Result   WriteFileResult
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type CompatIssue struct {
+	FilePath   string
+	LineNumber int
+	// breaking change if true, otherwise wire compatible but notable
+	IsError bool
+	Message string
+}
+
+// find changes between two protocol versions that break the wire format,
+// which is positional so any field change in a struct is breaking
+type CompatChecker struct {
+	Issues []*CompatIssue
+}
+
+func NewCompatChecker() *CompatChecker {
+	newObj := new(CompatChecker)
+	newObj.Issues = make([]*CompatIssue, 0)
+
+	return newObj
+}
+
+func (this *CompatChecker) ErrorCount() int {
+	count := 0
+	for _, issue := range this.Issues {
+		if issue.IsError {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (this *CompatChecker) addError(
+	filePath string, lineNumber int, format string, args ...any) {
+
+	issue := new(CompatIssue)
+	issue.FilePath = filePath
+	issue.LineNumber = lineNumber
+	issue.IsError = true
+	issue.Message = fmt.Sprintf(format, args...)
+	this.Issues = append(this.Issues, issue)
+}
+
+func (this *CompatChecker) addWarning(
+	filePath string, lineNumber int, format string, args ...any) {
+
+	issue := new(CompatIssue)
+	issue.FilePath = filePath
+	issue.LineNumber = lineNumber
+	issue.IsError = false
+	issue.Message = fmt.Sprintf(format, args...)
+	this.Issues = append(this.Issues, issue)
+}
+
+func (this *CompatChecker) Check(
+	oldDescriptor *ProtocolDescriptor, newDescriptor *ProtocolDescriptor) {
+
+	protoNames := make([]string, 0, len(oldDescriptor.ImportedProtos))
+	for name := range oldDescriptor.ImportedProtos {
+		protoNames = append(protoNames, name)
+	}
+	sort.Strings(protoNames)
+
+	for _, name := range protoNames {
+		oldProtoDef := oldDescriptor.ImportedProtos[name]
+		newProtoDef, ok := newDescriptor.ImportedProtos[name]
+		if ok == false {
+			this.addError(oldProtoDef.FilePath, 0,
+				"protocol `%s` removed", name)
+			continue
+		}
+		this.checkProtocol(oldProtoDef, newProtoDef)
+	}
+}
+
+func (this *CompatChecker) checkProtocol(
+	oldProtoDef *ProtocolDef, newProtoDef *ProtocolDef) {
+
+	for _, oldDef := range oldProtoDef.Enums {
+		newDef, ok := newProtoDef.EnumNameIndex[oldDef.Name]
+		if ok == false {
+			this.addError(oldProtoDef.FilePath, oldDef.LineNumber,
+				"enum `%s` removed", oldDef.Name)
+			continue
+		}
+		this.checkEnum(oldDef, newDef)
+	}
+
+	for _, oldDef := range oldProtoDef.Structs {
+		newDef, ok := newProtoDef.StructNameIndex[oldDef.Name]
+		if ok == false {
+			this.addError(oldProtoDef.FilePath, oldDef.LineNumber,
+				"struct `%s` removed", oldDef.Name)
+			continue
+		}
+		this.checkStruct(oldDef, newDef)
+	}
+
+	for _, oldDef := range oldProtoDef.EnumMaps {
+		newDef, ok := newProtoDef.EnumMapNameIndex[oldDef.Name]
+		if ok == false {
+			this.addError(oldProtoDef.FilePath, oldDef.LineNumber,
+				"enum map `%s` removed", oldDef.Name)
+			continue
+		}
+		this.checkEnumMap(oldDef, newDef)
+	}
+}
+
+func (this *CompatChecker) checkEnum(oldEnumDef *EnumDef, newEnumDef *EnumDef) {
+	newFilePath := newEnumDef.ParentRef.FilePath
+
+	for _, oldDef := range oldEnumDef.Items {
+		newDef, ok := newEnumDef.ItemNameIndex[oldDef.Name]
+		if ok == false {
+			this.addError(oldEnumDef.ParentRef.FilePath, oldDef.LineNumber,
+				"enum item `%s.%s` removed",
+				oldEnumDef.Name, oldDef.Name)
+			continue
+		}
+		if oldDef.IntValue != newDef.IntValue {
+			this.addError(newFilePath, newDef.LineNumber,
+				"enum item `%s.%s` value changed from %d to %d",
+				oldEnumDef.Name, oldDef.Name,
+				oldDef.IntValue, newDef.IntValue)
+		}
+	}
+}
+
+func (this *CompatChecker) checkStruct(
+	oldStructDef *StructDef, newStructDef *StructDef) {
+
+	oldFilePath := oldStructDef.ParentRef.FilePath
+	newFilePath := newStructDef.ParentRef.FilePath
+
+	oldFieldIndex := make(map[string]int)
+	for i, def := range oldStructDef.Fields {
+		oldFieldIndex[def.Name] = i
+	}
+	newFieldIndex := make(map[string]int)
+	for i, def := range newStructDef.Fields {
+		newFieldIndex[def.Name] = i
+	}
+
+	for i, newDef := range newStructDef.Fields {
+		j, ok := oldFieldIndex[newDef.Name]
+		if ok == false {
+			// same position, same type and old name is gone,
+			// only the name changed
+			if i < len(oldStructDef.Fields) {
+				oldDef := oldStructDef.Fields[i]
+				if _, ok := newFieldIndex[oldDef.Name]; ok == false &&
+					StructFieldDefGetTypeName(oldDef) ==
+						StructFieldDefGetTypeName(newDef) &&
+					oldDef.IsOptional == newDef.IsOptional {
+					this.addWarning(newFilePath, newDef.LineNumber,
+						"field `%s.%s` renamed from `%s`, "+
+							"wire compatible but breaks json and text",
+						newStructDef.Name, newDef.Name, oldDef.Name)
+					continue
+				}
+			}
+			this.addError(newFilePath, newDef.LineNumber,
+				"field `%s.%s` inserted at position %d",
+				newStructDef.Name, newDef.Name, i)
+			continue
+		}
+		if i != j {
+			this.addError(newFilePath, newDef.LineNumber,
+				"field `%s.%s` moved from position %d to %d",
+				newStructDef.Name, newDef.Name, j, i)
+			continue
+		}
+
+		oldDef := oldStructDef.Fields[j]
+		oldTypeName := StructFieldDefGetTypeName(oldDef)
+		newTypeName := StructFieldDefGetTypeName(newDef)
+		if oldTypeName != newTypeName {
+			this.addError(newFilePath, newDef.LineNumber,
+				"field `%s.%s` type changed from `%s` to `%s`",
+				newStructDef.Name, newDef.Name, oldTypeName, newTypeName)
+		}
+		if oldDef.IsOptional != newDef.IsOptional {
+			this.addError(newFilePath, newDef.LineNumber,
+				"field `%s.%s` changed from %s to %s",
+				newStructDef.Name, newDef.Name,
+				compatGetFieldRequirement(oldDef),
+				compatGetFieldRequirement(newDef))
+		}
+	}
+
+	for i, oldDef := range oldStructDef.Fields {
+		if _, ok := newFieldIndex[oldDef.Name]; ok {
+			continue
+		}
+		// reported as renamed already
+		if i < len(newStructDef.Fields) {
+			newDef := newStructDef.Fields[i]
+			if _, ok := oldFieldIndex[newDef.Name]; ok == false &&
+				StructFieldDefGetTypeName(oldDef) ==
+					StructFieldDefGetTypeName(newDef) &&
+				oldDef.IsOptional == newDef.IsOptional {
+				continue
+			}
+		}
+		this.addError(oldFilePath, oldDef.LineNumber,
+			"field `%s.%s` removed",
+			oldStructDef.Name, oldDef.Name)
+	}
+}
+
+func (this *CompatChecker) checkEnumMap(
+	oldEnumMapDef *EnumMapDef, newEnumMapDef *EnumMapDef) {
+
+	for _, oldDef := range oldEnumMapDef.Items {
+		if oldDef.RefStructDef == nil {
+			continue
+		}
+
+		newStructDef, ok := newEnumMapDef.IdToStructIndex[oldDef.IntValue]
+		if ok == false {
+			this.addError(oldEnumMapDef.ParentRef.FilePath,
+				oldDef.LineNumber,
+				"enum map `%s` id %d (`%s`) removed",
+				oldEnumMapDef.Name, oldDef.IntValue,
+				StructDefGetQualifiedName(oldDef.RefStructDef))
+			continue
+		}
+
+		oldStructName := StructDefGetQualifiedName(oldDef.RefStructDef)
+		newStructName := StructDefGetQualifiedName(newStructDef)
+		if oldStructName != newStructName {
+			lineNumber := 0
+			for _, newDef := range newEnumMapDef.Items {
+				if newDef.RefStructDef == newStructDef {
+					lineNumber = newDef.LineNumber
+					break
+				}
+			}
+			this.addError(newEnumMapDef.ParentRef.FilePath, lineNumber,
+				"enum map `%s` id %d remapped from `%s` to `%s`",
+				oldEnumMapDef.Name, oldDef.IntValue,
+				oldStructName, newStructName)
+		}
+	}
+}
+
+func compatGetFieldRequirement(fieldDef *StructFieldDef) string {
+	if fieldDef.IsOptional {
+		return "optional"
+	} else {
+		return "required"
+	}
+}
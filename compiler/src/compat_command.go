@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printCompatUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"check breaking changes between two protocol versions\n"+
+		"usage: %s compat "+
+		"--old <old_dir> "+
+		"--new <new_dir> "+
+		"-f <protocol_file>"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"protocol_file and search_path are relative to old_dir and new_dir\n"+
+		"exit code is 1 if any breaking change is found\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runCompatCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optOldDir string
+	var optNewDir string
+	var optProtoFilePath string
+	var optSearchPath []string
+
+	flagSet := flag.NewFlagSet("compat", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVar(&optOldDir, "old", "", "")
+	flagSet.StringVar(&optNewDir, "new", "", "")
+	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+
+	if flagSet.Parse(args) != nil {
+		printCompatUsage()
+		return 1
+	}
+	if optHelp {
+		printCompatUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if optOldDir == "" ||
+		optNewDir == "" ||
+		optProtoFilePath == "" {
+		printCompatUsage()
+		return 1
+	}
+
+	// -- check option old_dir and new_dir
+	for _, dir := range []string{optOldDir, optNewDir} {
+		if UtilCheckDirExists(dir) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find directory `%s`\n",
+				dir)
+			return 1
+		}
+	}
+
+	// parse both versions
+	oldParser := parseProtocolInDir(optOldDir, optProtoFilePath, optSearchPath)
+	if oldParser == nil {
+		return 1
+	}
+	defer oldParser.Close()
+	newParser := parseProtocolInDir(optNewDir, optProtoFilePath, optSearchPath)
+	if newParser == nil {
+		return 1
+	}
+	defer newParser.Close()
+
+	// check
+	checker := NewCompatChecker()
+	checker.Check(oldParser.Descriptor, newParser.Descriptor)
+
+	for _, issue := range checker.Issues {
+		var level string
+		if issue.IsError {
+			level = "error"
+		} else {
+			level = "warning"
+		}
+
+		filePath := issue.FilePath
+		if relPath, err := filepath.Rel(
+			UtilGetFullPath("."), filePath); err == nil {
+			filePath = relPath
+		}
+
+		if issue.LineNumber > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s: %s\n",
+				filePath, issue.LineNumber, level, issue.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n",
+				filePath, level, issue.Message)
+		}
+	}
+
+	errorCount := checker.ErrorCount()
+	if errorCount > 0 {
+		fmt.Fprintf(os.Stderr,
+			"%d breaking change(s) found\n", errorCount)
+		return 1
+	}
+
+	return 0
+}
+
+// imports are resolved against the working directory first,
+// so parse inside dir to keep the two versions apart
+func parseProtocolInDir(dir string,
+	protoFilePath string, protoSearchPath []string) *ProtocolParser {
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: get working directory failed: %s\n", err.Error())
+		return nil
+	}
+	if err := os.Chdir(dir); err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: change to directory `%s` failed: %s\n",
+			dir, err.Error())
+		return nil
+	}
+	defer os.Chdir(cwd)
+
+	if UtilCheckFileExists(protoFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find protocol file `%s` in `%s`\n",
+			protoFilePath, dir)
+		return nil
+	}
+
+	parser := NewProtocolParser()
+	if parser.Parse(protoFilePath, protoSearchPath) == false {
+		parser.Close()
+		return nil
+	}
+
+	return parser
+}
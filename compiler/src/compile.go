@@ -0,0 +1,476 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// options of generating code, from command line or project file
+type CompileOptions struct {
+	// protocol files, directories or globs
+	ProtoFilePaths []string
+	WithImports    bool
+	// comma separated list
+	Language        string
+	OutputDir       string
+	CppOutputDir    string
+	CSharpOutputDir string
+	PhpOutputDir    string
+	NamespaceDir    bool
+	SearchPath      []string
+	NewLineType     string
+	DepFilePath     string
+	// text, gcc or json
+	DiagnosticsFormat string
+}
+
+func NewCompileOptions() *CompileOptions {
+	newObj := new(CompileOptions)
+	newObj.ProtoFilePaths = make([]string, 0)
+	newObj.SearchPath = make([]string, 0)
+
+	return newObj
+}
+
+func (this *CompileOptions) AddFlags(flagSet *flag.FlagSet) {
+	flagSet.StringArrayVarP(&this.ProtoFilePaths, "-proto_file_path", "f", []string{}, "")
+	flagSet.BoolVar(&this.WithImports, "with-imports", false, "")
+	flagSet.StringVarP(&this.Language, "-language", "l", "", "")
+	flagSet.StringVarP(&this.OutputDir, "-output_dir", "o", "", "")
+	flagSet.StringVar(&this.CppOutputDir, "cpp_out", "", "")
+	flagSet.StringVar(&this.CSharpOutputDir, "csharp_out", "", "")
+	flagSet.StringVar(&this.PhpOutputDir, "php_out", "", "")
+	flagSet.BoolVar(&this.NamespaceDir, "namespace_dir", false, "")
+	flagSet.StringSliceVarP(&this.SearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVarP(&this.NewLineType, "-new_line_type", "n", "", "")
+	flagSet.StringVarP(&this.DepFilePath, "depfile", "M", "", "")
+	flagSet.StringVar(&this.DiagnosticsFormat, "diagnostics-format", "", "")
+}
+
+// copy options set on command line in flagSet from flagOptions
+func (this *CompileOptions) OverrideByFlags(
+	flagOptions *CompileOptions, flagSet *flag.FlagSet) {
+
+	if flagSet.Changed("-proto_file_path") {
+		this.ProtoFilePaths = flagOptions.ProtoFilePaths
+	}
+	if flagSet.Changed("with-imports") {
+		this.WithImports = flagOptions.WithImports
+	}
+	if flagSet.Changed("-language") {
+		this.Language = flagOptions.Language
+	}
+	if flagSet.Changed("-output_dir") {
+		this.OutputDir = flagOptions.OutputDir
+	}
+	if flagSet.Changed("cpp_out") {
+		this.CppOutputDir = flagOptions.CppOutputDir
+	}
+	if flagSet.Changed("csharp_out") {
+		this.CSharpOutputDir = flagOptions.CSharpOutputDir
+	}
+	if flagSet.Changed("php_out") {
+		this.PhpOutputDir = flagOptions.PhpOutputDir
+	}
+	if flagSet.Changed("namespace_dir") {
+		this.NamespaceDir = flagOptions.NamespaceDir
+	}
+	if flagSet.Changed("-search_path") {
+		this.SearchPath = flagOptions.SearchPath
+	}
+	if flagSet.Changed("-new_line_type") {
+		this.NewLineType = flagOptions.NewLineType
+	}
+	if flagSet.Changed("depfile") {
+		this.DepFilePath = flagOptions.DepFilePath
+	}
+	if flagSet.Changed("diagnostics-format") {
+		this.DiagnosticsFormat = flagOptions.DiagnosticsFormat
+	}
+}
+
+// options checked and expanded, ready to compile
+type CompileTask struct {
+	Options        *CompileOptions
+	ProtoFilePaths []string
+	Languages      []string
+	// language -> output dir
+	OutputDirs  map[string]string
+	NewLineType NewLineType
+}
+
+// apply default values and check options, return nil if failed
+func NewCompileTask(options *CompileOptions) *CompileTask {
+	// -- option default value
+	if options.OutputDir == "" {
+		options.OutputDir = "."
+	}
+	if options.CppOutputDir == "" {
+		options.CppOutputDir = options.OutputDir
+	}
+	if options.CSharpOutputDir == "" {
+		options.CSharpOutputDir = options.OutputDir
+	}
+	if options.PhpOutputDir == "" {
+		options.PhpOutputDir = options.OutputDir
+	}
+	if options.NewLineType == "" {
+		options.NewLineType = "unix"
+	}
+	if options.DiagnosticsFormat == "" {
+		options.DiagnosticsFormat = "text"
+	}
+
+	// -- check option proto_file_path
+	protoFilePaths := getProtoFilePaths(options.ProtoFilePaths)
+	if protoFilePaths == nil {
+		return nil
+	}
+
+	// -- check option language
+	languages := make([]string, 0)
+	outputDirs := make(map[string]string)
+	for _, language := range strings.Split(options.Language, ",") {
+		language = strings.TrimSpace(language)
+		if _, ok := outputDirs[language]; ok {
+			continue
+		}
+
+		var outputDir string
+		if language == "cpp" {
+			outputDir = options.CppOutputDir
+		} else if language == "php" {
+			outputDir = options.PhpOutputDir
+		} else if language == "csharp" {
+			outputDir = options.CSharpOutputDir
+		} else if language == "proto3" || language == "jsonschema" ||
+			language == "html" || language == "markdown" {
+			outputDir = options.OutputDir
+		} else if PluginFindProgram(language) != "" {
+			outputDir = options.OutputDir
+		} else {
+			fmt.Fprintf(os.Stderr,
+				"error: language `%s` is not supported, "+
+					"generator plugin `%s%s` is not found in PATH\n",
+				language, PluginProgramPrefix, language)
+			return nil
+		}
+		languages = append(languages, language)
+		outputDirs[language] = outputDir
+	}
+
+	// -- check option output_dir
+	for _, language := range languages {
+		if UtilCheckDirExists(outputDirs[language]) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find output directory `%s`\n",
+				outputDirs[language])
+			return nil
+		}
+	}
+
+	// -- check option new_line_type
+	if options.NewLineType != "dos" &&
+		options.NewLineType != "unix" {
+		fmt.Fprintf(os.Stderr,
+			"error: new_line_type `%s` is invalid\n",
+			options.NewLineType)
+		return nil
+	}
+
+	// -- check option diagnostics_format
+	if DiagnosticsFormatIsValid(options.DiagnosticsFormat) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: diagnostics_format `%s` is invalid\n",
+			options.DiagnosticsFormat)
+		return nil
+	}
+
+	newObj := new(CompileTask)
+	newObj.Options = options
+	newObj.ProtoFilePaths = protoFilePaths
+	newObj.Languages = languages
+	newObj.OutputDirs = outputDirs
+	if options.NewLineType == "dos" {
+		newObj.NewLineType = NewLineType_Dos
+	} else {
+		newObj.NewLineType = NewLineType_Unix
+	}
+
+	return newObj
+}
+
+// generate code of every language for protoDefs, allProtoDefs are all
+// protocols generated in this run, documents only link to them.
+// return generated files or nil if failed
+func (this *CompileTask) GenerateCode(descriptor *ProtocolDescriptor,
+	protoDefs []*ProtocolDef, allProtoDefs []*ProtocolDef) []*OutputFile {
+
+	outputFiles := make([]*OutputFile, 0)
+	for _, language := range this.Languages {
+		files := generateCode(descriptor, protoDefs, allProtoDefs,
+			language, this.OutputDirs[language],
+			this.NewLineType, this.Options.NamespaceDir)
+		if files == nil {
+			return nil
+		}
+		outputFiles = append(outputFiles, files...)
+	}
+
+	return outputFiles
+}
+
+func (this *CompileTask) WriteDepFile(descriptor *ProtocolDescriptor,
+	outputFiles []*OutputFile) bool {
+
+	if this.Options.DepFilePath == "" {
+		return true
+	}
+
+	outputFilePaths := make([]string, 0, len(outputFiles))
+	for _, file := range outputFiles {
+		outputFilePaths = append(outputFilePaths, file.FilePath)
+	}
+
+	return WriteDepFile(this.Options.DepFilePath,
+		outputFilePaths, descriptor)
+}
+
+// make compares mtime of generated files listed in dependency file with
+// protocol files, so unchanged files are touched, otherwise brexc runs
+// again on every build once a protocol file is newer than them
+func (this *CompileTask) TouchUnchangedFiles(outputFiles []*OutputFile) bool {
+	if this.Options.DepFilePath == "" {
+		return true
+	}
+
+	now := time.Now()
+	for _, file := range outputFiles {
+		if file.Result != WriteFileResult_Unchanged {
+			continue
+		}
+		if err := os.Chtimes(file.FilePath, now, now); err != nil {
+			fmt.Fprintf(os.Stderr,
+				"error: touch file %s failed: %s\n",
+				file.FilePath, err.Error())
+			return false
+		}
+	}
+
+	return true
+}
+
+// parse protocols and generate code of every language,
+// required options are checked by caller
+func runCompile(options *CompileOptions) int {
+	task := NewCompileTask(options)
+	if task == nil {
+		return 1
+	}
+
+	// create parser
+	parser := NewProtocolParser()
+	parser.DiagnosticsFormat = options.DiagnosticsFormat
+	if parser.ParseFiles(task.ProtoFilePaths, options.SearchPath) == false {
+		return 1
+	}
+	defer parser.Close()
+
+	// collect protocols to generate
+	protoDefs := getProtoDefsToGenerate(
+		parser.Descriptor, task.ProtoFilePaths, options.WithImports)
+	if protoDefs == nil {
+		return 1
+	}
+
+	// generate code
+	outputFiles := task.GenerateCode(parser.Descriptor, protoDefs, protoDefs)
+	if outputFiles == nil {
+		return 1
+	}
+	printOutputFilesReport(outputFiles)
+
+	// write dependency file
+	if task.TouchUnchangedFiles(outputFiles) == false ||
+		task.WriteDepFile(parser.Descriptor, outputFiles) == false {
+		return 1
+	}
+
+	return 0
+}
+
+// run generator of language over every protocol in protoDefs,
+// return generated files or nil if failed
+func generateCode(descriptor *ProtocolDescriptor,
+	protoDefs []*ProtocolDef, allProtoDefs []*ProtocolDef, language string,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) []*OutputFile {
+
+	// create generator
+	var generator CodeGenerator = nil
+	if language == "cpp" {
+		generator = NewCppCodeGenerator()
+	} else if language == "php" {
+		generator = NewPhpCodeGenerator()
+	} else if language == "csharp" {
+		generator = NewCSharpCodeGenerator()
+	} else if language == "proto3" {
+		generator = NewProto3CodeGenerator()
+	} else if language == "jsonschema" {
+		generator = NewJsonSchemaCodeGenerator()
+	} else if language == "html" {
+		generator = NewHtmlCodeGenerator(allProtoDefs)
+	} else if language == "markdown" {
+		generator = NewMarkdownCodeGenerator(allProtoDefs)
+	} else {
+		generator = NewPluginCodeGenerator(language)
+	}
+	defer generator.Close()
+
+	// descriptor is shared, only switch the protocol to generate
+	mainProtoDef := descriptor.ProtoDef
+	defer func() {
+		descriptor.ProtoDef = mainProtoDef
+	}()
+
+	outputFiles := make([]*OutputFile, 0)
+	for _, protoDef := range protoDefs {
+		descriptor.ProtoDef = protoDef
+		if generator.Generate(descriptor,
+			outputDir, newLineType, useNamespaceDir) == false {
+			return nil
+		}
+		outputFiles = append(outputFiles, generator.OutputFiles()...)
+	}
+
+	return outputFiles
+}
+
+// created and updated files are listed, unchanged ones are only counted
+func printOutputFilesReport(outputFiles []*OutputFile) {
+	createdCount := 0
+	updatedCount := 0
+	unchangedCount := 0
+
+	for _, file := range outputFiles {
+		if file.Result == WriteFileResult_Created {
+			createdCount++
+			fmt.Printf("created: %s\n", file.FilePath)
+		} else if file.Result == WriteFileResult_Updated {
+			updatedCount++
+			fmt.Printf("updated: %s\n", file.FilePath)
+		} else if file.Result == WriteFileResult_Unchanged {
+			unchangedCount++
+		}
+	}
+
+	fmt.Printf("%d created, %d updated, %d unchanged\n",
+		createdCount, updatedCount, unchangedCount)
+}
+
+// expand directories to the xml and text protocol files in them and globs to the matched
+// files, return nil if failed
+func getProtoFilePaths(optProtoFilePaths []string) []string {
+	protoFilePaths := make([]string, 0)
+	checkDuplicate := make(map[string]bool)
+
+	for _, optPath := range optProtoFilePaths {
+		var matchPaths []string
+		if UtilCheckDirExists(optPath) {
+			matchPaths, _ = filepath.Glob(filepath.Join(optPath, "*.xml"))
+			textPaths, _ := filepath.Glob(filepath.Join(optPath,
+				"*"+ProtocolTextFileExtension))
+			matchPaths = append(matchPaths, textPaths...)
+		} else if strings.ContainsAny(optPath, "*?[") {
+			var err error
+			matchPaths, err = filepath.Glob(optPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr,
+					"error: invalid pattern `%s`\n",
+					optPath)
+				return nil
+			}
+		} else if UtilCheckFileExists(optPath) {
+			matchPaths = []string{optPath}
+		} else {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find protocol file `%s`\n",
+				optPath)
+			return nil
+		}
+
+		count := 0
+		for _, path := range matchPaths {
+			if UtilCheckFileExists(path) == false {
+				continue
+			}
+			count++
+
+			fullPath := UtilGetFullPath(path)
+			if checkDuplicate[fullPath] {
+				continue
+			}
+			checkDuplicate[fullPath] = true
+			protoFilePaths = append(protoFilePaths, path)
+		}
+		if count == 0 {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find protocol file in `%s`\n",
+				optPath)
+			return nil
+		}
+	}
+
+	return protoFilePaths
+}
+
+// protocols given on command line in order, then imported protocols
+// sorted by name if withImports, return nil if failed
+func getProtoDefsToGenerate(descriptor *ProtocolDescriptor,
+	protoFilePaths []string, withImports bool) []*ProtocolDef {
+
+	protoDefs := make([]*ProtocolDef, 0)
+	checkDuplicate := make(map[*ProtocolDef]bool)
+
+	for _, path := range protoFilePaths {
+		protoName := UtilGetFileNameWithoutExtension(path)
+		protoDef := descriptor.ImportedProtos[protoName]
+		// protocol name is the file name, so it must be unique
+		if protoDef.FilePath != UtilGetFullPath(path) {
+			fmt.Fprintf(os.Stderr,
+				"error: protocol `%s` in `%s` conflicts with `%s`\n",
+				protoName, path, protoDef.FilePath)
+			return nil
+		}
+		if checkDuplicate[protoDef] {
+			continue
+		}
+		checkDuplicate[protoDef] = true
+		protoDefs = append(protoDefs, protoDef)
+	}
+
+	if withImports {
+		protoNames := make([]string, 0, len(descriptor.ImportedProtos))
+		for name := range descriptor.ImportedProtos {
+			protoNames = append(protoNames, name)
+		}
+		sort.Strings(protoNames)
+
+		for _, name := range protoNames {
+			protoDef := descriptor.ImportedProtos[name]
+			if checkDuplicate[protoDef] {
+				continue
+			}
+			checkDuplicate[protoDef] = true
+			protoDefs = append(protoDefs, protoDef)
+		}
+	}
+
+	return protoDefs
+}
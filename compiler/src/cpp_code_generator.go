@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"path/filepath"
+	"path"
 	"slices"
 	"strings"
 )
@@ -23,21 +23,22 @@ func (this *CppCodeGenerator) Close() {
 
 func (this *CppCodeGenerator) Generate(
 	descriptor *ProtocolDescriptor,
-	outputDir string, newLineType NewLineType) bool {
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
 
-	this.init(descriptor, newLineType)
+	this.init(descriptor, newLineType, useNamespaceDir)
 
-	headerFilePath := filepath.Join(
-		outputDir, this.descriptor.ProtoDef.Name+".h")
+	headerFilePath := this.getOutputFilePath(outputDir, "cpp",
+		this.descriptor.ProtoDef.Name+".h")
 	headerFileContent := this.generateHeaderFile()
-	if UtilWriteAllText(headerFilePath, headerFileContent) == false {
+	if this.writeOutputFile(headerFilePath, headerFileContent) == false {
 		return false
 	}
 
-	sourceFilePath := filepath.Join(
-		outputDir, this.descriptor.ProtoDef.Name+".cc")
+	sourceFilePath := this.getOutputFilePath(outputDir, "cpp",
+		this.descriptor.ProtoDef.Name+".cc")
 	sourceFileContent := this.generateSourceFile()
-	if UtilWriteAllText(sourceFilePath, sourceFileContent) == false {
+	if this.writeOutputFile(sourceFilePath, sourceFileContent) == false {
 		return false
 	}
 
@@ -94,6 +95,18 @@ func (this *CppCodeGenerator) getStructFullQualifiedName(
 	}
 }
 
+func (this *CppCodeGenerator) getEnumDescriptorFullQualifiedName(
+	enumDef *EnumDef) string {
+
+	return this.getEnumFullQualifiedName(enumDef) + "_descriptor"
+}
+
+func (this *CppCodeGenerator) getStructDescriptorFullQualifiedName(
+	structDef *StructDef) string {
+
+	return this.getStructFullQualifiedName(structDef) + "::s_descriptor"
+}
+
 func (this *CppCodeGenerator) getStructFieldCppType(
 	fieldDef *StructFieldDef) string {
 
@@ -156,6 +169,7 @@ func (this *CppCodeGenerator) generateHeaderFile() string {
 	this.writeHeaderFileEnumDecl(&sb)
 	this.writeHeaderFileStructDecl(&sb)
 	this.writeHeaderFileEnumMapDecl(&sb)
+	this.writeHeaderFileProtocolDecl(&sb)
 	this.writeNamespaceDeclEnd(&sb)
 	this.writeHeaderFileIncludeGuardEnd(&sb)
 
@@ -168,6 +182,7 @@ func (this *CppCodeGenerator) generateSourceFile() string {
 	this.writeDontEditComment(&sb)
 	this.writeSourceFileIncludeFileDecl(&sb)
 	this.writeNamespaceDeclStart(&sb)
+	this.writeSourceFileEnumImpl(&sb)
 	this.writeSourceFileStructImpl(&sb)
 	this.writeSourceFileEnumMapImpl(&sb)
 	this.writeNamespaceDeclEnd(&sb)
@@ -265,8 +280,12 @@ func (this *CppCodeGenerator) writeHeaderFileIncludeFileDecl(
 	useStringH := false
 	useVectorH := false
 	useBrickredBaseStructH := false
+	useBrickredDescriptorH := false
 	useOtherProtoH := false
 
+	if len(protoDef.Enums) > 0 {
+		useBrickredDescriptorH = true
+	}
 	if len(protoDef.Structs) > 0 {
 		useCStdDefH = true
 		useBrickredBaseStructH = true
@@ -312,6 +331,7 @@ func (this *CppCodeGenerator) writeHeaderFileIncludeFileDecl(
 		useStringH == false &&
 		useVectorH == false &&
 		useBrickredBaseStructH == false &&
+		useBrickredDescriptorH == false &&
 		useOtherProtoH == false {
 		return
 	}
@@ -336,12 +356,15 @@ func (this *CppCodeGenerator) writeHeaderFileIncludeFileDecl(
 			"#include <vector>")
 	}
 
-	if useBrickredBaseStructH || useOtherProtoH {
+	if useBrickredBaseStructH || useBrickredDescriptorH || useOtherProtoH {
 		this.writeEmptyLine(sb)
 	}
 	if useBrickredBaseStructH {
 		this.writeLine(sb,
 			"#include <brickred/exchange/base_struct.h>")
+	} else if useBrickredDescriptorH {
+		this.writeLine(sb,
+			"#include <brickred/exchange/descriptor.h>")
 	}
 	for _, importDef := range protoDef.Imports {
 		if importDef.IsRefByEnum == false &&
@@ -349,11 +372,19 @@ func (this *CppCodeGenerator) writeHeaderFileIncludeFileDecl(
 			continue
 		}
 		this.writeLineFormat(sb,
-			"#include \"%s.h\"",
-			importDef.ProtoDef.Name)
+			"#include \"%s\"",
+			this.getImportHeaderFilePath(importDef.ProtoDef))
 	}
 }
 
+// relative to output directory, includes namespace directory if used
+func (this *CppCodeGenerator) getImportHeaderFilePath(
+	protoDef *ProtocolDef) string {
+
+	return path.Join(
+		this.getNamespaceDir(protoDef, "cpp"), protoDef.Name+".h")
+}
+
 func (this *CppCodeGenerator) writeHeaderFileClassForwardDecl(
 	sb *strings.Builder) {
 
@@ -436,6 +467,10 @@ func (this *CppCodeGenerator) writeHeaderFileOneEnumDecl(
 
 	this.writeLine(sb,
 		"};")
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"extern const brickred::exchange::EnumDescriptor %s_descriptor;",
+		enumDef.Name)
 }
 
 func (this *CppCodeGenerator) writeHeaderFileStructDecl(
@@ -467,6 +502,9 @@ func (this *CppCodeGenerator) writeHeaderFileOneStructDecl(
 		"    void swap(%s &other);",
 		structDef.Name)
 	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"    static constexpr const char *FINGERPRINT = \"%s\";",
+		FingerprintStruct(structDef))
 	this.writeLineFormat(sb,
 		"    static brickred::exchange::BaseStruct *create() { return new %s(); }",
 		structDef.Name)
@@ -479,6 +517,19 @@ func (this *CppCodeGenerator) writeHeaderFileOneStructDecl(
 		"    int decode(const char *buffer, size_t size) override;")
 	this.writeLine(sb,
 		"    std::string dump() const override;")
+	this.writeLine(sb,
+		"    void to_json_value(brickred::exchange::JsonValue *output) const override;")
+	this.writeLine(sb,
+		"    bool from_json_value(const brickred::exchange::JsonValue &input) override;")
+	this.writeLine(sb,
+		"    bool parse_text_fields(brickred::exchange::TextParser *parser) override;")
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    static const brickred::exchange::StructDescriptor s_descriptor;")
+	this.writeLine(sb,
+		"    const brickred::exchange::StructDescriptor *descriptor() const override { return &s_descriptor; }")
+	this.writeLine(sb,
+		"    void *mutableField(int index) override;")
 	this.writeHeaderFileOneStructDeclOptionalFuncDecl(sb, structDef)
 	this.writeHeaderFileOneStructDeclFieldDecl(sb, structDef)
 	this.writeLine(sb,
@@ -527,6 +578,8 @@ func (this *CppCodeGenerator) writeHeaderFileOneStructDeclOptionalFuncDecl(
 	this.writeEmptyLine(sb)
 	this.writeLine(sb,
 		"private:")
+	this.writeLine(sb,
+		"    uint8_t *mutableHasBits() override { return _has_bits_; }")
 	this.writeLineFormat(sb,
 		"    uint8_t _has_bits_[%d];",
 		structDef.OptionalByteCount)
@@ -595,6 +648,9 @@ func (this *CppCodeGenerator) writeHeaderFileOneEnumMapDecl(
 	this.writeLine(sb,
 		"    struct id;")
 	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"    static constexpr const char *FINGERPRINT = \"%s\";",
+		FingerprintEnumMap(enumMapDef))
 	this.writeLine(sb,
 		"    static brickred::exchange::BaseStruct *create(int id);")
 	this.writeLine(sb,
@@ -631,6 +687,22 @@ func (this *CppCodeGenerator) writeHeaderFileOneEnumMapDeclIdTemplateDecl(
 	}
 }
 
+func (this *CppCodeGenerator) writeHeaderFileProtocolDecl(
+	sb *strings.Builder) {
+
+	protoDef := this.descriptor.ProtoDef
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"struct %sProtocol {",
+		UtilGetCamelCaseName(protoDef.Name))
+	this.writeLineFormat(sb,
+		"    static constexpr const char *FINGERPRINT = \"%s\";",
+		FingerprintProtocol(protoDef))
+	this.writeLine(sb,
+		"};")
+}
+
 func (this *CppCodeGenerator) writeSourceFileIncludeFileDecl(
 	sb *strings.Builder) {
 
@@ -713,11 +785,70 @@ func (this *CppCodeGenerator) writeSourceFileIncludeFileDecl(
 			continue
 		}
 		this.writeLineFormat(sb,
-			"#include \"%s.h\"",
-			importDef.ProtoDef.Name)
+			"#include \"%s\"",
+			this.getImportHeaderFilePath(importDef.ProtoDef))
 	}
 }
 
+func (this *CppCodeGenerator) writeSourceFileEnumImpl(
+	sb *strings.Builder) {
+
+	protoDef := this.descriptor.ProtoDef
+
+	for _, def := range protoDef.Enums {
+		this.writeSourceFileOneEnumImpl(sb, def)
+	}
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneEnumImpl(
+	sb *strings.Builder, enumDef *EnumDef) {
+
+	this.writeSourceFileOneEnumImplDescriptor(sb, enumDef)
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneEnumImplDescriptor(
+	sb *strings.Builder, enumDef *EnumDef) {
+
+	// c++ don't support zero-length array in standard
+	// so items is nullptr when enum is empty
+	itemsName := "nullptr"
+	if len(enumDef.Items) > 0 {
+		itemsName = fmt.Sprintf("%s_descriptor_items", enumDef.Name)
+
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, ""+
+			"static const brickred::exchange::EnumItemDescriptor "+
+			"%s[] = {",
+			itemsName)
+		for _, def := range enumDef.Items {
+			this.writeLineFormat(sb,
+				"    { \"%s\", %d },",
+				def.Name, def.IntValue)
+		}
+		this.writeLine(sb,
+			"};")
+	}
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"const brickred::exchange::EnumDescriptor %s_descriptor = {",
+		enumDef.Name)
+	this.writeLineFormat(sb,
+		"    \"%s\",",
+		enumDef.Name)
+	this.writeLineFormat(sb,
+		"    \"%s.%s\",",
+		enumDef.ParentRef.Name, enumDef.Name)
+	this.writeLineFormat(sb,
+		"    %s,",
+		itemsName)
+	this.writeLineFormat(sb,
+		"    %d,",
+		len(enumDef.Items))
+	this.writeLine(sb,
+		"};")
+}
+
 func (this *CppCodeGenerator) writeSourceFileStructImpl(
 	sb *strings.Builder) {
 
@@ -737,6 +868,11 @@ func (this *CppCodeGenerator) writeSourceFileOneStructImpl(
 	this.writeSourceFileOneStructImplEncodeFunc(sb, structDef)
 	this.writeSourceFileOneStructImplDecodeFunc(sb, structDef)
 	this.writeSourceFileOneStructImplDumpFunc(sb, structDef)
+	this.writeSourceFileOneStructImplParseTextFieldsFunc(sb, structDef)
+	this.writeSourceFileOneStructImplToJsonValueFunc(sb, structDef)
+	this.writeSourceFileOneStructImplFromJsonValueFunc(sb, structDef)
+	this.writeSourceFileOneStructImplDescriptor(sb, structDef)
+	this.writeSourceFileOneStructImplMutableFieldFunc(sb, structDef)
 }
 
 func (this *CppCodeGenerator) writeSourceFileOneStructImplConstructor(
@@ -1225,11 +1361,11 @@ func (this *CppCodeGenerator) writeSourceFileOneStructImplDumpFuncWriteStatement
 	} else if checkType == StructFieldType_String {
 		if isList {
 			writeStatement = fmt.Sprintf(
-				"ss << \"%s: \\\"\" << this->%s[i] << \"\\\" \"",
+				"ss << \"%s: \\\"\" << dumpString(this->%s[i]) << \"\\\" \"",
 				fieldDef.Name, fieldDef.Name)
 		} else {
 			writeStatement = fmt.Sprintf(
-				"ss << \"%s: \\\"\" << this->%s << \"\\\" \"",
+				"ss << \"%s: \\\"\" << dumpString(this->%s) << \"\\\" \"",
 				fieldDef.Name, fieldDef.Name)
 		}
 	} else if checkType == StructFieldType_Bytes {
@@ -1282,6 +1418,399 @@ func (this *CppCodeGenerator) writeSourceFileOneStructImplDumpFuncWriteStatement
 	}
 }
 
+func (this *CppCodeGenerator) writeSourceFileOneStructImplParseTextFieldsFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"bool %s::parse_text_fields(brickred::exchange::TextParser *parser)",
+		structDef.Name)
+	this.writeLine(sb,
+		"{")
+	this.writeLineFormat(sb,
+		"    %s().swap(*this);",
+		structDef.Name)
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    std::string name;")
+
+	if len(structDef.Fields) <= 0 {
+		this.writeLine(sb,
+			"    if (parser->readFieldName(&name)) {")
+		this.writeLine(sb,
+			"        return false;")
+		this.writeLine(sb,
+			"    }")
+	} else {
+		this.writeLine(sb,
+			"    while (parser->readFieldName(&name)) {")
+		for i, def := range structDef.Fields {
+			this.writeSourceFileOneStructImplParseTextFieldsFuncReadStatement(
+				sb, def, i == 0)
+		}
+		this.writeLine(sb,
+			"        } else {")
+		this.writeLine(sb,
+			"            return false;")
+		this.writeLine(sb,
+			"        }")
+		this.writeLine(sb,
+			"    }")
+	}
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    return parser->hasError() == false;")
+	this.writeLine(sb,
+		"}")
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplParseTextFieldsFuncReadStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef, isFirstField bool) {
+
+	if isFirstField {
+		this.writeLineFormat(sb,
+			"        if (name == \"%s\") {",
+			fieldDef.Name)
+	} else {
+		this.writeLineFormat(sb,
+			"        } else if (name == \"%s\") {",
+			fieldDef.Name)
+	}
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"            set_has_%s();",
+			fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	var readFunc string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		readFunc = "READ_TEXT_INT64"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		readFunc = "READ_TEXT_UINT64"
+	} else if StructFieldTypeIsInteger(checkType) ||
+		checkType == StructFieldType_Enum {
+		readFunc = "READ_TEXT_INT"
+	} else if checkType == StructFieldType_String {
+		readFunc = "READ_TEXT_STRING"
+	} else if checkType == StructFieldType_Bytes {
+		readFunc = "READ_TEXT_BYTES"
+	} else if checkType == StructFieldType_Bool {
+		readFunc = "READ_TEXT_BOOL"
+	} else if checkType == StructFieldType_Struct {
+		readFunc = "READ_TEXT_STRUCT"
+	}
+
+	if isList {
+		this.writeLineFormat(sb,
+			"            READ_TEXT_LIST_ITEM(this->%s, %s);",
+			fieldDef.Name, readFunc)
+	} else {
+		this.writeLineFormat(sb,
+			"            %s(this->%s);",
+			readFunc, fieldDef.Name)
+	}
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplToJsonValueFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb, ""+
+		"void %s::to_json_value("+
+		"brickred::exchange::JsonValue *output) const",
+		structDef.Name)
+	this.writeLine(sb,
+		"{")
+	this.writeLine(sb,
+		"    output->setObject();")
+
+	if len(structDef.Fields) > 0 {
+		this.writeEmptyLine(sb)
+		for _, def := range structDef.Fields {
+			this.writeSourceFileOneStructImplToJsonValueFuncWriteStatement(
+				sb, def)
+		}
+	}
+
+	this.writeLine(sb,
+		"}")
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplToJsonValueFuncWriteStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef) {
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"    if (has_%s()) {",
+			fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	var writeFunc string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		writeFunc = "WRITE_JSON_INT64"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		writeFunc = "WRITE_JSON_UINT64"
+	} else if StructFieldTypeIsInteger(checkType) ||
+		checkType == StructFieldType_Enum {
+		writeFunc = "WRITE_JSON_INT"
+	} else if checkType == StructFieldType_String {
+		writeFunc = "WRITE_JSON_STRING"
+	} else if checkType == StructFieldType_Bytes {
+		writeFunc = "WRITE_JSON_BYTES"
+	} else if checkType == StructFieldType_Bool {
+		writeFunc = "WRITE_JSON_BOOL"
+	} else if checkType == StructFieldType_Struct {
+		writeFunc = "WRITE_JSON_STRUCT"
+	}
+
+	var indent string
+	if fieldDef.IsOptional {
+		indent = "        "
+	} else {
+		indent = "    "
+	}
+	if isList {
+		this.writeLineFormat(sb,
+			"%sWRITE_JSON_LIST(output->addMember(\"%s\"), this->%s, %s);",
+			indent, fieldDef.Name, fieldDef.Name, writeFunc)
+	} else {
+		this.writeLineFormat(sb,
+			"%s%s(output->addMember(\"%s\"), this->%s);",
+			indent, writeFunc, fieldDef.Name, fieldDef.Name)
+	}
+
+	if fieldDef.IsOptional {
+		this.writeLine(sb,
+			"    }")
+	}
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplFromJsonValueFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb, ""+
+		"bool %s::from_json_value("+
+		"const brickred::exchange::JsonValue &input)",
+		structDef.Name)
+	this.writeLine(sb,
+		"{")
+	this.writeLine(sb,
+		"    if (input.isStruct() == false) {")
+	this.writeLine(sb,
+		"        return false;")
+	this.writeLine(sb,
+		"    }")
+
+	if len(structDef.Fields) > 0 {
+		this.writeEmptyLine(sb)
+		if structDef.OptionalByteCount > 0 {
+			this.writeLine(sb,
+				"    ::memset(_has_bits_, 0, sizeof(_has_bits_));")
+			this.writeEmptyLine(sb)
+		}
+		for _, def := range structDef.Fields {
+			this.writeSourceFileOneStructImplFromJsonValueFuncReadStatement(
+				sb, def)
+		}
+	}
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    return true;")
+	this.writeLine(sb,
+		"}")
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplFromJsonValueFuncReadStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef) {
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"    if (input.hasMember(\"%s\")) {",
+			fieldDef.Name)
+		this.writeLineFormat(sb,
+			"        set_has_%s();",
+			fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	var readFunc string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		readFunc = "READ_JSON_INT64"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		readFunc = "READ_JSON_UINT64"
+	} else if StructFieldTypeIsInteger(checkType) ||
+		checkType == StructFieldType_Enum {
+		readFunc = "READ_JSON_INT"
+	} else if checkType == StructFieldType_String {
+		readFunc = "READ_JSON_STRING"
+	} else if checkType == StructFieldType_Bytes {
+		readFunc = "READ_JSON_BYTES"
+	} else if checkType == StructFieldType_Bool {
+		readFunc = "READ_JSON_BOOL"
+	} else if checkType == StructFieldType_Struct {
+		readFunc = "READ_JSON_STRUCT"
+	}
+
+	var indent string
+	if fieldDef.IsOptional {
+		indent = "        "
+	} else {
+		indent = "    "
+	}
+	if isList {
+		this.writeLineFormat(sb, ""+
+			"%sREAD_JSON_LIST(input.findMember(\"%s\"), "+
+			"this->%s, %s);",
+			indent, fieldDef.Name, fieldDef.Name, readFunc)
+	} else {
+		this.writeLineFormat(sb,
+			"%s%s(input.findMember(\"%s\"), this->%s);",
+			indent, readFunc, fieldDef.Name, fieldDef.Name)
+	}
+
+	if fieldDef.IsOptional {
+		this.writeLine(sb,
+			"    }")
+	}
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplDescriptor(
+	sb *strings.Builder, structDef *StructDef) {
+
+	// c++ don't support zero-length array in standard
+	// so fields is nullptr when struct is empty
+	fieldsName := "nullptr"
+	if len(structDef.Fields) > 0 {
+		fieldsName = fmt.Sprintf("%s_descriptor_fields", structDef.Name)
+
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, ""+
+			"static const brickred::exchange::FieldDescriptor "+
+			"%s[] = {",
+			fieldsName)
+		for _, def := range structDef.Fields {
+			isList := def.Type == StructFieldType_List
+			var checkType StructFieldType
+			if isList {
+				checkType = def.ListType
+			} else {
+				checkType = def.Type
+			}
+
+			optionalIndex := -1
+			if def.IsOptional {
+				optionalIndex = def.OptionalFieldIndex
+			}
+			refEnum := "nullptr"
+			if def.RefEnumDef != nil {
+				refEnum = "&" +
+					this.getEnumDescriptorFullQualifiedName(def.RefEnumDef)
+			}
+			refStruct := "nullptr"
+			if def.RefStructDef != nil {
+				refStruct = "&" +
+					this.getStructDescriptorFullQualifiedName(def.RefStructDef)
+			}
+
+			this.writeLineFormat(sb, ""+
+				"    { \"%s\", brickred::exchange::FieldType::%s, "+
+				"%t, %d, %s, %s },",
+				def.Name,
+				strings.ToUpper(StructFieldTypeGetName(checkType)),
+				isList, optionalIndex, refEnum, refStruct)
+		}
+		this.writeLine(sb,
+			"};")
+	}
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"const brickred::exchange::StructDescriptor %s::s_descriptor = {",
+		structDef.Name)
+	this.writeLineFormat(sb,
+		"    \"%s\",",
+		structDef.Name)
+	this.writeLineFormat(sb,
+		"    \"%s.%s\",",
+		structDef.ParentRef.Name, structDef.Name)
+	this.writeLineFormat(sb,
+		"    %s,",
+		fieldsName)
+	this.writeLineFormat(sb,
+		"    %d,",
+		len(structDef.Fields))
+	this.writeLineFormat(sb,
+		"    &%s::create,",
+		structDef.Name)
+	this.writeLine(sb,
+		"};")
+}
+
+func (this *CppCodeGenerator) writeSourceFileOneStructImplMutableFieldFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"void *%s::mutableField(int index)",
+		structDef.Name)
+	this.writeLine(sb,
+		"{")
+
+	if len(structDef.Fields) <= 0 {
+		this.writeLine(sb,
+			"    (void)index;")
+		this.writeLine(sb,
+			"    return nullptr;")
+	} else {
+		this.writeLine(sb,
+			"    switch (index) {")
+		for i, def := range structDef.Fields {
+			this.writeLineFormat(sb,
+				"    case %d: return &this->%s;",
+				i, def.Name)
+		}
+		this.writeLine(sb,
+			"    default: return nullptr;")
+		this.writeLine(sb,
+			"    }")
+	}
+
+	this.writeLine(sb,
+		"}")
+}
+
 func (this *CppCodeGenerator) writeSourceFileEnumMapImpl(
 	sb *strings.Builder) {
 
@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"path/filepath"
 	"strings"
 )
 
@@ -22,14 +21,15 @@ func (this *CSharpCodeGenerator) Close() {
 
 func (this *CSharpCodeGenerator) Generate(
 	descriptor *ProtocolDescriptor,
-	outputDir string, newLineType NewLineType) bool {
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
 
-	this.init(descriptor, newLineType)
+	this.init(descriptor, newLineType, useNamespaceDir)
 
-	sourceFilePath := filepath.Join(
-		outputDir, this.descriptor.ProtoDef.Name+".cs")
+	sourceFilePath := this.getOutputFilePath(outputDir, "csharp",
+		this.descriptor.ProtoDef.Name+".cs")
 	sourceFileContent := this.generateSourceFile()
-	if UtilWriteAllText(sourceFilePath, sourceFileContent) == false {
+	if this.writeOutputFile(sourceFilePath, sourceFileContent) == false {
 		return false
 	}
 
@@ -86,6 +86,12 @@ func (this *CSharpCodeGenerator) getStructFullQualifiedName(
 	}
 }
 
+func (this *CSharpCodeGenerator) getEnumDescriptorFullQualifiedName(
+	enumDef *EnumDef) string {
+
+	return this.getEnumFullQualifiedName(enumDef) + "Descriptor.Instance"
+}
+
 func (this *CSharpCodeGenerator) getStructFieldCSharpType(
 	fieldDef *StructFieldDef) string {
 
@@ -182,6 +188,7 @@ func (this *CSharpCodeGenerator) generateSourceFile() string {
 	this.writeEnumDecl(&sb, &isFirstDecl, indent)
 	this.writeStructDecl(&sb, &isFirstDecl, indent)
 	this.writeEnumMapDecl(&sb, &isFirstDecl, indent)
+	this.writeProtocolDecl(&sb, &isFirstDecl, indent)
 
 	this.writeNamespaceDeclEnd(&sb)
 
@@ -220,7 +227,8 @@ func (this *CSharpCodeGenerator) writeUseStatementsDecl(
 	useSystem := false
 	useSystemCollectionsGeneric := false
 
-	if len(protoDef.Structs) > 0 ||
+	if len(protoDef.Enums) > 0 ||
+		len(protoDef.Structs) > 0 ||
 		len(protoDef.EnumMaps) > 0 {
 		useBrickredExchange = true
 	}
@@ -351,6 +359,41 @@ func (this *CSharpCodeGenerator) writeOneEnumDecl(
 	this.writeLineFormat(sb,
 		"%s}",
 		indent)
+
+	this.writeOneEnumDeclDescriptor(sb, enumDef, indent)
+}
+
+func (this *CSharpCodeGenerator) writeOneEnumDeclDescriptor(
+	sb *strings.Builder, enumDef *EnumDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%spublic static class %sDescriptor",
+		indent, enumDef.Name)
+	this.writeLineFormat(sb,
+		"%s{",
+		indent)
+	this.writeLineFormat(sb, ""+
+		"%s    public static readonly EnumDescriptor Instance = "+
+		"new EnumDescriptor(",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        \"%s\", \"%s.%s\",",
+		indent, enumDef.Name, enumDef.ParentRef.Name, enumDef.Name)
+	this.writeLineFormat(sb,
+		"%s        new EnumItemDescriptor[] {",
+		indent)
+	for _, def := range enumDef.Items {
+		this.writeLineFormat(sb,
+			"%s            new EnumItemDescriptor(\"%s\", %d),",
+			indent, def.Name, def.IntValue)
+	}
+	this.writeLineFormat(sb,
+		"%s        });",
+		indent)
+	this.writeLineFormat(sb,
+		"%s}",
+		indent)
 }
 
 func (this *CSharpCodeGenerator) writeStructDecl(
@@ -387,6 +430,12 @@ func (this *CSharpCodeGenerator) writeOneStructDecl(
 	this.writeOneStructDeclEncodeToStreamFunc(sb, structDef, indent)
 	this.writeOneStructDeclDecodeFromStreamFunc(sb, structDef, indent)
 	this.writeOneStructDeclDumpFunc(sb, structDef, indent)
+	this.writeOneStructDeclParseTextFieldsFunc(sb, structDef, indent)
+	this.writeOneStructDeclToJsonValueFunc(sb, structDef, indent)
+	this.writeOneStructDeclFromJsonValueFunc(sb, structDef, indent)
+	this.writeOneStructDeclDescriptorFunc(sb, structDef, indent)
+	this.writeOneStructDeclGetFieldFunc(sb, structDef, indent)
+	this.writeOneStructDeclSetFieldFunc(sb, structDef, indent)
 	this.writeOneStructDeclOptionalFunc(sb, structDef, indent)
 	this.writeLineFormat(sb,
 		"%s}",
@@ -418,6 +467,10 @@ func (this *CSharpCodeGenerator) writeOneStructDeclCreateFunc(
 	if len(structDef.Fields) > 0 {
 		this.writeEmptyLine(sb)
 	}
+	this.writeLineFormat(sb,
+		"%s    public const string FINGERPRINT = \"%s\";",
+		indent, FingerprintStruct(structDef))
+	this.writeEmptyLine(sb)
 	this.writeLineFormat(sb,
 		"%s    public static %s Create()",
 		indent, structDef.Name)
@@ -924,12 +977,14 @@ func (this *CSharpCodeGenerator) writeOneStructDeclDumpFuncWriteStatement(
 		}
 	} else if checkType == StructFieldType_String {
 		if isList {
-			writeStatement = fmt.Sprintf(
-				"sb.Add(string.Format(\"%s: \\\"{0}\\\"\", this.%s[i]))",
+			writeStatement = fmt.Sprintf(""+
+				"sb.Add(string.Format(\"%s: \\\"{0}\\\"\", "+
+				"DumpString(this.%s[i])))",
 				fieldDef.Name, fieldDef.Name)
 		} else {
-			writeStatement = fmt.Sprintf(
-				"sb.Add(string.Format(\"%s: \\\"{0}\\\"\", this.%s))",
+			writeStatement = fmt.Sprintf(""+
+				"sb.Add(string.Format(\"%s: \\\"{0}\\\"\", "+
+				"DumpString(this.%s)))",
 				fieldDef.Name, fieldDef.Name)
 		}
 	} else if checkType == StructFieldType_Bytes {
@@ -1007,6 +1062,575 @@ func (this *CSharpCodeGenerator) writeOneStructDeclDumpFuncWriteStatement(
 	}
 }
 
+func (this *CSharpCodeGenerator) writeOneStructDeclParseTextFieldsFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public override void ParseTextFields(TextParser parser)",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+
+	if len(structDef.Fields) > 0 {
+		if structDef.OptionalByteCount > 0 {
+			this.writeLineFormat(sb,
+				"%s        for (int i = 0; i < %d; ++i) {",
+				indent, structDef.OptionalByteCount)
+			this.writeLineFormat(sb,
+				"%s            this._has_bits_[i] = 0;",
+				indent)
+			this.writeLineFormat(sb,
+				"%s        }",
+				indent)
+		}
+		for _, def := range structDef.Fields {
+			this.writeLineFormat(sb,
+				"%s        this.%s = %s;",
+				indent, def.Name,
+				this.getStructFieldCSharpTypeDefaultValue(def))
+		}
+		this.writeEmptyLine(sb)
+	}
+
+	this.writeLineFormat(sb,
+		"%s        string name;",
+		indent)
+	if len(structDef.Fields) <= 0 {
+		this.writeLineFormat(sb,
+			"%s        if (parser.ReadFieldName(out name)) {",
+			indent)
+		this.writeLineFormat(sb,
+			"%s            throw parser.UnknownField(name);",
+			indent)
+		this.writeLineFormat(sb,
+			"%s        }",
+			indent)
+	} else {
+		this.writeLineFormat(sb,
+			"%s        while (parser.ReadFieldName(out name)) {",
+			indent)
+		for i, def := range structDef.Fields {
+			this.writeOneStructDeclParseTextFieldsFuncReadStatement(
+				sb, def, i == 0, indent)
+		}
+		this.writeLineFormat(sb,
+			"%s            } else {",
+			indent)
+		this.writeLineFormat(sb,
+			"%s                throw parser.UnknownField(name);",
+			indent)
+		this.writeLineFormat(sb,
+			"%s            }",
+			indent)
+		this.writeLineFormat(sb,
+			"%s        }",
+			indent)
+	}
+
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclParseTextFieldsFuncReadStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef,
+	isFirstField bool, indent string) {
+
+	if isFirstField {
+		this.writeLineFormat(sb,
+			"%s            if (name == \"%s\") {",
+			indent, fieldDef.Name)
+	} else {
+		this.writeLineFormat(sb,
+			"%s            } else if (name == \"%s\") {",
+			indent, fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	var readStatement string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		readStatement = "parser.ReadInt64()"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		readStatement = "parser.ReadUInt64()"
+	} else if StructFieldTypeIsInteger(checkType) {
+		intType := strings.TrimSuffix(strings.TrimPrefix(
+			this.getStructFieldCSharpType(fieldDef), "List<"), ">")
+		readStatement = "(" + intType + ")parser.ReadInt(" +
+			intType + ".MinValue, " + intType + ".MaxValue)"
+	} else if checkType == StructFieldType_Enum {
+		readStatement = "(" +
+			this.getEnumFullQualifiedName(fieldDef.RefEnumDef) +
+			")parser.ReadInt(int.MinValue, int.MaxValue)"
+	} else if checkType == StructFieldType_String {
+		readStatement = "parser.ReadString()"
+	} else if checkType == StructFieldType_Bytes {
+		readStatement = "parser.ReadBytes()"
+	} else if checkType == StructFieldType_Bool {
+		readStatement = "parser.ReadBool()"
+	} else if checkType == StructFieldType_Struct {
+		readStatement = "parser.ReadStruct<" +
+			this.getStructFullQualifiedName(fieldDef.RefStructDef) + ">()"
+	}
+
+	if isList {
+		this.writeLineFormat(sb,
+			"%s                this.%s.Add(%s);",
+			indent, fieldDef.Name, readStatement)
+	} else {
+		this.writeLineFormat(sb,
+			"%s                this.%s = %s;",
+			indent, fieldDef.Name, readStatement)
+	}
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"%s                set_has_%s();",
+			indent, fieldDef.Name)
+	}
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclToJsonValueFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public override void ToJsonValue(JsonValue output)",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        output.SetObject();",
+		indent)
+
+	if len(structDef.Fields) > 0 {
+		this.writeEmptyLine(sb)
+		for _, def := range structDef.Fields {
+			this.writeOneStructDeclToJsonValueFuncWriteStatement(
+				sb, def, indent)
+		}
+	}
+
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclToJsonValueFuncWriteStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef, indent string) {
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"%s        if (has_%s()) {",
+			indent, fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	// first %s is json value, second %s is field value
+	var writeStatement string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		writeStatement = "%s.SetInt64(%s)"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		writeStatement = "%s.SetUInt64(%s)"
+	} else if StructFieldTypeIsInteger(checkType) {
+		writeStatement = "%s.SetInt(%s)"
+	} else if checkType == StructFieldType_Enum {
+		writeStatement = "%s.SetInt((int)%s)"
+	} else if checkType == StructFieldType_String {
+		writeStatement = "%s.SetString(%s)"
+	} else if checkType == StructFieldType_Bytes {
+		writeStatement = "%s.SetBytes(%s)"
+	} else if checkType == StructFieldType_Bool {
+		writeStatement = "%s.SetBool(%s)"
+	}
+
+	var indent2 string
+	if fieldDef.IsOptional {
+		indent2 = "            "
+	} else {
+		indent2 = "        "
+	}
+	if isList {
+		var indent3 string
+		if fieldDef.IsOptional == false {
+			indent3 = "    "
+		} else {
+			indent3 = ""
+		}
+		if fieldDef.IsOptional == false {
+			this.writeLineFormat(sb,
+				"%s%s{",
+				indent, indent2)
+		}
+		this.writeLineFormat(sb,
+			"%s%s%sJsonValue v = output.AddMember(\"%s\");",
+			indent, indent2, indent3, fieldDef.Name)
+		this.writeLineFormat(sb,
+			"%s%s%sv.SetArray();",
+			indent, indent2, indent3)
+		this.writeLineFormat(sb,
+			"%s%s%sfor (int i = 0; i < this.%s.Count; ++i) {",
+			indent, indent2, indent3, fieldDef.Name)
+
+		if checkType == StructFieldType_Struct {
+			this.writeLineFormat(sb,
+				"%s%s%s    this.%s[i].ToJsonValue(v.Append());",
+				indent, indent2, indent3, fieldDef.Name)
+		} else {
+			this.writeLineFormat(sb,
+				"%s%s%s    %s;",
+				indent, indent2, indent3,
+				fmt.Sprintf(writeStatement,
+					"v.Append()", "this."+fieldDef.Name+"[i]"))
+		}
+
+		this.writeLineFormat(sb,
+			"%s%s%s}",
+			indent, indent2, indent3)
+		if fieldDef.IsOptional == false {
+			this.writeLineFormat(sb,
+				"%s%s}",
+				indent, indent2)
+		}
+	} else {
+		if checkType == StructFieldType_Struct {
+			this.writeLineFormat(sb,
+				"%s%sthis.%s.ToJsonValue(output.AddMember(\"%s\"));",
+				indent, indent2, fieldDef.Name, fieldDef.Name)
+		} else {
+			this.writeLineFormat(sb,
+				"%s%s%s;",
+				indent, indent2,
+				fmt.Sprintf(writeStatement,
+					"output.AddMember(\""+fieldDef.Name+"\")",
+					"this."+fieldDef.Name))
+		}
+	}
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"%s        }",
+			indent)
+	}
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclFromJsonValueFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public override void FromJsonValue(JsonValue input)",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        input.CheckStruct();",
+		indent)
+
+	if len(structDef.Fields) > 0 {
+		this.writeEmptyLine(sb)
+		if structDef.OptionalByteCount > 0 {
+			this.writeLineFormat(sb,
+				"%s        for (int i = 0; i < %d; ++i) {",
+				indent, structDef.OptionalByteCount)
+			this.writeLineFormat(sb,
+				"%s            this._has_bits_[i] = 0;",
+				indent)
+			this.writeLineFormat(sb,
+				"%s        }",
+				indent)
+			this.writeEmptyLine(sb)
+		}
+		for _, def := range structDef.Fields {
+			this.writeOneStructDeclFromJsonValueFuncReadStatement(
+				sb, def, indent)
+		}
+	}
+
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclFromJsonValueFuncReadStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef, indent string) {
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"%s        if (input.HasMember(\"%s\")) {",
+			indent, fieldDef.Name)
+		this.writeLineFormat(sb,
+			"%s            set_has_%s();",
+			indent, fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	// %s is json value
+	var readStatement string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		readStatement = "%s.GetInt64()"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		readStatement = "%s.GetUInt64()"
+	} else if StructFieldTypeIsInteger(checkType) {
+		intType := strings.TrimSuffix(strings.TrimPrefix(
+			this.getStructFieldCSharpType(fieldDef), "List<"), ">")
+		readStatement = "(" + intType + ")%s.GetInt(" +
+			intType + ".MinValue, " + intType + ".MaxValue)"
+	} else if checkType == StructFieldType_Enum {
+		readStatement = "(" +
+			this.getEnumFullQualifiedName(fieldDef.RefEnumDef) +
+			")%s.GetInt(int.MinValue, int.MaxValue)"
+	} else if checkType == StructFieldType_String {
+		readStatement = "%s.GetString()"
+	} else if checkType == StructFieldType_Bytes {
+		readStatement = "%s.GetBytes()"
+	} else if checkType == StructFieldType_Bool {
+		readStatement = "%s.GetBool()"
+	} else if checkType == StructFieldType_Struct {
+		readStatement = "%s.GetStruct<" +
+			this.getStructFullQualifiedName(fieldDef.RefStructDef) + ">()"
+	}
+
+	var indent2 string
+	if fieldDef.IsOptional {
+		indent2 = "            "
+	} else {
+		indent2 = "        "
+	}
+	if isList {
+		var indent3 string
+		if fieldDef.IsOptional == false {
+			indent3 = "    "
+		} else {
+			indent3 = ""
+		}
+		if fieldDef.IsOptional == false {
+			this.writeLineFormat(sb,
+				"%s%s{",
+				indent, indent2)
+		}
+		this.writeLineFormat(sb,
+			"%s%s%sJsonValue v = input.GetMember(\"%s\");",
+			indent, indent2, indent3, fieldDef.Name)
+		this.writeLineFormat(sb,
+			"%s%s%sv.CheckType(JsonValue.ValueType.ARRAY);",
+			indent, indent2, indent3)
+		this.writeLineFormat(sb,
+			"%s%s%sthis.%s.Clear();",
+			indent, indent2, indent3, fieldDef.Name)
+		this.writeLineFormat(sb,
+			"%s%s%sfor (int i = 0; i < v.Count; ++i) {",
+			indent, indent2, indent3)
+		this.writeLineFormat(sb,
+			"%s%s%s    this.%s.Add(%s);",
+			indent, indent2, indent3, fieldDef.Name,
+			fmt.Sprintf(readStatement, "v.At(i)"))
+		this.writeLineFormat(sb,
+			"%s%s%s}",
+			indent, indent2, indent3)
+		if fieldDef.IsOptional == false {
+			this.writeLineFormat(sb,
+				"%s%s}",
+				indent, indent2)
+		}
+	} else {
+		this.writeLineFormat(sb,
+			"%s%sthis.%s = %s;",
+			indent, indent2, fieldDef.Name,
+			fmt.Sprintf(readStatement,
+				"input.GetMember(\""+fieldDef.Name+"\")"))
+	}
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"%s        }",
+			indent)
+	}
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclDescriptorFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb, ""+
+		"%s    private static readonly StructDescriptor s_descriptor_ = "+
+		"new StructDescriptor(",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        \"%s\", \"%s.%s\",",
+		indent, structDef.Name, structDef.ParentRef.Name, structDef.Name)
+	this.writeLineFormat(sb,
+		"%s        new FieldDescriptor[] {",
+		indent)
+	for _, def := range structDef.Fields {
+		isList := def.Type == StructFieldType_List
+		var checkType StructFieldType
+		if isList {
+			checkType = def.ListType
+		} else {
+			checkType = def.Type
+		}
+
+		optionalIndex := -1
+		if def.IsOptional {
+			optionalIndex = def.OptionalFieldIndex
+		}
+		refEnum := "null"
+		if def.RefEnumDef != nil {
+			refEnum = this.getEnumDescriptorFullQualifiedName(def.RefEnumDef)
+		}
+		refStruct := "null"
+		if def.RefStructDef != nil {
+			refStruct = this.getStructFullQualifiedName(def.RefStructDef) +
+				".GetStaticDescriptor()"
+		}
+
+		this.writeLineFormat(sb, ""+
+			"%s            new FieldDescriptor(\"%s\", FieldType.%s, "+
+			"%t, %d, %s, %s),",
+			indent, def.Name,
+			strings.ToUpper(StructFieldTypeGetName(checkType)),
+			isList, optionalIndex, refEnum, refStruct)
+	}
+	this.writeLineFormat(sb,
+		"%s        },",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        Create);",
+		indent)
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public static StructDescriptor GetStaticDescriptor()",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        return s_descriptor_;",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public override StructDescriptor GetDescriptor()",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        return s_descriptor_;",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+
+	if structDef.OptionalByteCount > 0 {
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb,
+			"%s    protected override byte[] GetHasBits()",
+			indent)
+		this.writeLineFormat(sb,
+			"%s    {",
+			indent)
+		this.writeLineFormat(sb,
+			"%s        return this._has_bits_;",
+			indent)
+		this.writeLineFormat(sb,
+			"%s    }",
+			indent)
+	}
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclGetFieldFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    public override object GetField(int index)",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        switch (index) {",
+		indent)
+	for i, def := range structDef.Fields {
+		this.writeLineFormat(sb,
+			"%s        case %d: return this.%s;",
+			indent, i, def.Name)
+	}
+	this.writeLineFormat(sb,
+		"%s        default: return null;",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        }",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+}
+
+func (this *CSharpCodeGenerator) writeOneStructDeclSetFieldFunc(
+	sb *strings.Builder, structDef *StructDef, indent string) {
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"%s    protected override void SetFieldInternal(int index, object value)",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    {",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        switch (index) {",
+		indent)
+	for i, def := range structDef.Fields {
+		this.writeLineFormat(sb,
+			"%s        case %d: this.%s = (%s)value; break;",
+			indent, i, def.Name, this.getStructFieldCSharpType(def))
+	}
+	this.writeLineFormat(sb,
+		"%s        default: break;",
+		indent)
+	this.writeLineFormat(sb,
+		"%s        }",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    }",
+		indent)
+}
+
 func (this *CSharpCodeGenerator) writeOneStructDeclOptionalFunc(
 	sb *strings.Builder, structDef *StructDef, indent string) {
 
@@ -1127,6 +1751,10 @@ func (this *CSharpCodeGenerator) writeOneEnumMapDecl(
 		this.writeEmptyLine(sb)
 	}
 
+	this.writeLineFormat(sb,
+		"%s    public const string FINGERPRINT = \"%s\";",
+		indent, FingerprintEnumMap(enumMapDef))
+	this.writeEmptyLine(sb)
 	this.writeLineFormat(sb,
 		"%s    private static int[] s_id_list_ = {",
 		indent)
@@ -1241,3 +1869,28 @@ func (this *CSharpCodeGenerator) writeOneEnumMapDecl(
 		"%s}",
 		indent)
 }
+
+func (this *CSharpCodeGenerator) writeProtocolDecl(
+	sb *strings.Builder, isFirstDecl *bool, indent string) {
+
+	protoDef := this.descriptor.ProtoDef
+
+	if *isFirstDecl == true {
+		*isFirstDecl = false
+	} else {
+		this.writeEmptyLine(sb)
+	}
+
+	this.writeLineFormat(sb,
+		"%spublic static class %sProtocol",
+		indent, UtilGetCamelCaseName(protoDef.Name))
+	this.writeLineFormat(sb,
+		"%s{",
+		indent)
+	this.writeLineFormat(sb,
+		"%s    public const string FINGERPRINT = \"%s\";",
+		indent, FingerprintProtocol(protoDef))
+	this.writeLineFormat(sb,
+		"%s}",
+		indent)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printDecodeUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"decode binary from stdin by protocol schema\n"+
+		"usage: %s decode "+
+		"-f <protocol_file> "+
+		"(-t <struct> | --enum-map <enum_map> --id <id>)"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"    [-i <input_file>] default is stdin\n"+
+		"    [--format <format>] (json|text) default is json\n"+
+		"struct and enum_map name can be prefixed by imported protocol "+
+		"name, e.g. attr.Attr\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runDecodeCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePath string
+	var optSearchPath []string
+	var optStructName string
+	var optEnumMapName string
+	var optId int
+	var optInputFilePath string
+	var optFormat string
+
+	flagSet := flag.NewFlagSet("decode", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVarP(&optStructName, "type", "t", "", "")
+	flagSet.StringVar(&optEnumMapName, "enum-map", "", "")
+	flagSet.IntVar(&optId, "id", -1, "")
+	flagSet.StringVarP(&optInputFilePath, "input", "i", "", "")
+	flagSet.StringVar(&optFormat, "format", "", "")
+
+	if flagSet.Parse(args) != nil {
+		printDecodeUsage()
+		return 1
+	}
+	if optHelp {
+		printDecodeUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if optProtoFilePath == "" ||
+		(optStructName == "") == (optEnumMapName == "") {
+		printDecodeUsage()
+		return 1
+	}
+
+	// -- option default value
+	if optFormat == "" {
+		optFormat = "json"
+	}
+
+	// -- check option proto_file_path
+	if UtilCheckFileExists(optProtoFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find protocol file `%s`\n",
+			optProtoFilePath)
+		return 1
+	}
+
+	// -- check option enum_map
+	if optEnumMapName != "" && flagSet.Changed("id") == false {
+		fmt.Fprintf(os.Stderr,
+			"error: option --id is required by --enum-map\n")
+		return 1
+	}
+
+	// -- check option format
+	if optFormat != "json" &&
+		optFormat != "text" {
+		fmt.Fprintf(os.Stderr,
+			"error: format `%s` is invalid\n",
+			optFormat)
+		return 1
+	}
+
+	// create parser
+	parser := NewProtocolParser()
+	if parser.Parse(optProtoFilePath, optSearchPath) == false {
+		return 1
+	}
+	defer parser.Close()
+
+	// find struct to decode
+	structDef := findStructDefByOptions(parser.Descriptor,
+		optStructName, optEnumMapName, optId)
+	if structDef == nil {
+		return 1
+	}
+
+	// read input
+	var input []byte
+	var err error
+	if optInputFilePath == "" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(optInputFilePath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: read input failed: %s\n", err.Error())
+		return 1
+	}
+
+	// decode
+	decoder := NewDynamicDecoder(input)
+	value := decoder.Decode(structDef)
+	if value == nil {
+		fmt.Fprintf(os.Stderr,
+			"error: decode failed at byte offset %d: %s\n",
+			decoder.ErrorOffset(), decoder.ErrorMessage())
+		return 1
+	}
+	if decoder.ReadSize() < len(input) {
+		fmt.Fprintf(os.Stderr,
+			"warning: %d trailing bytes after byte offset %d\n",
+			len(input)-decoder.ReadSize(), decoder.ReadSize())
+	}
+
+	if optFormat == "json" {
+		fmt.Println(value.ToJson())
+	} else {
+		fmt.Println(value.Dump())
+	}
+
+	return 0
+}
+
+// print error and return nil if not found
+func findStructDefByOptions(descriptor *ProtocolDescriptor,
+	structName string, enumMapName string, id int) *StructDef {
+
+	if structName != "" {
+		structDef := descriptor.FindStructDef(structName)
+		if structDef == nil {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find struct `%s`\n",
+				structName)
+		}
+		return structDef
+	}
+
+	enumMapDef := descriptor.FindEnumMapDef(enumMapName)
+	if enumMapDef == nil {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find enum map `%s`\n",
+			enumMapName)
+		return nil
+	}
+
+	structDef, ok := enumMapDef.IdToStructIndex[id]
+	if ok == false {
+		fmt.Fprintf(os.Stderr,
+			"error: enum map `%s` has no struct with id %d\n",
+			enumMapName, id)
+		return nil
+	}
+
+	return structDef
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+type DependencyGraphNode struct {
+	// protocol name or qualified name of definition, e.g. `attr.Attr`
+	Id string `json:"id"`
+	// protocol, enum, struct or enum_map
+	Kind     string `json:"kind"`
+	Protocol string `json:"protocol"`
+}
+
+type DependencyGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// names of fields or enum map items making the reference,
+	// comma separated
+	Label string `json:"label"`
+}
+
+// nodes and edges are kept in insert order, so output is stable
+type DependencyGraph struct {
+	Name  string
+	Nodes []*DependencyGraphNode
+	Edges []*DependencyGraphEdge
+
+	nodeIndex map[string]*DependencyGraphNode
+	// from + "\n" + to -> edge
+	edgeIndex map[string]*DependencyGraphEdge
+}
+
+func NewDependencyGraph(name string) *DependencyGraph {
+	newObj := new(DependencyGraph)
+	newObj.Name = name
+	newObj.Nodes = make([]*DependencyGraphNode, 0)
+	newObj.Edges = make([]*DependencyGraphEdge, 0)
+	newObj.nodeIndex = make(map[string]*DependencyGraphNode)
+	newObj.edgeIndex = make(map[string]*DependencyGraphEdge)
+
+	return newObj
+}
+
+func (this *DependencyGraph) AddNode(
+	id string, kind string, protocol string) {
+
+	if _, ok := this.nodeIndex[id]; ok {
+		return
+	}
+
+	node := &DependencyGraphNode{Id: id, Kind: kind, Protocol: protocol}
+	this.Nodes = append(this.Nodes, node)
+	this.nodeIndex[id] = node
+}
+
+// edges between the same nodes are merged, labels are joined
+func (this *DependencyGraph) AddEdge(from string, to string, label string) {
+	key := from + "\n" + to
+	if edge, ok := this.edgeIndex[key]; ok {
+		if label != "" {
+			if edge.Label == "" {
+				edge.Label = label
+			} else {
+				edge.Label += ", " + label
+			}
+		}
+		return
+	}
+
+	edge := &DependencyGraphEdge{From: from, To: to, Label: label}
+	this.Edges = append(this.Edges, edge)
+	this.edgeIndex[key] = edge
+}
+
+func (this *DependencyGraph) HasNode(id string) bool {
+	_, ok := this.nodeIndex[id]
+	return ok
+}
+
+// sub graph of nodes reachable from root, or nodes reaching root
+// when reverse is true
+func (this *DependencyGraph) Closure(
+	rootId string, reverse bool) *DependencyGraph {
+
+	// node id -> ids of adjacent nodes
+	adjacentIds := make(map[string][]string)
+	for _, edge := range this.Edges {
+		if reverse {
+			adjacentIds[edge.To] = append(adjacentIds[edge.To], edge.From)
+		} else {
+			adjacentIds[edge.From] = append(adjacentIds[edge.From], edge.To)
+		}
+	}
+
+	visited := make(map[string]bool)
+	pendingIds := []string{rootId}
+	visited[rootId] = true
+	for len(pendingIds) > 0 {
+		id := pendingIds[len(pendingIds)-1]
+		pendingIds = pendingIds[:len(pendingIds)-1]
+		for _, adjacentId := range adjacentIds[id] {
+			if visited[adjacentId] {
+				continue
+			}
+			visited[adjacentId] = true
+			pendingIds = append(pendingIds, adjacentId)
+		}
+	}
+
+	graph := NewDependencyGraph(this.Name)
+	for _, node := range this.Nodes {
+		if visited[node.Id] {
+			graph.AddNode(node.Id, node.Kind, node.Protocol)
+		}
+	}
+	for _, edge := range this.Edges {
+		if visited[edge.From] && visited[edge.To] {
+			graph.AddEdge(edge.From, edge.To, edge.Label)
+		}
+	}
+
+	return graph
+}
+
+// nodes of type graph are grouped into a cluster per protocol
+func (this *DependencyGraph) WriteDot(w io.Writer) {
+	fmt.Fprintf(w, "digraph %s {\n", dependencyGraphQuote(this.Name))
+	fmt.Fprintf(w, "    rankdir=LR;\n")
+
+	// protocol name -> nodes in the protocol
+	protocolNodes := make(map[string][]*DependencyGraphNode)
+	protocolNames := make([]string, 0)
+	for _, node := range this.Nodes {
+		if node.Kind == "protocol" {
+			fmt.Fprintf(w, "    %s [shape=box];\n",
+				dependencyGraphQuote(node.Id))
+			continue
+		}
+		if _, ok := protocolNodes[node.Protocol]; ok == false {
+			protocolNames = append(protocolNames, node.Protocol)
+		}
+		protocolNodes[node.Protocol] =
+			append(protocolNodes[node.Protocol], node)
+	}
+	for _, protocolName := range protocolNames {
+		fmt.Fprintf(w, "    subgraph %s {\n",
+			dependencyGraphQuote("cluster_"+protocolName))
+		fmt.Fprintf(w, "        label=%s;\n",
+			dependencyGraphQuote(protocolName))
+		for _, node := range protocolNodes[protocolName] {
+			fmt.Fprintf(w, "        %s [shape=%s];\n",
+				dependencyGraphQuote(node.Id),
+				dependencyGraphGetShape(node.Kind))
+		}
+		fmt.Fprintf(w, "    }\n")
+	}
+
+	for _, edge := range this.Edges {
+		if edge.Label == "" {
+			fmt.Fprintf(w, "    %s -> %s;\n",
+				dependencyGraphQuote(edge.From),
+				dependencyGraphQuote(edge.To))
+		} else {
+			fmt.Fprintf(w, "    %s -> %s [label=%s];\n",
+				dependencyGraphQuote(edge.From),
+				dependencyGraphQuote(edge.To),
+				dependencyGraphQuote(edge.Label))
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+func (this *DependencyGraph) WriteJson(w io.Writer) {
+	output, _ := json.MarshalIndent(struct {
+		Nodes []*DependencyGraphNode `json:"nodes"`
+		Edges []*DependencyGraphEdge `json:"edges"`
+	}{this.Nodes, this.Edges}, "", "  ")
+	fmt.Fprintf(w, "%s\n", output)
+}
+
+func dependencyGraphQuote(text string) string {
+	return "\"" + strings.ReplaceAll(text, "\"", "\\\"") + "\""
+}
+
+func dependencyGraphGetShape(kind string) string {
+	if kind == "enum" {
+		return "ellipse"
+	} else if kind == "enum_map" {
+		return "hexagon"
+	} else {
+		return "box"
+	}
+}
+
+// ----------------------------------------------------------------------------
+// protocols of descriptor sorted by name
+func dependencyGraphGetProtoDefs(
+	descriptor *ProtocolDescriptor) []*ProtocolDef {
+
+	protoDefs := make([]*ProtocolDef, 0, len(descriptor.ImportedProtos))
+	for _, protoDef := range descriptor.ImportedProtos {
+		protoDefs = append(protoDefs, protoDef)
+	}
+	sort.Slice(protoDefs, func(i, j int) bool {
+		return protoDefs[i].Name < protoDefs[j].Name
+	})
+
+	return protoDefs
+}
+
+// protocol -> imported protocol
+func BuildImportGraph(descriptor *ProtocolDescriptor) *DependencyGraph {
+	graph := NewDependencyGraph("imports")
+
+	protoDefs := dependencyGraphGetProtoDefs(descriptor)
+	for _, protoDef := range protoDefs {
+		graph.AddNode(protoDef.Name, "protocol", protoDef.Name)
+	}
+	for _, protoDef := range protoDefs {
+		for _, importDef := range protoDef.Imports {
+			if importDef.ProtoDef == nil {
+				continue
+			}
+			graph.AddEdge(protoDef.Name, importDef.ProtoDef.Name, "")
+		}
+	}
+
+	return graph
+}
+
+// struct -> struct or enum of its fields,
+// enum_map -> struct of its items
+func BuildTypeGraph(descriptor *ProtocolDescriptor) *DependencyGraph {
+	graph := NewDependencyGraph("types")
+
+	protoDefs := dependencyGraphGetProtoDefs(descriptor)
+	for _, protoDef := range protoDefs {
+		for _, def := range protoDef.Enums {
+			graph.AddNode(protoDef.Name+"."+def.Name,
+				"enum", protoDef.Name)
+		}
+		for _, def := range protoDef.Structs {
+			graph.AddNode(StructDefGetQualifiedName(def),
+				"struct", protoDef.Name)
+		}
+		for _, def := range protoDef.EnumMaps {
+			graph.AddNode(protoDef.Name+"."+def.Name,
+				"enum_map", protoDef.Name)
+		}
+	}
+
+	for _, protoDef := range protoDefs {
+		for _, structDef := range protoDef.Structs {
+			from := StructDefGetQualifiedName(structDef)
+			for _, fieldDef := range structDef.Fields {
+				if fieldDef.RefStructDef != nil {
+					graph.AddEdge(from,
+						StructDefGetQualifiedName(fieldDef.RefStructDef),
+						fieldDef.Name)
+				} else if fieldDef.RefEnumDef != nil {
+					refDef := fieldDef.RefEnumDef
+					graph.AddEdge(from,
+						refDef.ParentRef.Name+"."+refDef.Name,
+						fieldDef.Name)
+				}
+			}
+		}
+		for _, enumMapDef := range protoDef.EnumMaps {
+			from := protoDef.Name + "." + enumMapDef.Name
+			for _, itemDef := range enumMapDef.Items {
+				if itemDef.RefStructDef == nil {
+					continue
+				}
+				graph.AddEdge(from,
+					StructDefGetQualifiedName(itemDef.RefStructDef),
+					itemDef.Name)
+			}
+		}
+	}
+
+	return graph
+}
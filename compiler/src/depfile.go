@@ -0,0 +1,59 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// make and ninja compatible dependency file, every protocol file parsed
+// is a prerequisite of the generated files, imported ones are also
+// empty targets so make does not fail when an import is removed
+func WriteDepFile(filePath string,
+	targets []string, descriptor *ProtocolDescriptor) bool {
+
+	// main protocol file first, then imported ones in stable order
+	deps := make([]string, 0, len(descriptor.ImportedProtos))
+	deps = append(deps, descriptor.ProtoDef.FilePath)
+	importedDeps := make([]string, 0, len(descriptor.ImportedProtos))
+	for _, protoDef := range descriptor.ImportedProtos {
+		if protoDef == descriptor.ProtoDef {
+			continue
+		}
+		importedDeps = append(importedDeps, protoDef.FilePath)
+	}
+	sort.Strings(importedDeps)
+	deps = append(deps, importedDeps...)
+
+	var sb strings.Builder
+
+	for i, target := range targets {
+		if i > 0 {
+			sb.WriteString(" \\\n  ")
+		}
+		sb.WriteString(depFileEscapePath(target))
+	}
+	sb.WriteString(":")
+	for _, dep := range deps {
+		sb.WriteString(" \\\n  ")
+		sb.WriteString(depFileEscapePath(dep))
+	}
+	sb.WriteString("\n")
+
+	for _, dep := range importedDeps {
+		sb.WriteString("\n")
+		sb.WriteString(depFileEscapePath(dep))
+		sb.WriteString(":\n")
+	}
+
+	return UtilWriteAllText(filePath, sb.String())
+}
+
+func depFileEscapePath(filePath string) string {
+	// make uses `/` on all platforms
+	filePath = strings.ReplaceAll(filePath, "\\", "/")
+	filePath = strings.ReplaceAll(filePath, "$", "$$")
+	filePath = strings.ReplaceAll(filePath, "#", "\\#")
+	filePath = strings.ReplaceAll(filePath, " ", "\\ ")
+
+	return filePath
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type DiagnosticSeverity int
+
+const (
+	DiagnosticSeverity_None DiagnosticSeverity = iota
+	DiagnosticSeverity_Error
+	DiagnosticSeverity_Warning
+)
+
+// stable diagnostic codes, never reuse or renumber
+const (
+	DiagnosticCode_FileNotFound        = "E0001"
+	DiagnosticCode_FileReadFailed      = "E0002"
+	DiagnosticCode_XmlSyntaxError      = "E0003"
+	DiagnosticCode_InvalidRootNode     = "E0004"
+	DiagnosticCode_UnexpectedNode      = "E0005"
+	DiagnosticCode_TextSyntaxError     = "E0006"
+	DiagnosticCode_ImportSelf          = "E0101"
+	DiagnosticCode_ImportFailed        = "E0102"
+	DiagnosticCode_ImportDuplicated    = "E0103"
+	DiagnosticCode_ImportNotUsed       = "W0101"
+	DiagnosticCode_MissingAttribute    = "E0201"
+	DiagnosticCode_InvalidAttribute    = "E0202"
+	DiagnosticCode_DuplicatedName      = "E0203"
+	DiagnosticCode_InvalidNamespace    = "E0204"
+	DiagnosticCode_UndefinedProtocol   = "E0301"
+	DiagnosticCode_UndefinedEnum       = "E0302"
+	DiagnosticCode_UndefinedEnumItem   = "E0303"
+	DiagnosticCode_UndefinedType       = "E0304"
+	DiagnosticCode_UndefinedStruct     = "E0305"
+	DiagnosticCode_InvalidEnumValue    = "E0401"
+	DiagnosticCode_InvalidType         = "E0402"
+	DiagnosticCode_InvalidStruct       = "E0403"
+	DiagnosticCode_DecreasingValue     = "E0404"
+	DiagnosticCode_IdAlreadyMapped     = "E0405"
+	DiagnosticCode_StructAlreadyMapped = "E0406"
+	DiagnosticCode_ProtoSyntaxError    = "E0501"
+	DiagnosticCode_ProtoNotSupported   = "W0501"
+)
+
+type Diagnostic struct {
+	FilePath string
+	// 1-based, 0 if unknown
+	LineNumber int
+	// 1-based byte offset in line, 0 if unknown
+	Column   int
+	Severity DiagnosticSeverity
+	Code     string
+	Message  string
+}
+
+func (this *Diagnostic) GetSeverityName() string {
+	if this.Severity == DiagnosticSeverity_Error {
+		return "error"
+	} else {
+		return "warning"
+	}
+}
+
+type DiagnosticList struct {
+	Items []*Diagnostic
+}
+
+func NewDiagnosticList() *DiagnosticList {
+	newObj := new(DiagnosticList)
+	newObj.Items = make([]*Diagnostic, 0)
+
+	return newObj
+}
+
+func (this *DiagnosticList) Add(
+	filePath string, lineNumber int, column int,
+	severity DiagnosticSeverity, code string,
+	format string, args ...any) {
+
+	item := new(Diagnostic)
+	item.FilePath = filePath
+	item.LineNumber = lineNumber
+	item.Column = column
+	item.Severity = severity
+	item.Code = code
+	item.Message = fmt.Sprintf(format, args...)
+	this.Items = append(this.Items, item)
+}
+
+func (this *DiagnosticList) ErrorCount() int {
+	count := 0
+	for _, item := range this.Items {
+		if item.Severity == DiagnosticSeverity_Error {
+			count++
+		}
+	}
+
+	return count
+}
+
+func DiagnosticsFormatIsValid(format string) bool {
+	return format == "text" ||
+		format == "gcc" ||
+		format == "json"
+}
+
+// format is one of
+//   - text: error:<file>:<line>: <message>
+//   - gcc: <file>:<line>:<column>: error: <message> [<code>]
+//   - json: an array of objects, one per diagnostic
+//
+// nothing is printed if there is no diagnostic
+func (this *DiagnosticList) Print(w io.Writer, format string) {
+	if len(this.Items) == 0 {
+		return
+	}
+	if format == "json" {
+		this.printJson(w)
+		return
+	}
+
+	for _, item := range this.Items {
+		if format == "gcc" {
+			var sb strings.Builder
+			if item.FilePath != "" {
+				sb.WriteString(item.FilePath)
+				if item.LineNumber > 0 {
+					fmt.Fprintf(&sb, ":%d", item.LineNumber)
+					if item.Column > 0 {
+						fmt.Fprintf(&sb, ":%d", item.Column)
+					}
+				}
+				sb.WriteString(": ")
+			}
+			fmt.Fprintf(w, "%s%s: %s [%s]\n",
+				sb.String(), item.GetSeverityName(),
+				item.Message, item.Code)
+		} else if item.LineNumber > 0 {
+			fmt.Fprintf(w, "%s:%s:%d: %s\n",
+				item.GetSeverityName(), item.FilePath,
+				item.LineNumber, item.Message)
+		} else if item.FilePath != "" {
+			fmt.Fprintf(w, "%s:%s: %s\n",
+				item.GetSeverityName(), item.FilePath, item.Message)
+		} else {
+			fmt.Fprintf(w, "%s: %s\n",
+				item.GetSeverityName(), item.Message)
+		}
+	}
+}
+
+func (this *DiagnosticList) printJson(w io.Writer) {
+	type jsonItem struct {
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		Column   int    `json:"column"`
+		Severity string `json:"severity"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+	}
+
+	items := make([]jsonItem, 0, len(this.Items))
+	for _, item := range this.Items {
+		items = append(items, jsonItem{
+			File:     item.FilePath,
+			Line:     item.LineNumber,
+			Column:   item.Column,
+			Severity: item.GetSeverityName(),
+			Code:     item.Code,
+			Message:  item.Message,
+		})
+	}
+
+	output, _ := json.Marshal(items)
+	fmt.Fprintf(w, "%s\n", output)
+}
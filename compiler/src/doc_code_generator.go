@@ -0,0 +1,113 @@
+package main
+
+import (
+	"sort"
+)
+
+// helpers shared by html and markdown document generators, each protocol
+// is a page named after it, definitions are anchors in the page.
+// only protocols generated in the same run have a page, references to
+// other protocols are not linked
+
+// names of protocols having a page
+func docGetPages(protoDefs []*ProtocolDef) map[string]bool {
+	pages := make(map[string]bool, len(protoDefs))
+	for _, protoDef := range protoDefs {
+		pages[protoDef.Name] = true
+	}
+
+	return pages
+}
+
+// e.g. struct-MsgTest
+func docGetAnchor(kind string, name string) string {
+	return kind + "-" + name
+}
+
+// link to page of protocol name, empty if it has no page
+func docGetPageHref(name string, fileExt string,
+	pages map[string]bool) string {
+
+	if pages[name] {
+		return name + fileExt
+	} else {
+		return ""
+	}
+}
+
+// link from page of protoDef to anchor in page of refProtoDef,
+// empty if refProtoDef has no page
+func docGetHref(protoDef *ProtocolDef, refProtoDef *ProtocolDef,
+	anchor string, fileExt string, pages map[string]bool) string {
+
+	if protoDef == refProtoDef {
+		return "#" + anchor
+	} else if pages[refProtoDef.Name] {
+		return refProtoDef.Name + fileExt + "#" + anchor
+	} else {
+		return ""
+	}
+}
+
+// definitions in other protocol are prefixed by protocol name
+func docGetRefName(protoDef *ProtocolDef, refProtoDef *ProtocolDef,
+	name string) string {
+
+	if protoDef == refProtoDef {
+		return name
+	} else {
+		return refProtoDef.Name + "." + name
+	}
+}
+
+// name and href of enum or struct referenced by field, href is empty
+// for builtin types
+func docGetFieldItemType(protoDef *ProtocolDef,
+	fieldDef *StructFieldDef, fileExt string,
+	pages map[string]bool) (string, string) {
+
+	if fieldDef.RefEnumDef != nil {
+		refDef := fieldDef.RefEnumDef
+		return docGetRefName(protoDef, refDef.ParentRef, refDef.Name),
+			docGetHref(protoDef, refDef.ParentRef,
+				docGetAnchor("enum", refDef.Name), fileExt, pages)
+	} else if fieldDef.RefStructDef != nil {
+		refDef := fieldDef.RefStructDef
+		return docGetRefName(protoDef, refDef.ParentRef, refDef.Name),
+			docGetHref(protoDef, refDef.ParentRef,
+				docGetAnchor("struct", refDef.Name), fileExt, pages)
+	} else if fieldDef.Type == StructFieldType_List {
+		return StructFieldTypeGetName(fieldDef.ListType), ""
+	} else {
+		return StructFieldTypeGetName(fieldDef.Type), ""
+	}
+}
+
+// protocols of descriptor having a page sorted by name, for page
+// navigation
+func docGetSortedProtoDefs(descriptor *ProtocolDescriptor,
+	pages map[string]bool) []*ProtocolDef {
+
+	protoDefs := make([]*ProtocolDef, 0, len(pages))
+	for _, protoDef := range descriptor.ImportedProtos {
+		if pages[protoDef.Name] {
+			protoDefs = append(protoDefs, protoDef)
+		}
+	}
+	sort.Slice(protoDefs, func(i, j int) bool {
+		return protoDefs[i].Name < protoDefs[j].Name
+	})
+
+	return protoDefs
+}
+
+// sorted namespace languages of protocol
+func docGetNamespaceLanguages(protoDef *ProtocolDef) []string {
+	languages := make([]string, 0, len(protoDef.Namespaces))
+	for language := range protoDef.Namespaces {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	return languages
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const dynamicDecoderMaxDepth = 64
+
+// items of list which may take no byte, e.g. struct without fields,
+// can not be bounded by bytes left
+const dynamicDecoderMaxEmptyItemCount = 1 << 20
+
+// decode binary by schema, same as generated decode()
+type DynamicDecoder struct {
+	buf []byte
+	pos int
+
+	// field path of current decoding position, e.g. MsgTest.a2[3].id
+	path []string
+	// struct def -> minimum encoded size
+	structMinSizes map[*StructDef]int
+
+	errorOffset  int
+	errorMessage string
+}
+
+func NewDynamicDecoder(buf []byte) *DynamicDecoder {
+	newObj := new(DynamicDecoder)
+	newObj.buf = buf
+	newObj.path = make([]string, 0)
+	newObj.structMinSizes = make(map[*StructDef]int)
+
+	return newObj
+}
+
+// return nil if failed, check ErrorOffset() and ErrorMessage()
+func (this *DynamicDecoder) Decode(structDef *StructDef) *DynamicStruct {
+	this.pos = 0
+	this.path = append(this.path[:0], structDef.Name)
+	this.errorOffset = 0
+	this.errorMessage = ""
+
+	return this.readStruct(structDef)
+}
+
+// number of bytes consumed by the last successful Decode()
+func (this *DynamicDecoder) ReadSize() int {
+	return this.pos
+}
+
+func (this *DynamicDecoder) ErrorOffset() int {
+	return this.errorOffset
+}
+
+func (this *DynamicDecoder) ErrorMessage() string {
+	return this.errorMessage
+}
+
+func (this *DynamicDecoder) setError(offset int, format string, args ...any) {
+	this.errorOffset = offset
+	this.errorMessage = fmt.Sprintf("%s: %s",
+		strings.Join(this.path, "."), fmt.Sprintf(format, args...))
+}
+
+func (this *DynamicDecoder) readUInt(size int) (uint64, bool) {
+	if len(this.buf)-this.pos < size {
+		this.setError(this.pos,
+			"need %d bytes, only %d bytes left",
+			size, len(this.buf)-this.pos)
+		return 0, false
+	}
+
+	var val uint64 = 0
+	for i := 0; i < size; i++ {
+		val = val<<8 | uint64(this.buf[this.pos+i])
+	}
+	this.pos += size
+
+	return val, true
+}
+
+// prefix byte less than maxByteValue is the value itself,
+// otherwise the following 2, 4 or 8 bytes are the value
+func (this *DynamicDecoder) readVarUInt(maxByteValue uint64) (uint64, bool) {
+	val, ok := this.readUInt(1)
+	if ok == false {
+		return 0, false
+	}
+	if val < maxByteValue {
+		return val, true
+	}
+
+	sizes := []int{2, 4, 8}
+	return this.readUInt(sizes[val-maxByteValue])
+}
+
+func (this *DynamicDecoder) readLength() (int, bool) {
+	offset := this.pos
+	val, ok := this.readVarUInt(254)
+	if ok == false {
+		return 0, false
+	}
+	if val > math.MaxInt32 {
+		this.setError(offset, "length %d is too large", val)
+		return 0, false
+	}
+
+	return int(val), true
+}
+
+// minimum encoded size of struct, with optional fields not set and
+// lists empty. a struct referencing itself is counted as 0 bytes at
+// the reference, so the result is still a lower bound
+func (this *DynamicDecoder) getStructMinSize(structDef *StructDef) int {
+	if size, ok := this.structMinSizes[structDef]; ok {
+		return size
+	}
+	this.structMinSizes[structDef] = 0
+
+	size := structDef.OptionalByteCount
+	for _, fieldDef := range structDef.Fields {
+		if fieldDef.IsOptional {
+			continue
+		}
+		if fieldDef.Type == StructFieldType_Struct {
+			size += this.getStructMinSize(fieldDef.RefStructDef)
+		} else {
+			size += 1
+		}
+	}
+	this.structMinSizes[structDef] = size
+
+	return size
+}
+
+func (this *DynamicDecoder) readStruct(structDef *StructDef) *DynamicStruct {
+	if len(this.path) > dynamicDecoderMaxDepth {
+		this.setError(this.pos, "struct nested too deep")
+		return nil
+	}
+
+	ret := NewDynamicStruct(structDef)
+
+	hasBits := make([]byte, structDef.OptionalByteCount)
+	for i := range hasBits {
+		val, ok := this.readUInt(1)
+		if ok == false {
+			return nil
+		}
+		hasBits[i] = byte(val)
+	}
+
+	for i, fieldDef := range structDef.Fields {
+		if fieldDef.IsOptional {
+			index := fieldDef.OptionalFieldIndex
+			if hasBits[index/8]&(1<<(index%8)) == 0 {
+				continue
+			}
+		}
+
+		this.path = append(this.path, fieldDef.Name)
+		field := this.readField(fieldDef)
+		this.path = this.path[:len(this.path)-1]
+		if field == nil {
+			return nil
+		}
+		ret.Fields[i] = field
+	}
+
+	return ret
+}
+
+func (this *DynamicDecoder) readField(fieldDef *StructFieldDef) *DynamicField {
+	field := NewDynamicField(fieldDef)
+
+	if fieldDef.Type != StructFieldType_List {
+		val, ok := this.readItem(fieldDef, fieldDef.Type)
+		if ok == false {
+			return nil
+		}
+		field.Values = append(field.Values, val)
+
+		return field
+	}
+
+	offset := this.pos
+	count, ok := this.readLength()
+	if ok == false {
+		return nil
+	}
+	// every item except struct takes at least one byte
+	itemMinSize := 1
+	if fieldDef.ListType == StructFieldType_Struct {
+		itemMinSize = this.getStructMinSize(fieldDef.RefStructDef)
+	}
+	if itemMinSize > 0 {
+		if count > (len(this.buf)-this.pos)/itemMinSize {
+			this.setError(offset,
+				"list length %d exceeds %d bytes left",
+				count, len(this.buf)-this.pos)
+			return nil
+		}
+	} else if count > dynamicDecoderMaxEmptyItemCount {
+		this.setError(offset,
+			"list length %d exceeds limit %d of empty items",
+			count, dynamicDecoderMaxEmptyItemCount)
+		return nil
+	}
+
+	name := this.path[len(this.path)-1]
+	for i := 0; i < count; i++ {
+		this.path[len(this.path)-1] = fmt.Sprintf("%s[%d]", name, i)
+		val, ok := this.readItem(fieldDef, fieldDef.ListType)
+		if ok == false {
+			return nil
+		}
+		field.Values = append(field.Values, val)
+	}
+	this.path[len(this.path)-1] = name
+
+	return field
+}
+
+func (this *DynamicDecoder) readItem(
+	fieldDef *StructFieldDef, itemType StructFieldType) (any, bool) {
+
+	var val uint64
+	var ok bool
+
+	if itemType == StructFieldType_I8 ||
+		itemType == StructFieldType_U8 ||
+		itemType == StructFieldType_Bool {
+		val, ok = this.readUInt(1)
+	} else if itemType == StructFieldType_I16 ||
+		itemType == StructFieldType_U16 {
+		val, ok = this.readUInt(2)
+	} else if itemType == StructFieldType_I32 ||
+		itemType == StructFieldType_U32 {
+		val, ok = this.readUInt(4)
+	} else if itemType == StructFieldType_I64 ||
+		itemType == StructFieldType_U64 {
+		val, ok = this.readUInt(8)
+	} else if itemType == StructFieldType_I16V ||
+		itemType == StructFieldType_U16V {
+		val, ok = this.readVarUInt(255)
+	} else if itemType == StructFieldType_I32V ||
+		itemType == StructFieldType_U32V ||
+		itemType == StructFieldType_Enum {
+		val, ok = this.readVarUInt(254)
+	} else if itemType == StructFieldType_I64V ||
+		itemType == StructFieldType_U64V {
+		val, ok = this.readVarUInt(253)
+	} else if itemType == StructFieldType_String ||
+		itemType == StructFieldType_Bytes {
+		return this.readString()
+	} else if itemType == StructFieldType_Struct {
+		structVal := this.readStruct(fieldDef.RefStructDef)
+		return structVal, structVal != nil
+	}
+	if ok == false {
+		return nil, false
+	}
+
+	if itemType == StructFieldType_I8 {
+		return int64(int8(val)), true
+	} else if itemType == StructFieldType_U8 {
+		return int64(uint8(val)), true
+	} else if itemType == StructFieldType_I16 ||
+		itemType == StructFieldType_I16V {
+		return int64(int16(val)), true
+	} else if itemType == StructFieldType_U16 ||
+		itemType == StructFieldType_U16V {
+		return int64(uint16(val)), true
+	} else if itemType == StructFieldType_I32 ||
+		itemType == StructFieldType_I32V ||
+		itemType == StructFieldType_Enum {
+		return int64(int32(val)), true
+	} else if itemType == StructFieldType_U32 ||
+		itemType == StructFieldType_U32V {
+		return int64(uint32(val)), true
+	} else if itemType == StructFieldType_I64 ||
+		itemType == StructFieldType_I64V {
+		return int64(val), true
+	} else if itemType == StructFieldType_Bool {
+		return val != 0, true
+	} else {
+		return val, true
+	}
+}
+
+func (this *DynamicDecoder) readString() (any, bool) {
+	offset := this.pos
+	length, ok := this.readLength()
+	if ok == false {
+		return nil, false
+	}
+	if length > len(this.buf)-this.pos {
+		this.setError(offset,
+			"string length %d exceeds %d bytes left",
+			length, len(this.buf)-this.pos)
+		return nil, false
+	}
+
+	val := string(this.buf[this.pos : this.pos+length])
+	this.pos += length
+
+	return val, true
+}
@@ -0,0 +1,166 @@
+package main
+
+// encode DynamicStruct to binary, same as generated encode()
+type DynamicEncoder struct {
+	buf []byte
+
+	errorMessage string
+}
+
+func NewDynamicEncoder() *DynamicEncoder {
+	newObj := new(DynamicEncoder)
+
+	return newObj
+}
+
+// return nil if failed, check ErrorMessage()
+func (this *DynamicEncoder) Encode(value *DynamicStruct) []byte {
+	this.buf = make([]byte, 0, 256)
+	this.errorMessage = ""
+
+	if this.writeStruct(value) == false {
+		return nil
+	}
+
+	return this.buf
+}
+
+func (this *DynamicEncoder) ErrorMessage() string {
+	return this.errorMessage
+}
+
+func (this *DynamicEncoder) writeUInt(val uint64, size int) {
+	for i := size - 1; i >= 0; i-- {
+		this.buf = append(this.buf, byte(val>>(i*8)))
+	}
+}
+
+func (this *DynamicEncoder) writeVarUInt16(val uint64) {
+	if val < 255 {
+		this.writeUInt(val, 1)
+	} else {
+		this.writeUInt(255, 1)
+		this.writeUInt(val, 2)
+	}
+}
+
+func (this *DynamicEncoder) writeVarUInt32(val uint64) {
+	if val < 254 {
+		this.writeUInt(val, 1)
+	} else if val <= 0xffff {
+		this.writeUInt(254, 1)
+		this.writeUInt(val, 2)
+	} else {
+		this.writeUInt(255, 1)
+		this.writeUInt(val, 4)
+	}
+}
+
+func (this *DynamicEncoder) writeVarUInt64(val uint64) {
+	if val < 253 {
+		this.writeUInt(val, 1)
+	} else if val <= 0xffff {
+		this.writeUInt(253, 1)
+		this.writeUInt(val, 2)
+	} else if val <= 0xffffffff {
+		this.writeUInt(254, 1)
+		this.writeUInt(val, 4)
+	} else {
+		this.writeUInt(255, 1)
+		this.writeUInt(val, 8)
+	}
+}
+
+func (this *DynamicEncoder) writeLength(length int) bool {
+	if uint64(length) > 0xffffffff {
+		this.errorMessage = "length exceeds 0xffffffff"
+		return false
+	}
+	this.writeVarUInt32(uint64(length))
+
+	return true
+}
+
+func (this *DynamicEncoder) writeStruct(value *DynamicStruct) bool {
+	hasBits := make([]byte, value.Def.OptionalByteCount)
+	for i, fieldDef := range value.Def.Fields {
+		if fieldDef.IsOptional && value.Fields[i] != nil {
+			index := fieldDef.OptionalFieldIndex
+			hasBits[index/8] |= 1 << (index % 8)
+		}
+	}
+	this.buf = append(this.buf, hasBits...)
+
+	for _, field := range value.Fields {
+		if field == nil {
+			continue
+		}
+
+		if field.Def.Type == StructFieldType_List {
+			if this.writeLength(len(field.Values)) == false {
+				return false
+			}
+		}
+		for _, val := range field.Values {
+			if this.writeItem(field.getItemType(), val) == false {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (this *DynamicEncoder) writeItem(
+	itemType StructFieldType, val any) bool {
+
+	if itemType == StructFieldType_String ||
+		itemType == StructFieldType_Bytes {
+		str := val.(string)
+		if this.writeLength(len(str)) == false {
+			return false
+		}
+		this.buf = append(this.buf, str...)
+		return true
+	} else if itemType == StructFieldType_Struct {
+		return this.writeStruct(val.(*DynamicStruct))
+	}
+
+	var intVal uint64
+	if v, ok := val.(int64); ok {
+		intVal = uint64(v)
+	} else if v, ok := val.(uint64); ok {
+		intVal = v
+	} else if val.(bool) {
+		intVal = 1
+	} else {
+		intVal = 0
+	}
+
+	if itemType == StructFieldType_I8 ||
+		itemType == StructFieldType_U8 ||
+		itemType == StructFieldType_Bool {
+		this.writeUInt(intVal, 1)
+	} else if itemType == StructFieldType_I16 ||
+		itemType == StructFieldType_U16 {
+		this.writeUInt(intVal, 2)
+	} else if itemType == StructFieldType_I32 ||
+		itemType == StructFieldType_U32 {
+		this.writeUInt(intVal, 4)
+	} else if itemType == StructFieldType_I64 ||
+		itemType == StructFieldType_U64 {
+		this.writeUInt(intVal, 8)
+	} else if itemType == StructFieldType_I16V ||
+		itemType == StructFieldType_U16V {
+		this.writeVarUInt16(uint64(uint16(intVal)))
+	} else if itemType == StructFieldType_I32V ||
+		itemType == StructFieldType_U32V ||
+		itemType == StructFieldType_Enum {
+		this.writeVarUInt32(uint64(uint32(intVal)))
+	} else if itemType == StructFieldType_I64V ||
+		itemType == StructFieldType_U64V {
+		this.writeVarUInt64(intVal)
+	}
+
+	return true
+}
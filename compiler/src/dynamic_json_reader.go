@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// read json in the shape of generated to_json() and php toArray()
+// into DynamicStruct, validate it against the schema
+type DynamicJsonReader struct {
+	// field path of current reading position, e.g. MsgTest.a2[3].id
+	path []string
+
+	errorMessage string
+}
+
+func NewDynamicJsonReader() *DynamicJsonReader {
+	newObj := new(DynamicJsonReader)
+	newObj.path = make([]string, 0)
+
+	return newObj
+}
+
+// return nil if failed, check ErrorMessage()
+func (this *DynamicJsonReader) Read(
+	structDef *StructDef, input []byte) *DynamicStruct {
+
+	this.path = append(this.path[:0], structDef.Name)
+	this.errorMessage = ""
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var val any
+	if err := decoder.Decode(&val); err != nil {
+		this.errorMessage = fmt.Sprintf("invalid json: %s", err.Error())
+		return nil
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		this.errorMessage = "invalid json: unexpected data after value"
+		return nil
+	}
+
+	return this.readStruct(structDef, val)
+}
+
+func (this *DynamicJsonReader) ErrorMessage() string {
+	return this.errorMessage
+}
+
+func (this *DynamicJsonReader) setError(format string, args ...any) {
+	this.errorMessage = fmt.Sprintf("%s: %s",
+		strings.Join(this.path, "."), fmt.Sprintf(format, args...))
+}
+
+func (this *DynamicJsonReader) readStruct(
+	structDef *StructDef, val any) *DynamicStruct {
+
+	obj, ok := val.(map[string]any)
+	if ok == false {
+		// php writes a struct without any field set as empty array
+		if arr, isArray := val.([]any); isArray && len(arr) == 0 {
+			obj = map[string]any{}
+		} else {
+			this.setError("expect object")
+			return nil
+		}
+	}
+
+	// check unknown fields in stable order
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := structDef.FieldNameIndex[name]; ok == false {
+			this.setError("unknown field `%s`", name)
+			return nil
+		}
+	}
+
+	ret := NewDynamicStruct(structDef)
+
+	for i, fieldDef := range structDef.Fields {
+		fieldVal, ok := obj[fieldDef.Name]
+		if ok == false || fieldVal == nil {
+			if fieldDef.IsOptional {
+				continue
+			}
+			this.setError("missing required field `%s`", fieldDef.Name)
+			return nil
+		}
+
+		this.path = append(this.path, fieldDef.Name)
+		field := this.readField(fieldDef, fieldVal)
+		this.path = this.path[:len(this.path)-1]
+		if field == nil {
+			return nil
+		}
+		ret.Fields[i] = field
+	}
+
+	return ret
+}
+
+func (this *DynamicJsonReader) readField(
+	fieldDef *StructFieldDef, val any) *DynamicField {
+
+	field := NewDynamicField(fieldDef)
+
+	if fieldDef.Type != StructFieldType_List {
+		item, ok := this.readItem(fieldDef, fieldDef.Type, val)
+		if ok == false {
+			return nil
+		}
+		field.Values = append(field.Values, item)
+
+		return field
+	}
+
+	arr, ok := val.([]any)
+	if ok == false {
+		this.setError("expect array")
+		return nil
+	}
+
+	name := this.path[len(this.path)-1]
+	for i, itemVal := range arr {
+		this.path[len(this.path)-1] = fmt.Sprintf("%s[%d]", name, i)
+		item, ok := this.readItem(fieldDef, fieldDef.ListType, itemVal)
+		if ok == false {
+			return nil
+		}
+		field.Values = append(field.Values, item)
+	}
+	this.path[len(this.path)-1] = name
+
+	return field
+}
+
+func (this *DynamicJsonReader) readItem(
+	fieldDef *StructFieldDef, itemType StructFieldType,
+	val any) (any, bool) {
+
+	if itemType == StructFieldType_U64 ||
+		itemType == StructFieldType_U64V {
+		str, ok := this.getIntegerString(val)
+		if ok == false {
+			return nil, false
+		}
+		ret, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			this.setError("`%s` is not a valid %s",
+				str, StructFieldTypeGetName(itemType))
+			return nil, false
+		}
+		return ret, true
+	} else if StructFieldTypeIsInteger(itemType) {
+		str, ok := this.getIntegerString(val)
+		if ok == false {
+			return nil, false
+		}
+		minVal, maxVal := dynamicGetIntegerRange(itemType)
+		ret, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || ret < minVal || ret > maxVal {
+			this.setError("`%s` is not a valid %s",
+				str, StructFieldTypeGetName(itemType))
+			return nil, false
+		}
+		return ret, true
+	} else if itemType == StructFieldType_Enum {
+		return this.readEnum(fieldDef.RefEnumDef, val)
+	} else if itemType == StructFieldType_Bool {
+		ret, ok := val.(bool)
+		if ok == false {
+			this.setError("expect bool")
+			return nil, false
+		}
+		return ret, true
+	} else if itemType == StructFieldType_String {
+		ret, ok := val.(string)
+		if ok == false {
+			this.setError("expect string")
+			return nil, false
+		}
+		return ret, true
+	} else if itemType == StructFieldType_Bytes {
+		str, ok := val.(string)
+		if ok == false {
+			this.setError("expect base64 string")
+			return nil, false
+		}
+		ret, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			this.setError("invalid base64 string: %s", err.Error())
+			return nil, false
+		}
+		return string(ret), true
+	} else if itemType == StructFieldType_Struct {
+		ret := this.readStruct(fieldDef.RefStructDef, val)
+		return ret, ret != nil
+	} else {
+		this.setError("unsupported field type")
+		return nil, false
+	}
+}
+
+// integer can be json number or string, 64 bit integer is string
+// in generated to_json() output
+func (this *DynamicJsonReader) getIntegerString(val any) (string, bool) {
+	if num, ok := val.(json.Number); ok {
+		return num.String(), true
+	} else if str, ok := val.(string); ok {
+		return str, true
+	} else {
+		this.setError("expect integer")
+		return "", false
+	}
+}
+
+// enum can be item value or item name
+func (this *DynamicJsonReader) readEnum(
+	enumDef *EnumDef, val any) (any, bool) {
+
+	if str, ok := val.(string); ok {
+		if itemDef, ok := enumDef.ItemNameIndex[str]; ok {
+			return int64(itemDef.IntValue), true
+		}
+	} else if num, ok := val.(json.Number); ok {
+		ret, err := strconv.ParseInt(num.String(), 10, 64)
+		if err == nil {
+			for _, itemDef := range enumDef.Items {
+				if int64(itemDef.IntValue) == ret {
+					return ret, true
+				}
+			}
+		}
+	}
+
+	this.setError("`%v` is not a valid value of enum `%s`",
+		val, enumDef.Name)
+	return nil, false
+}
+
+func dynamicGetIntegerRange(t StructFieldType) (int64, int64) {
+	if t == StructFieldType_I8 {
+		return math.MinInt8, math.MaxInt8
+	} else if t == StructFieldType_U8 {
+		return 0, math.MaxUint8
+	} else if t == StructFieldType_I16 ||
+		t == StructFieldType_I16V {
+		return math.MinInt16, math.MaxInt16
+	} else if t == StructFieldType_U16 ||
+		t == StructFieldType_U16V {
+		return 0, math.MaxUint16
+	} else if t == StructFieldType_I32 ||
+		t == StructFieldType_I32V {
+		return math.MinInt32, math.MaxInt32
+	} else if t == StructFieldType_U32 ||
+		t == StructFieldType_U32V {
+		return 0, math.MaxUint32
+	} else {
+		return math.MinInt64, math.MaxInt64
+	}
+}
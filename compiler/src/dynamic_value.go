@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// struct value handled by schema without generated code
+type DynamicStruct struct {
+	Def *StructDef
+	// in StructDef.Fields order, nil if optional field is not set
+	Fields []*DynamicField
+}
+
+type DynamicField struct {
+	Def *StructFieldDef
+	// single item if field is not list, item type is
+	// - int64: integer (except u64 and u64v) and enum
+	// - uint64: u64 and u64v
+	// - bool: bool
+	// - string: string and bytes
+	// - *DynamicStruct: struct
+	Values []any
+}
+
+func NewDynamicStruct(def *StructDef) *DynamicStruct {
+	newObj := new(DynamicStruct)
+	newObj.Def = def
+	newObj.Fields = make([]*DynamicField, len(def.Fields))
+
+	return newObj
+}
+
+func NewDynamicField(def *StructFieldDef) *DynamicField {
+	newObj := new(DynamicField)
+	newObj.Def = def
+	newObj.Values = make([]any, 0)
+
+	return newObj
+}
+
+func (this *DynamicField) getItemType() StructFieldType {
+	if this.Def.Type == StructFieldType_List {
+		return this.Def.ListType
+	} else {
+		return this.Def.Type
+	}
+}
+
+// same output as generated to_json()
+func (this *DynamicStruct) ToJson() string {
+	var sb strings.Builder
+	this.writeJson(&sb)
+
+	return sb.String()
+}
+
+// same output as generated dump()
+func (this *DynamicStruct) Dump() string {
+	items := make([]string, 0, len(this.Fields))
+	for _, field := range this.Fields {
+		if field == nil {
+			continue
+		}
+		for _, val := range field.Values {
+			var sb strings.Builder
+			sb.WriteString(field.Def.Name)
+			sb.WriteString(": ")
+			field.writeDumpItem(&sb, val)
+			items = append(items, sb.String())
+		}
+	}
+
+	return strings.Join(items, " ")
+}
+
+func (this *DynamicStruct) writeJson(sb *strings.Builder) {
+	sb.WriteByte('{')
+
+	isFirst := true
+	for _, field := range this.Fields {
+		if field == nil {
+			continue
+		}
+		if isFirst {
+			isFirst = false
+		} else {
+			sb.WriteByte(',')
+		}
+		dynamicWriteJsonString(sb, field.Def.Name)
+		sb.WriteByte(':')
+
+		if field.Def.Type == StructFieldType_List {
+			sb.WriteByte('[')
+			for i, val := range field.Values {
+				if i > 0 {
+					sb.WriteByte(',')
+				}
+				field.writeJsonItem(sb, val)
+			}
+			sb.WriteByte(']')
+		} else {
+			field.writeJsonItem(sb, field.Values[0])
+		}
+	}
+
+	sb.WriteByte('}')
+}
+
+func (this *DynamicField) writeJsonItem(sb *strings.Builder, val any) {
+	itemType := this.getItemType()
+
+	if itemType == StructFieldType_I64 ||
+		itemType == StructFieldType_I64V {
+		sb.WriteString(fmt.Sprintf("\"%d\"", val.(int64)))
+	} else if itemType == StructFieldType_U64 ||
+		itemType == StructFieldType_U64V {
+		sb.WriteString(fmt.Sprintf("\"%d\"", val.(uint64)))
+	} else if StructFieldTypeIsInteger(itemType) ||
+		itemType == StructFieldType_Enum {
+		sb.WriteString(strconv.FormatInt(val.(int64), 10))
+	} else if itemType == StructFieldType_Bool {
+		sb.WriteString(strconv.FormatBool(val.(bool)))
+	} else if itemType == StructFieldType_String {
+		dynamicWriteJsonString(sb, val.(string))
+	} else if itemType == StructFieldType_Bytes {
+		dynamicWriteJsonString(sb,
+			base64.StdEncoding.EncodeToString([]byte(val.(string))))
+	} else if itemType == StructFieldType_Struct {
+		val.(*DynamicStruct).writeJson(sb)
+	}
+}
+
+func (this *DynamicField) writeDumpItem(sb *strings.Builder, val any) {
+	itemType := this.getItemType()
+
+	if itemType == StructFieldType_U64 ||
+		itemType == StructFieldType_U64V {
+		sb.WriteString(strconv.FormatUint(val.(uint64), 10))
+	} else if StructFieldTypeIsInteger(itemType) ||
+		itemType == StructFieldType_Enum {
+		sb.WriteString(strconv.FormatInt(val.(int64), 10))
+	} else if itemType == StructFieldType_Bool {
+		if val.(bool) {
+			sb.WriteString("1")
+		} else {
+			sb.WriteString("0")
+		}
+	} else if itemType == StructFieldType_String {
+		sb.WriteByte('"')
+		dynamicWriteDumpString(sb, val.(string))
+		sb.WriteByte('"')
+	} else if itemType == StructFieldType_Bytes {
+		sb.WriteByte('"')
+		for i := 0; i < len(val.(string)); i++ {
+			if i > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteString(fmt.Sprintf("%02X", val.(string)[i]))
+		}
+		sb.WriteByte('"')
+	} else if itemType == StructFieldType_Struct {
+		sb.WriteString("{ ")
+		sb.WriteString(val.(*DynamicStruct).Dump())
+		sb.WriteString(" }")
+	}
+}
+
+func dynamicWriteJsonString(sb *strings.Builder, val string) {
+	sb.WriteByte('"')
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c == '"' {
+			sb.WriteString("\\\"")
+		} else if c == '\\' {
+			sb.WriteString("\\\\")
+		} else if c == '\b' {
+			sb.WriteString("\\b")
+		} else if c == '\f' {
+			sb.WriteString("\\f")
+		} else if c == '\n' {
+			sb.WriteString("\\n")
+		} else if c == '\r' {
+			sb.WriteString("\\r")
+		} else if c == '\t' {
+			sb.WriteString("\\t")
+		} else if c < 0x20 {
+			sb.WriteString(fmt.Sprintf("\\u%04x", c))
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+}
+
+func dynamicWriteDumpString(sb *strings.Builder, val string) {
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+		if c == '\\' {
+			sb.WriteString("\\\\")
+		} else if c == '"' {
+			sb.WriteString("\\\"")
+		} else if c == '\n' {
+			sb.WriteString("\\n")
+		} else if c == '\r' {
+			sb.WriteString("\\r")
+		} else if c == '\t' {
+			sb.WriteString("\\t")
+		} else if c < 0x20 || c == 0x7f {
+			sb.WriteString(fmt.Sprintf("\\x%02X", c))
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+}
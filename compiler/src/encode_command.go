@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printEncodeUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"encode json from stdin to binary by protocol schema\n"+
+		"usage: %s encode "+
+		"-f <protocol_file> "+
+		"(-t <struct> | --enum-map <enum_map> --id <id>)"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"    [-i <input_file>] default is stdin\n"+
+		"    [-o <output_file>] default is stdout\n"+
+		"struct and enum_map name can be prefixed by imported protocol "+
+		"name, e.g. attr.Attr\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runEncodeCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePath string
+	var optSearchPath []string
+	var optStructName string
+	var optEnumMapName string
+	var optId int
+	var optInputFilePath string
+	var optOutputFilePath string
+
+	flagSet := flag.NewFlagSet("encode", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVarP(&optStructName, "type", "t", "", "")
+	flagSet.StringVar(&optEnumMapName, "enum-map", "", "")
+	flagSet.IntVar(&optId, "id", -1, "")
+	flagSet.StringVarP(&optInputFilePath, "input", "i", "", "")
+	flagSet.StringVarP(&optOutputFilePath, "output", "o", "", "")
+
+	if flagSet.Parse(args) != nil {
+		printEncodeUsage()
+		return 1
+	}
+	if optHelp {
+		printEncodeUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if optProtoFilePath == "" ||
+		(optStructName == "") == (optEnumMapName == "") {
+		printEncodeUsage()
+		return 1
+	}
+
+	// -- check option proto_file_path
+	if UtilCheckFileExists(optProtoFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find protocol file `%s`\n",
+			optProtoFilePath)
+		return 1
+	}
+
+	// -- check option enum_map
+	if optEnumMapName != "" && flagSet.Changed("id") == false {
+		fmt.Fprintf(os.Stderr,
+			"error: option --id is required by --enum-map\n")
+		return 1
+	}
+
+	// create parser
+	parser := NewProtocolParser()
+	if parser.Parse(optProtoFilePath, optSearchPath) == false {
+		return 1
+	}
+	defer parser.Close()
+
+	// find struct to encode
+	structDef := findStructDefByOptions(parser.Descriptor,
+		optStructName, optEnumMapName, optId)
+	if structDef == nil {
+		return 1
+	}
+
+	// read input
+	var input []byte
+	var err error
+	if optInputFilePath == "" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(optInputFilePath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: read input failed: %s\n", err.Error())
+		return 1
+	}
+
+	// validate and encode
+	reader := NewDynamicJsonReader()
+	value := reader.Read(structDef, input)
+	if value == nil {
+		fmt.Fprintf(os.Stderr,
+			"error: %s\n", reader.ErrorMessage())
+		return 1
+	}
+
+	encoder := NewDynamicEncoder()
+	output := encoder.Encode(value)
+	if output == nil {
+		fmt.Fprintf(os.Stderr,
+			"error: encode failed: %s\n", encoder.ErrorMessage())
+		return 1
+	}
+
+	// write output
+	if optOutputFilePath == "" {
+		_, err = os.Stdout.Write(output)
+	} else {
+		err = os.WriteFile(optOutputFilePath, output, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: write output failed: %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schema fingerprint is a hash over a canonical text of the definition
+// and all structs and enums it references, so two builds from different
+// protocol revisions can be told apart in handshake.
+// only wire relevant things take part in canonical text, comments,
+// line numbers and namespaces do not change the fingerprint
+type FingerprintBuilder struct {
+	// qualified name -> canonical text of referenced definitions
+	refs map[string]string
+}
+
+func NewFingerprintBuilder() *FingerprintBuilder {
+	newObj := new(FingerprintBuilder)
+	newObj.refs = make(map[string]string)
+
+	return newObj
+}
+
+func FingerprintStruct(structDef *StructDef) string {
+	builder := NewFingerprintBuilder()
+	builder.addStruct(structDef)
+
+	return builder.hash(
+		"struct " + StructDefGetQualifiedName(structDef))
+}
+
+func FingerprintEnumMap(enumMapDef *EnumMapDef) string {
+	builder := NewFingerprintBuilder()
+	builder.addEnumMap(enumMapDef)
+
+	return builder.hash(
+		"enum_map " + enumMapDef.ParentRef.Name + "." + enumMapDef.Name)
+}
+
+func FingerprintProtocol(protoDef *ProtocolDef) string {
+	builder := NewFingerprintBuilder()
+	for _, def := range protoDef.Enums {
+		builder.addEnum(def)
+	}
+	for _, def := range protoDef.Structs {
+		builder.addStruct(def)
+	}
+	for _, def := range protoDef.EnumMaps {
+		builder.addEnumMap(def)
+	}
+
+	return builder.hash("protocol " + protoDef.Name)
+}
+
+// root definition first, then the others sorted by qualified name
+func (this *FingerprintBuilder) hash(rootName string) string {
+	names := make([]string, 0, len(this.refs))
+	for name := range this.refs {
+		if name != rootName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	if text, ok := this.refs[rootName]; ok {
+		sb.WriteString(text)
+	} else {
+		sb.WriteString(rootName + "{}")
+	}
+	for _, name := range names {
+		sb.WriteString(this.refs[name])
+	}
+
+	sum := sha256.Sum256([]byte(sb.String()))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func (this *FingerprintBuilder) addEnum(enumDef *EnumDef) {
+	name := "enum " + enumDef.ParentRef.Name + "." + enumDef.Name
+	if _, ok := this.refs[name]; ok {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name + "{")
+	for _, def := range enumDef.Items {
+		sb.WriteString(fmt.Sprintf("%s=%d;", def.Name, def.IntValue))
+	}
+	sb.WriteString("}")
+
+	this.refs[name] = sb.String()
+}
+
+func (this *FingerprintBuilder) addStruct(structDef *StructDef) {
+	name := "struct " + StructDefGetQualifiedName(structDef)
+	if _, ok := this.refs[name]; ok {
+		return
+	}
+	// mark first to stop on recursive reference
+	this.refs[name] = ""
+
+	var sb strings.Builder
+	sb.WriteString(name + "{")
+	for _, def := range structDef.Fields {
+		if def.IsOptional {
+			sb.WriteString("optional ")
+		} else {
+			sb.WriteString("required ")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s;",
+			def.Name, StructFieldDefGetTypeName(def)))
+
+		if def.RefEnumDef != nil {
+			this.addEnum(def.RefEnumDef)
+		}
+		if def.RefStructDef != nil {
+			this.addStruct(def.RefStructDef)
+		}
+	}
+	sb.WriteString("}")
+
+	this.refs[name] = sb.String()
+}
+
+func (this *FingerprintBuilder) addEnumMap(enumMapDef *EnumMapDef) {
+	name := "enum_map " + enumMapDef.ParentRef.Name + "." + enumMapDef.Name
+	if _, ok := this.refs[name]; ok {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name + "{")
+	for _, def := range enumMapDef.Items {
+		if def.RefStructDef == nil {
+			sb.WriteString(fmt.Sprintf("%s=%d;", def.Name, def.IntValue))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s=%d:%s;",
+				def.Name, def.IntValue,
+				StructDefGetQualifiedName(def.RefStructDef)))
+			this.addStruct(def.RefStructDef)
+		}
+	}
+	sb.WriteString("}")
+
+	this.refs[name] = sb.String()
+}
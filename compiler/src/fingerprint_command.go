@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printFingerprintUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"print schema fingerprints of protocol\n"+
+		"usage: %s fingerprint "+
+		"-f <protocol_file>"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"    [-t <struct>] print fingerprint of struct only\n"+
+		"    [--enum-map <enum_map> [--id <id>]] print fingerprint of "+
+		"enum map or struct of id only\n"+
+		"struct and enum_map name can be prefixed by imported protocol "+
+		"name, e.g. attr.Attr\n"+
+		"without -t and --enum-map, fingerprints of protocol and all its "+
+		"structs and enum maps are printed\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runFingerprintCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePath string
+	var optSearchPath []string
+	var optStructName string
+	var optEnumMapName string
+	var optId int
+
+	flagSet := flag.NewFlagSet("fingerprint", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVarP(&optStructName, "type", "t", "", "")
+	flagSet.StringVar(&optEnumMapName, "enum-map", "", "")
+	flagSet.IntVar(&optId, "id", -1, "")
+
+	if flagSet.Parse(args) != nil {
+		printFingerprintUsage()
+		return 1
+	}
+	if optHelp {
+		printFingerprintUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if optProtoFilePath == "" ||
+		(optStructName != "" && optEnumMapName != "") {
+		printFingerprintUsage()
+		return 1
+	}
+
+	// -- check option proto_file_path
+	if UtilCheckFileExists(optProtoFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find protocol file `%s`\n",
+			optProtoFilePath)
+		return 1
+	}
+
+	// -- check option id
+	if optEnumMapName == "" && flagSet.Changed("id") {
+		fmt.Fprintf(os.Stderr,
+			"error: option --id requires --enum-map\n")
+		return 1
+	}
+
+	// create parser
+	parser := NewProtocolParser()
+	if parser.Parse(optProtoFilePath, optSearchPath) == false {
+		return 1
+	}
+	defer parser.Close()
+
+	descriptor := parser.Descriptor
+
+	// single definition
+	if optStructName != "" || flagSet.Changed("id") {
+		structDef := findStructDefByOptions(descriptor,
+			optStructName, optEnumMapName, optId)
+		if structDef == nil {
+			return 1
+		}
+		fmt.Println(FingerprintStruct(structDef))
+		return 0
+	}
+	if optEnumMapName != "" {
+		enumMapDef := descriptor.FindEnumMapDef(optEnumMapName)
+		if enumMapDef == nil {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find enum map `%s`\n",
+				optEnumMapName)
+			return 1
+		}
+		fmt.Println(FingerprintEnumMap(enumMapDef))
+		return 0
+	}
+
+	// all definitions of protocol
+	protoDef := descriptor.ProtoDef
+
+	fmt.Printf("%s protocol %s\n",
+		FingerprintProtocol(protoDef), protoDef.Name)
+	for _, def := range protoDef.Structs {
+		fmt.Printf("%s struct %s\n",
+			FingerprintStruct(def), StructDefGetQualifiedName(def))
+	}
+	for _, def := range protoDef.EnumMaps {
+		fmt.Printf("%s enum_map %s.%s\n",
+			FingerprintEnumMap(def), protoDef.Name, def.Name)
+	}
+
+	return 0
+}
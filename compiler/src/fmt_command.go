@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printFmtUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"rewrite protocol files into canonical layout\n"+
+		"usage: %s fmt "+
+		"-f <protocol_file>"+
+		"\n"+
+		"    [-f <protocol_file>] more protocol files, directories or globs\n"+
+		"    [--check] do not write, list files not formatted and "+
+		"exit with 1\n"+
+		"only xml files are formatted, `*%s` files are left as they are\n",
+		filepath.Base(os.Args[0]), ProtocolTextFileExtension)
+}
+
+func runFmtCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePaths []string
+	var optCheck bool
+
+	flagSet := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringArrayVarP(&optProtoFilePaths, "-proto_file_path", "f", []string{}, "")
+	flagSet.BoolVar(&optCheck, "check", false, "")
+
+	if flagSet.Parse(args) != nil {
+		printFmtUsage()
+		return 1
+	}
+	if optHelp {
+		printFmtUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if len(optProtoFilePaths) == 0 {
+		printFmtUsage()
+		return 1
+	}
+
+	// -- check option proto_file_path
+	protoFilePaths := getProtoFilePaths(optProtoFilePaths)
+	if protoFilePaths == nil {
+		return 1
+	}
+
+	exitCode := 0
+	formatter := NewProtocolFormatter()
+	for _, filePath := range protoFilePaths {
+		if ProtocolFileIsText(filePath) {
+			continue
+		}
+
+		fileBin, err := os.ReadFile(filePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr,
+				"error: can not read protocol file `%s`: %s\n",
+				filePath, err.Error())
+			exitCode = 1
+			continue
+		}
+		fileText := string(fileBin)
+
+		formattedText, ok := formatter.Format(filePath, fileText)
+		if ok == false {
+			exitCode = 1
+			continue
+		}
+		if formattedText == fileText {
+			continue
+		}
+
+		if optCheck {
+			fmt.Printf("%s\n", filePath)
+			exitCode = 1
+		} else {
+			if UtilWriteAllText(filePath, formattedText) == false {
+				exitCode = 1
+				continue
+			}
+			fmt.Printf("formatted: %s\n", filePath)
+		}
+	}
+
+	return exitCode
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printGraphUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"print dependency graph of protocol\n"+
+		"usage: %s graph "+
+		"-f <protocol_file>"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"    [-g <graph>] (import|type) default is import, "+
+		"type is used if -t is given\n"+
+		"    [--format <format>] (dot|json) default is dot\n"+
+		"    [-t <struct>] print types the struct depends on only\n"+
+		"    [--reverse] with -t, print types depending on the struct\n"+
+		"struct name can be prefixed by imported protocol name, "+
+		"e.g. attr.Attr\n"+
+		"import graph links protocols to their imports, type graph links "+
+		"structs to types of their fields and enum maps to their structs\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runGraphCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePath string
+	var optSearchPath []string
+	var optGraph string
+	var optFormat string
+	var optStructName string
+	var optReverse bool
+
+	flagSet := flag.NewFlagSet("graph", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVarP(&optGraph, "graph", "g", "", "")
+	flagSet.StringVar(&optFormat, "format", "", "")
+	flagSet.StringVarP(&optStructName, "type", "t", "", "")
+	flagSet.BoolVar(&optReverse, "reverse", false, "")
+
+	if flagSet.Parse(args) != nil {
+		printGraphUsage()
+		return 1
+	}
+	if optHelp {
+		printGraphUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if optProtoFilePath == "" {
+		printGraphUsage()
+		return 1
+	}
+
+	// -- option default value
+	if optGraph == "" {
+		if optStructName != "" {
+			optGraph = "type"
+		} else {
+			optGraph = "import"
+		}
+	}
+	if optFormat == "" {
+		optFormat = "dot"
+	}
+
+	// -- check option proto_file_path
+	if UtilCheckFileExists(optProtoFilePath) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find protocol file `%s`\n",
+			optProtoFilePath)
+		return 1
+	}
+
+	// -- check option graph
+	if optGraph != "import" && optGraph != "type" {
+		fmt.Fprintf(os.Stderr,
+			"error: graph `%s` is invalid\n", optGraph)
+		return 1
+	}
+	if optStructName != "" && optGraph != "type" {
+		fmt.Fprintf(os.Stderr,
+			"error: option -t requires type graph\n")
+		return 1
+	}
+
+	// -- check option format
+	if optFormat != "dot" && optFormat != "json" {
+		fmt.Fprintf(os.Stderr,
+			"error: format `%s` is invalid\n", optFormat)
+		return 1
+	}
+
+	// -- check option reverse
+	if optReverse && optStructName == "" {
+		fmt.Fprintf(os.Stderr,
+			"error: option --reverse requires -t\n")
+		return 1
+	}
+
+	// create parser
+	parser := NewProtocolParser()
+	if parser.Parse(optProtoFilePath, optSearchPath) == false {
+		return 1
+	}
+	defer parser.Close()
+
+	descriptor := parser.Descriptor
+
+	var graph *DependencyGraph
+	if optGraph == "import" {
+		graph = BuildImportGraph(descriptor)
+	} else {
+		graph = BuildTypeGraph(descriptor)
+	}
+
+	// closure of struct
+	if optStructName != "" {
+		structDef := descriptor.FindStructDef(optStructName)
+		if structDef == nil {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find struct `%s`\n", optStructName)
+			return 1
+		}
+		graph = graph.Closure(
+			StructDefGetQualifiedName(structDef), optReverse)
+	}
+
+	if optFormat == "dot" {
+		graph.WriteDot(os.Stdout)
+	} else {
+		graph.WriteJson(os.Stdout)
+	}
+
+	return 0
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type HtmlCodeGenerator struct {
+	BaseCodeGenerator
+
+	// names of protocols having a page
+	pages map[string]bool
+}
+
+// protoDefs are all protocols generated, pages only link to them
+func NewHtmlCodeGenerator(protoDefs []*ProtocolDef) *HtmlCodeGenerator {
+	newObj := new(HtmlCodeGenerator)
+	newObj.pages = docGetPages(protoDefs)
+
+	return newObj
+}
+
+func (this *HtmlCodeGenerator) Close() {
+	this.close()
+}
+
+// pages link each other by relative path, so namespace directories
+// are not used
+func (this *HtmlCodeGenerator) Generate(
+	descriptor *ProtocolDescriptor,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
+
+	this.init(descriptor, newLineType, false)
+
+	docFilePath := this.getOutputFilePath(outputDir, "html",
+		this.descriptor.ProtoDef.Name+".html")
+	docFileContent := this.generateDocFile()
+	if this.writeOutputFile(docFilePath, docFileContent) == false {
+		return false
+	}
+
+	return true
+}
+
+func (this *HtmlCodeGenerator) generateDocFile() string {
+	var sb strings.Builder
+
+	this.writeHeader(&sb)
+	this.writeTitle(&sb)
+	this.writeNamespaces(&sb)
+	this.writeImports(&sb)
+	this.writeEnums(&sb)
+	this.writeStructs(&sb)
+	this.writeEnumMaps(&sb)
+	this.writeFooter(&sb)
+
+	return sb.String()
+}
+
+func (this *HtmlCodeGenerator) writeHeader(sb *strings.Builder) {
+	this.writeLine(sb, "<!DOCTYPE html>")
+	this.writeLine(sb,
+		"<!-- Generated by brickred exchange compiler. Do not edit. -->")
+	this.writeLine(sb, "<html>")
+	this.writeLine(sb, "<head>")
+	this.writeLine(sb, "<meta charset=\"utf-8\">")
+	this.writeLineFormat(sb, "<title>Protocol %s</title>",
+		this.escape(this.descriptor.ProtoDef.Name))
+	this.writeLine(sb, "<style>")
+	this.writeLine(sb, "body { font-family: sans-serif; margin: 2em; }")
+	this.writeLine(sb, "table { border-collapse: collapse; margin-bottom: 1em; }")
+	this.writeLine(sb, "th, td { border: 1px solid #ccc; padding: 4px 8px; "+
+		"text-align: left; }")
+	this.writeLine(sb, "code { font-family: monospace; }")
+	this.writeLine(sb, "</style>")
+	this.writeLine(sb, "</head>")
+	this.writeLine(sb, "<body>")
+}
+
+func (this *HtmlCodeGenerator) writeFooter(sb *strings.Builder) {
+	this.writeLine(sb, "</body>")
+	this.writeLine(sb, "</html>")
+}
+
+func (this *HtmlCodeGenerator) escape(text string) string {
+	return html.EscapeString(text)
+}
+
+func (this *HtmlCodeGenerator) getLink(text string, href string) string {
+	if href == "" {
+		return this.escape(text)
+	} else {
+		return "<a href=\"" + this.escape(href) + "\">" +
+			this.escape(text) + "</a>"
+	}
+}
+
+func (this *HtmlCodeGenerator) writeTitle(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+
+	this.writeLineFormat(sb, "<h1>Protocol %s</h1>",
+		this.escape(protoDef.Name))
+
+	// navigation to all protocols
+	links := make([]string, 0)
+	for _, def := range docGetSortedProtoDefs(this.descriptor, this.pages) {
+		if def == protoDef {
+			links = append(links, "<b>"+this.escape(def.Name)+"</b>")
+		} else {
+			links = append(links, this.getLink(def.Name, def.Name+".html"))
+		}
+	}
+	this.writeLineFormat(sb, "<p>Protocols: %s</p>",
+		strings.Join(links, " | "))
+
+	this.writeLineFormat(sb, "<p>Fingerprint: <code>%s</code></p>",
+		FingerprintProtocol(protoDef))
+}
+
+func (this *HtmlCodeGenerator) writeNamespaces(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Namespaces) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "<h2>Namespaces</h2>")
+	this.writeLine(sb, "<table>")
+	this.writeLine(sb, "<tr><th>Language</th><th>Namespace</th></tr>")
+	for _, language := range docGetNamespaceLanguages(protoDef) {
+		this.writeLineFormat(sb,
+			"<tr><td>%s</td><td><code>%s</code></td></tr>",
+			this.escape(language),
+			this.escape(protoDef.Namespaces[language].Namespace))
+	}
+	this.writeLine(sb, "</table>")
+}
+
+func (this *HtmlCodeGenerator) writeImports(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Imports) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "<h2>Imports</h2>")
+	this.writeLine(sb, "<ul>")
+	for _, def := range protoDef.Imports {
+		this.writeLineFormat(sb, "<li>%s</li>",
+			this.getLink(def.Name, docGetPageHref(def.Name, ".html", this.pages)))
+	}
+	this.writeLine(sb, "</ul>")
+}
+
+func (this *HtmlCodeGenerator) writeEnums(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Enums) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "<h2>Enums</h2>")
+	for _, enumDef := range protoDef.Enums {
+		this.writeLineFormat(sb, "<h3 id=\"%s\">%s</h3>",
+			docGetAnchor("enum", enumDef.Name),
+			this.escape(enumDef.Name))
+		this.writeLine(sb, "<table>")
+		this.writeLine(sb, "<tr><th>Name</th><th>Value</th></tr>")
+		for _, def := range enumDef.Items {
+			this.writeLineFormat(sb, "<tr><td>%s</td><td>%s</td></tr>",
+				this.escape(def.Name), this.getEnumItemValue(def))
+		}
+		this.writeLine(sb, "</table>")
+	}
+}
+
+// value with the item it refers to, e.g. 6 (= attr.AttrType.MAX)
+func (this *HtmlCodeGenerator) getEnumItemValue(
+	enumItemDef *EnumItemDef) string {
+
+	refDef := enumItemDef.RefEnumItemDef
+	if refDef == nil {
+		return fmt.Sprintf("%d", enumItemDef.IntValue)
+	}
+
+	protoDef := this.descriptor.ProtoDef
+	var refName string
+	if refDef.ParentRef == enumItemDef.ParentRef {
+		refName = refDef.Name
+	} else {
+		refName = docGetRefName(protoDef, refDef.ParentRef.ParentRef,
+			refDef.ParentRef.Name) + "." + refDef.Name
+	}
+	href := docGetHref(protoDef, refDef.ParentRef.ParentRef,
+		docGetAnchor("enum", refDef.ParentRef.Name), ".html",
+		this.pages)
+
+	return fmt.Sprintf("%d (= %s)",
+		enumItemDef.IntValue, this.getLink(refName, href))
+}
+
+func (this *HtmlCodeGenerator) writeStructs(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Structs) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "<h2>Structs</h2>")
+	for _, structDef := range protoDef.Structs {
+		this.writeLineFormat(sb, "<h3 id=\"%s\">%s</h3>",
+			docGetAnchor("struct", structDef.Name),
+			this.escape(structDef.Name))
+		this.writeLineFormat(sb, "<p>Fingerprint: <code>%s</code></p>",
+			FingerprintStruct(structDef))
+		if len(structDef.Fields) == 0 {
+			this.writeLine(sb, "<p>No fields.</p>")
+			continue
+		}
+		this.writeLine(sb, "<table>")
+		this.writeLine(sb,
+			"<tr><th>Name</th><th>Type</th><th>Optional</th></tr>")
+		for _, def := range structDef.Fields {
+			optional := ""
+			if def.IsOptional {
+				optional = "optional"
+			}
+			this.writeLineFormat(sb,
+				"<tr><td>%s</td><td>%s</td><td>%s</td></tr>",
+				this.escape(def.Name), this.getFieldType(def), optional)
+		}
+		this.writeLine(sb, "</table>")
+	}
+}
+
+// e.g. list{<a href="attr.html#struct-Attr">attr.Attr</a>}
+func (this *HtmlCodeGenerator) getFieldType(
+	fieldDef *StructFieldDef) string {
+
+	name, href := docGetFieldItemType(
+		this.descriptor.ProtoDef, fieldDef, ".html", this.pages)
+	if fieldDef.Type == StructFieldType_List {
+		return "list{" + this.getLink(name, href) + "}"
+	} else {
+		return this.getLink(name, href)
+	}
+}
+
+func (this *HtmlCodeGenerator) writeEnumMaps(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.EnumMaps) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "<h2>Enum Maps</h2>")
+	for _, enumMapDef := range protoDef.EnumMaps {
+		this.writeLineFormat(sb, "<h3 id=\"%s\">%s</h3>",
+			docGetAnchor("enum_map", enumMapDef.Name),
+			this.escape(enumMapDef.Name))
+		this.writeLineFormat(sb, "<p>Fingerprint: <code>%s</code></p>",
+			FingerprintEnumMap(enumMapDef))
+		this.writeLine(sb, "<table>")
+		this.writeLine(sb,
+			"<tr><th>Name</th><th>Id</th><th>Struct</th></tr>")
+		for _, def := range enumMapDef.Items {
+			structLink := ""
+			if def.RefStructDef != nil {
+				refDef := def.RefStructDef
+				structLink = this.getLink(
+					docGetRefName(protoDef, refDef.ParentRef, refDef.Name),
+					docGetHref(protoDef, refDef.ParentRef,
+						docGetAnchor("struct", refDef.Name), ".html",
+						this.pages))
+			}
+			this.writeLineFormat(sb,
+				"<tr><td>%s</td><td>%d</td><td>%s</td></tr>",
+				this.escape(def.Name), def.IntValue, structLink)
+		}
+		this.writeLine(sb, "</table>")
+	}
+}
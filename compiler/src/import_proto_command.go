@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printImportProtoUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"convert protobuf schema files to protocol files\n"+
+		"usage: %s import-proto "+
+		"-f <proto_file>"+
+		"\n"+
+		"    [-f <proto_file>] more proto files\n"+
+		"    [--with-imports] also convert every imported proto file\n"+
+		"    [-o <output_dir>] default is .\n"+
+		"    [-I <search_path>] search paths of imported proto files\n"+
+		"    [--diagnostics-format <format>] (text|gcc|json) "+
+		"default is text\n"+
+		"each `<name>.proto` is written to `<output_dir>/<name>.xml`, "+
+		"constructs without brickred equivalent are reported as warnings\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runImportProtoCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProtoFilePaths []string
+	var optWithImports bool
+	var optOutputDir string
+	var optSearchPath []string
+	var optDiagnosticsFormat string
+
+	flagSet := flag.NewFlagSet("import-proto", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringArrayVarP(&optProtoFilePaths, "-proto_file_path", "f", []string{}, "")
+	flagSet.BoolVar(&optWithImports, "with-imports", false, "")
+	flagSet.StringVarP(&optOutputDir, "-output_dir", "o", "", "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVar(&optDiagnosticsFormat, "diagnostics-format", "", "")
+
+	if flagSet.Parse(args) != nil {
+		printImportProtoUsage()
+		return 1
+	}
+	if optHelp {
+		printImportProtoUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- required options
+	if len(optProtoFilePaths) == 0 {
+		printImportProtoUsage()
+		return 1
+	}
+
+	// -- option default value
+	if optOutputDir == "" {
+		optOutputDir = "."
+	}
+	if optDiagnosticsFormat == "" {
+		optDiagnosticsFormat = "text"
+	}
+
+	// -- check option proto_file_path
+	for _, filePath := range optProtoFilePaths {
+		if UtilCheckFileExists(filePath) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find proto file `%s`\n", filePath)
+			return 1
+		}
+	}
+
+	// -- check option output_dir
+	if UtilCheckDirExists(optOutputDir) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find output directory `%s`\n", optOutputDir)
+		return 1
+	}
+
+	// -- check option diagnostics_format
+	if DiagnosticsFormatIsValid(optDiagnosticsFormat) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: diagnostics_format `%s` is invalid\n",
+			optDiagnosticsFormat)
+		return 1
+	}
+
+	importer := NewProtoSchemaImporter(optSearchPath)
+	files := importer.Load(optProtoFilePaths)
+	if files == nil {
+		importer.Diagnostics.Print(os.Stderr, optDiagnosticsFormat)
+		return 1
+	}
+	if optWithImports {
+		files = importer.Files
+	}
+
+	// convert all files before writing any
+	outputTexts := make([]string, 0, len(files))
+	ok := true
+	for _, file := range files {
+		text, convertOk := importer.Convert(file)
+		if convertOk == false {
+			ok = false
+		}
+		outputTexts = append(outputTexts, text)
+	}
+	importer.Diagnostics.Print(os.Stderr, optDiagnosticsFormat)
+	if ok == false {
+		return 1
+	}
+
+	for i, file := range files {
+		outputFilePath := filepath.Join(optOutputDir,
+			ProtoSchemaGetProtocolName(file)+".xml")
+		if UtilWriteAllText(outputFilePath, outputTexts[i]) == false {
+			return 1
+		}
+		fmt.Printf("converted: %s -> %s\n", file.FilePath, outputFilePath)
+	}
+
+	return 0
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// json object keeping key order, so output is stable and readable
+type jsonSchemaObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJsonSchemaObject() *jsonSchemaObject {
+	newObj := new(jsonSchemaObject)
+	newObj.keys = make([]string, 0)
+	newObj.values = make(map[string]any)
+
+	return newObj
+}
+
+func (this *jsonSchemaObject) Set(key string, value any) *jsonSchemaObject {
+	if _, ok := this.values[key]; ok == false {
+		this.keys = append(this.keys, key)
+	}
+	this.values[key] = value
+
+	return this
+}
+
+func (this *jsonSchemaObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString("{")
+	for i, key := range this.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		keyBin, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueBin, err := json.Marshal(this.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyBin)
+		buf.WriteString(":")
+		buf.Write(valueBin)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+// ----------------------------------------------------------------------------
+// describes json of php `toJson()`, 64 bit integers are decimal strings,
+// bytes are base64 strings, enums are their values
+type JsonSchemaCodeGenerator struct {
+	BaseCodeGenerator
+}
+
+func NewJsonSchemaCodeGenerator() *JsonSchemaCodeGenerator {
+	newObj := new(JsonSchemaCodeGenerator)
+
+	return newObj
+}
+
+func (this *JsonSchemaCodeGenerator) Close() {
+	this.close()
+}
+
+// documents reference each other by relative path, so namespace
+// directories are not used
+func (this *JsonSchemaCodeGenerator) Generate(
+	descriptor *ProtocolDescriptor,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
+
+	this.init(descriptor, newLineType, false)
+
+	schemaFilePath := this.getOutputFilePath(outputDir, "jsonschema",
+		this.getSchemaFileName(this.descriptor.ProtoDef))
+	schemaFileContent := this.generateSchemaFile()
+	if this.writeOutputFile(schemaFilePath, schemaFileContent) == false {
+		return false
+	}
+
+	return true
+}
+
+func (this *JsonSchemaCodeGenerator) getSchemaFileName(
+	protoDef *ProtocolDef) string {
+
+	return protoDef.Name + ".schema.json"
+}
+
+// e.g. #/$defs/Attr or attr.schema.json#/$defs/Attr
+func (this *JsonSchemaCodeGenerator) getRef(
+	protoDef *ProtocolDef, name string) string {
+
+	if protoDef == this.descriptor.ProtoDef {
+		return "#/$defs/" + name
+	} else {
+		return this.getSchemaFileName(protoDef) + "#/$defs/" + name
+	}
+}
+
+func (this *JsonSchemaCodeGenerator) generateSchemaFile() string {
+	var sb strings.Builder
+
+	protoDef := this.descriptor.ProtoDef
+
+	defs := newJsonSchemaObject()
+	for _, def := range protoDef.Enums {
+		defs.Set(def.Name, this.getEnumSchema(def))
+	}
+	for _, def := range protoDef.Structs {
+		defs.Set(def.Name, this.getStructSchema(def))
+	}
+	for _, def := range protoDef.EnumMaps {
+		defs.Set(def.Name, this.getEnumMapSchema(def))
+	}
+
+	root := newJsonSchemaObject()
+	root.Set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	root.Set("$id", this.getSchemaFileName(protoDef))
+	root.Set("$comment", "Generated by brickred exchange compiler. "+
+		"Do not edit unless you are sure that you know what you are doing.")
+	root.Set("title", "Protocol "+protoDef.Name)
+	root.Set("$defs", defs)
+
+	output, _ := json.MarshalIndent(root, "", "  ")
+	for _, line := range strings.Split(string(output), "\n") {
+		this.writeLine(&sb, line)
+	}
+
+	return sb.String()
+}
+
+// enum is its value, items are named by title,
+// anyOf allows items of the same value
+func (this *JsonSchemaCodeGenerator) getEnumSchema(
+	enumDef *EnumDef) *jsonSchemaObject {
+
+	items := make([]any, 0, len(enumDef.Items))
+	for _, def := range enumDef.Items {
+		items = append(items, newJsonSchemaObject().
+			Set("const", def.IntValue).
+			Set("title", def.Name))
+	}
+
+	return newJsonSchemaObject().
+		Set("title", enumDef.Name).
+		Set("type", "integer").
+		Set("anyOf", items)
+}
+
+// enum map is its id, items are named by title and mapped struct
+func (this *JsonSchemaCodeGenerator) getEnumMapSchema(
+	enumMapDef *EnumMapDef) *jsonSchemaObject {
+
+	items := make([]any, 0, len(enumMapDef.Items))
+	for _, def := range enumMapDef.Items {
+		item := newJsonSchemaObject().
+			Set("const", def.IntValue).
+			Set("title", def.Name)
+		if def.RefStructDef != nil {
+			item.Set("description",
+				StructDefGetQualifiedName(def.RefStructDef))
+		}
+		items = append(items, item)
+	}
+
+	return newJsonSchemaObject().
+		Set("title", enumMapDef.Name).
+		Set("type", "integer").
+		Set("anyOf", items)
+}
+
+// optional fields not set are absent, a struct without any field
+// written is encoded by php as empty array `[]`
+func (this *JsonSchemaCodeGenerator) getStructSchema(
+	structDef *StructDef) *jsonSchemaObject {
+
+	properties := newJsonSchemaObject()
+	required := make([]string, 0)
+	for _, def := range structDef.Fields {
+		properties.Set(def.Name, this.getFieldSchema(def))
+		if def.IsOptional == false {
+			required = append(required, def.Name)
+		}
+	}
+
+	objectSchema := newJsonSchemaObject()
+	if len(required) > 0 {
+		objectSchema.Set("title", structDef.Name)
+	}
+	objectSchema.Set("type", "object")
+	objectSchema.Set("properties", properties)
+	if len(required) > 0 {
+		objectSchema.Set("required", required)
+	}
+	objectSchema.Set("additionalProperties", false)
+
+	if len(required) > 0 {
+		return objectSchema
+	}
+
+	emptyArraySchema := newJsonSchemaObject().
+		Set("type", "array").
+		Set("maxItems", 0)
+
+	return newJsonSchemaObject().
+		Set("title", structDef.Name).
+		Set("anyOf", []any{objectSchema, emptyArraySchema})
+}
+
+func (this *JsonSchemaCodeGenerator) getFieldSchema(
+	fieldDef *StructFieldDef) *jsonSchemaObject {
+
+	if fieldDef.Type == StructFieldType_List {
+		return newJsonSchemaObject().
+			Set("description", StructFieldDefGetTypeName(fieldDef)).
+			Set("type", "array").
+			Set("items", this.getItemSchema(fieldDef, fieldDef.ListType))
+	}
+
+	return this.getItemSchema(fieldDef, fieldDef.Type)
+}
+
+func (this *JsonSchemaCodeGenerator) getItemSchema(
+	fieldDef *StructFieldDef, itemType StructFieldType) *jsonSchemaObject {
+
+	if itemType == StructFieldType_Enum {
+		refDef := fieldDef.RefEnumDef
+		return newJsonSchemaObject().
+			Set("$ref", this.getRef(refDef.ParentRef, refDef.Name))
+	} else if itemType == StructFieldType_Struct {
+		refDef := fieldDef.RefStructDef
+		return newJsonSchemaObject().
+			Set("$ref", this.getRef(refDef.ParentRef, refDef.Name))
+	}
+
+	schema := newJsonSchemaObject().
+		Set("description", StructFieldTypeGetName(itemType))
+
+	if itemType == StructFieldType_I8 {
+		schema.Set("type", "integer").
+			Set("minimum", -128).Set("maximum", 127)
+	} else if itemType == StructFieldType_U8 {
+		schema.Set("type", "integer").
+			Set("minimum", 0).Set("maximum", 255)
+	} else if itemType == StructFieldType_I16 ||
+		itemType == StructFieldType_I16V {
+		schema.Set("type", "integer").
+			Set("minimum", -32768).Set("maximum", 32767)
+	} else if itemType == StructFieldType_U16 ||
+		itemType == StructFieldType_U16V {
+		schema.Set("type", "integer").
+			Set("minimum", 0).Set("maximum", 65535)
+	} else if itemType == StructFieldType_I32 ||
+		itemType == StructFieldType_I32V {
+		schema.Set("type", "integer").
+			Set("minimum", -2147483648).Set("maximum", 2147483647)
+	} else if itemType == StructFieldType_U32 ||
+		itemType == StructFieldType_U32V {
+		schema.Set("type", "integer").
+			Set("minimum", 0).Set("maximum", 4294967295)
+	} else if itemType == StructFieldType_I64 ||
+		itemType == StructFieldType_I64V {
+		// decimal string, range is not checked
+		schema.Set("type", "string").
+			Set("pattern", "^-?[0-9]+$")
+	} else if itemType == StructFieldType_U64 ||
+		itemType == StructFieldType_U64V {
+		schema.Set("type", "string").
+			Set("pattern", "^[0-9]+$")
+	} else if itemType == StructFieldType_String {
+		schema.Set("type", "string")
+	} else if itemType == StructFieldType_Bytes {
+		schema.Set("type", "string").
+			Set("contentEncoding", "base64")
+	} else if itemType == StructFieldType_Bool {
+		schema.Set("type", "boolean")
+	}
+
+	return schema
+}
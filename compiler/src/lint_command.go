@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printLintUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"check protocol files against lint rules\n"+
+		"usage: %s lint"+
+		"\n"+
+		"    [-c <project_file>] default is brexc.json if exists, "+
+		"lint config is read from its `lint` section\n"+
+		"    [-f <protocol_file>] protocol files, directories or globs, "+
+		"override the project file\n"+
+		"    [-I <search_path>] override the project file\n"+
+		"    [--diagnostics-format <format>] (text|gcc|json) "+
+		"default is text\n"+
+		"rules: naming, unused-definition, enum-map-gap, "+
+		"enum-map-item-without-struct, keyword-name, oversized-struct, "+
+		"missing-doc\n"+
+		"exit with 1 if any rule of error severity is broken\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runLintCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProjectFilePath string
+	var optProtoFilePaths []string
+	var optSearchPath []string
+	var optDiagnosticsFormat string
+
+	flagSet := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProjectFilePath, "project", "c", "", "")
+	flagSet.StringArrayVarP(&optProtoFilePaths, "-proto_file_path", "f", []string{}, "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+	flagSet.StringVar(&optDiagnosticsFormat, "diagnostics-format", "", "")
+
+	if flagSet.Parse(args) != nil {
+		printLintUsage()
+		return 1
+	}
+	if optHelp {
+		printLintUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- option default value
+	if optDiagnosticsFormat == "" {
+		optDiagnosticsFormat = "text"
+	}
+
+	// -- check option project_file
+	config := NewLintConfig()
+	if optProjectFilePath == "" && UtilCheckFileExists("brexc.json") {
+		optProjectFilePath = "brexc.json"
+	}
+	if optProjectFilePath != "" {
+		if UtilCheckFileExists(optProjectFilePath) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find project file `%s`\n",
+				optProjectFilePath)
+			return 1
+		}
+		projectFile := LoadProjectFile(optProjectFilePath)
+		if projectFile == nil {
+			return 1
+		}
+		if flagSet.Changed("-proto_file_path") == false {
+			optProtoFilePaths = projectFile.ProtoFiles
+		}
+		if flagSet.Changed("-search_path") == false {
+			optSearchPath = projectFile.SearchPaths
+		}
+		if projectFile.Lint != nil {
+			config = projectFile.Lint
+		}
+	}
+	if config.Check() == false {
+		return 1
+	}
+
+	// -- required options
+	if len(optProtoFilePaths) == 0 {
+		printLintUsage()
+		return 1
+	}
+
+	// -- check option proto_file_path
+	protoFilePaths := getProtoFilePaths(optProtoFilePaths)
+	if protoFilePaths == nil {
+		return 1
+	}
+
+	// -- check option diagnostics_format
+	if DiagnosticsFormatIsValid(optDiagnosticsFormat) == false {
+		fmt.Fprintf(os.Stderr,
+			"error: diagnostics_format `%s` is invalid\n",
+			optDiagnosticsFormat)
+		return 1
+	}
+
+	// create parser, its diagnostics are printed with lint ones
+	parser := NewProtocolParser()
+	parser.DiagnosticsFormat = ""
+	ok := parser.ParseFiles(protoFilePaths, optSearchPath)
+	defer parser.Close()
+	if ok == false {
+		parser.Diagnostics.Print(os.Stderr, optDiagnosticsFormat)
+		return 1
+	}
+
+	protoDefs := getProtoDefsToGenerate(
+		parser.Descriptor, protoFilePaths, false)
+	if protoDefs == nil {
+		return 1
+	}
+
+	linter := NewProtocolLinter(config)
+	linter.Diagnostics.Items = append(linter.Diagnostics.Items,
+		parser.Diagnostics.Items...)
+	linter.Lint(parser, protoDefs)
+	linter.Diagnostics.Print(os.Stderr, optDiagnosticsFormat)
+
+	if linter.Diagnostics.ErrorCount() > 0 {
+		return 1
+	}
+
+	return 0
+}
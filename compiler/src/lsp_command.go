@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printLspUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"language server of protocol files over stdio\n"+
+		"usage: %s lsp"+
+		"\n"+
+		"    [-I <search_path>]\n"+
+		"imports are also searched in the directory of the edited file\n",
+		filepath.Base(os.Args[0]))
+}
+
+func runLspCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optSearchPath []string
+
+	flagSet := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
+
+	if flagSet.Parse(args) != nil {
+		printLspUsage()
+		return 1
+	}
+	if optHelp {
+		printLspUsage()
+		return 0
+	}
+
+	server := NewLspServer(os.Stdin, os.Stdout, optSearchPath)
+	defer server.Close()
+
+	return server.Run()
+}
@@ -0,0 +1,1016 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var g_lspAttrRegexp *regexp.Regexp = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+var g_lspAttrPrefixRegexp *regexp.Regexp = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)$`)
+var g_lspImportRegexp *regexp.Regexp = regexp.MustCompile(`<import>\s*([^<]*?)\s*</import>`)
+
+// message body larger than this is rejected before reading it
+const lspMaxMessageSize = 64 * 1024 * 1024
+
+// ----------------------------------------------------------------------------
+// language server protocol messages, only fields used are defined
+type lspMessage struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	Uri   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextDocumentIdentifier struct {
+	Uri  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type lspTextDocumentParams struct {
+	TextDocument   lspTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+	Position     lspPosition               `json:"position"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+const (
+	lspCompletionItemKind_Module     = 9
+	lspCompletionItemKind_Enum       = 13
+	lspCompletionItemKind_Keyword    = 14
+	lspCompletionItemKind_EnumMember = 20
+	lspCompletionItemKind_Struct     = 22
+)
+
+// attribute value or import file name under the cursor
+type lspValueContext struct {
+	TagName  string
+	AttrName string
+	Value    string
+	// byte offset of cursor in Value
+	Offset int
+}
+
+// ----------------------------------------------------------------------------
+// every open document is parsed again on each change, together with the
+// other open documents as unsaved buffers, so diagnostics of importing
+// documents follow the edited one
+type LspServer struct {
+	reader     *bufio.Reader
+	writer     io.Writer
+	searchPath []string
+	isShutdown bool
+	// document uri -> text
+	documents map[string]string
+	// document uri -> parser of last validation
+	parsers map[string]*ProtocolParser
+}
+
+func NewLspServer(reader io.Reader, writer io.Writer,
+	searchPath []string) *LspServer {
+
+	newObj := new(LspServer)
+	newObj.reader = bufio.NewReader(reader)
+	newObj.writer = writer
+	newObj.searchPath = searchPath
+	newObj.documents = make(map[string]string)
+	newObj.parsers = make(map[string]*ProtocolParser)
+
+	return newObj
+}
+
+func (this *LspServer) Close() {
+	for _, parser := range this.parsers {
+		parser.Close()
+	}
+	clear(this.parsers)
+}
+
+// serve until exit notification, return process exit code
+func (this *LspServer) Run() int {
+	for {
+		message, err := this.readMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) == false {
+				fmt.Fprintf(os.Stderr,
+					"error: read message failed: %s\n", err.Error())
+			}
+			return 1
+		}
+		if message == nil {
+			continue
+		}
+
+		if message.Method == "exit" {
+			if this.isShutdown {
+				return 0
+			} else {
+				return 1
+			}
+		}
+		this.handleMessage(message)
+	}
+}
+
+// return nil message without error if body is not valid json,
+// parse error is replied and the next message can still be read
+func (this *LspServer) readMessage() (*lspMessage, error) {
+	contentLength := -1
+	for {
+		line, err := this.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(
+			strings.TrimSpace(name), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid header `%s`", line)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	if contentLength > lspMaxMessageSize {
+		return nil, fmt.Errorf("Content-Length %d exceeds limit %d",
+			contentLength, lspMaxMessageSize)
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(this.reader, body); err != nil {
+		return nil, err
+	}
+
+	message := new(lspMessage)
+	if err := json.Unmarshal(body, message); err != nil {
+		this.writeError(json.RawMessage("null"), -32700, "parse error")
+		return nil, nil
+	}
+
+	return message, nil
+}
+
+func (this *LspServer) writeMessage(message map[string]any) {
+	message["jsonrpc"] = "2.0"
+	body, err := json.Marshal(message)
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: write message failed: %s\n", err.Error())
+		return
+	}
+
+	fmt.Fprintf(this.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (this *LspServer) writeResult(id json.RawMessage, result any) {
+	this.writeMessage(map[string]any{
+		"id":     id,
+		"result": result,
+	})
+}
+
+func (this *LspServer) writeError(id json.RawMessage,
+	code int, message string) {
+
+	this.writeMessage(map[string]any{
+		"id": id,
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
+
+func (this *LspServer) writeNotification(method string, params any) {
+	this.writeMessage(map[string]any{
+		"method": method,
+		"params": params,
+	})
+}
+
+func (this *LspServer) handleMessage(message *lspMessage) {
+	isRequest := len(message.Id) > 0
+
+	if message.Method == "initialize" {
+		this.writeResult(message.Id, map[string]any{
+			"capabilities": map[string]any{
+				// full document sync
+				"textDocumentSync":   1,
+				"definitionProvider": true,
+				"hoverProvider":      true,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{".", "\""},
+				},
+			},
+			"serverInfo": map[string]any{
+				"name": "brexc",
+			},
+		})
+	} else if message.Method == "shutdown" {
+		this.isShutdown = true
+		this.writeResult(message.Id, nil)
+	} else if message.Method == "textDocument/didOpen" ||
+		message.Method == "textDocument/didChange" ||
+		message.Method == "textDocument/didSave" ||
+		message.Method == "textDocument/didClose" {
+		var params lspTextDocumentParams
+		if json.Unmarshal(message.Params, &params) != nil {
+			return
+		}
+		uri := params.TextDocument.Uri
+
+		if message.Method == "textDocument/didOpen" {
+			this.documents[uri] = params.TextDocument.Text
+		} else if message.Method == "textDocument/didChange" {
+			if len(params.ContentChanges) > 0 {
+				this.documents[uri] =
+					params.ContentChanges[len(params.ContentChanges)-1].Text
+			}
+		} else if message.Method == "textDocument/didClose" {
+			delete(this.documents, uri)
+			if parser, ok := this.parsers[uri]; ok {
+				parser.Close()
+				delete(this.parsers, uri)
+			}
+			this.publishDiagnostics(uri, []lspDiagnostic{})
+		}
+		this.validateDocuments()
+	} else if message.Method == "textDocument/definition" ||
+		message.Method == "textDocument/hover" ||
+		message.Method == "textDocument/completion" {
+		var params lspTextDocumentPositionParams
+		if json.Unmarshal(message.Params, &params) != nil {
+			this.writeError(message.Id, -32602, "invalid params")
+			return
+		}
+
+		if message.Method == "textDocument/definition" {
+			this.writeResult(message.Id, this.findDefinition(
+				params.TextDocument.Uri, params.Position))
+		} else if message.Method == "textDocument/hover" {
+			this.writeResult(message.Id, this.getHover(
+				params.TextDocument.Uri, params.Position))
+		} else {
+			this.writeResult(message.Id, this.getCompletion(
+				params.TextDocument.Uri, params.Position))
+		}
+	} else if isRequest {
+		this.writeError(message.Id, -32601,
+			fmt.Sprintf("method `%s` not found", message.Method))
+	}
+}
+
+// ----------------------------------------------------------------------------
+func (this *LspServer) validateDocuments() {
+	uris := make([]string, 0, len(this.documents))
+	for uri := range this.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		filePath := lspUriToPath(uri)
+		if filePath == "" {
+			continue
+		}
+
+		parser := NewProtocolParser()
+		parser.DiagnosticsFormat = ""
+		for documentUri, text := range this.documents {
+			if documentPath := lspUriToPath(documentUri); documentPath != "" {
+				parser.FileTexts[documentPath] = text
+			}
+		}
+		searchPath := append([]string{filepath.Dir(filePath)},
+			this.searchPath...)
+		parser.Parse(filePath, searchPath)
+
+		if oldParser, ok := this.parsers[uri]; ok {
+			oldParser.Close()
+		}
+		this.parsers[uri] = parser
+
+		// diagnostics of imported files are published by their own
+		// document when they are open
+		diagnostics := make([]lspDiagnostic, 0)
+		lines := parser.fileLines[filePath]
+		for _, item := range parser.Diagnostics.Items {
+			if item.FilePath != filePath {
+				continue
+			}
+
+			diagnostic := lspDiagnostic{
+				Range:   lspGetTagRange(lines, item.LineNumber, item.Column),
+				Code:    item.Code,
+				Source:  "brexc",
+				Message: item.Message,
+			}
+			if item.Severity == DiagnosticSeverity_Error {
+				diagnostic.Severity = 1
+			} else {
+				diagnostic.Severity = 2
+			}
+			diagnostics = append(diagnostics, diagnostic)
+		}
+		this.publishDiagnostics(uri, diagnostics)
+	}
+}
+
+func (this *LspServer) publishDiagnostics(
+	uri string, diagnostics []lspDiagnostic) {
+
+	this.writeNotification("textDocument/publishDiagnostics",
+		map[string]any{
+			"uri":         uri,
+			"diagnostics": diagnostics,
+		})
+}
+
+// ----------------------------------------------------------------------------
+// return parser and protocol of document, nil if not parsed
+func (this *LspServer) getDocumentProtoDef(
+	uri string) (*ProtocolParser, *ProtocolDef) {
+
+	parser, ok := this.parsers[uri]
+	if ok == false || parser.Descriptor == nil {
+		return nil, nil
+	}
+	filePath := lspUriToPath(uri)
+	protoDef, ok := parser.Descriptor.ImportedProtos[UtilGetFileNameWithoutExtension(filePath)]
+	if ok == false || protoDef.FilePath != filePath {
+		return nil, nil
+	}
+
+	return parser, protoDef
+}
+
+func (this *LspServer) getDocumentLine(uri string, line int) string {
+	lines := strings.Split(this.documents[uri], "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+
+	return strings.TrimRight(lines[line], "\r")
+}
+
+// find the attribute value or import file name under the cursor,
+// nil if cursor is not in one
+func (this *LspServer) getValueContext(
+	uri string, position lspPosition) *lspValueContext {
+
+	line := this.getDocumentLine(uri, position.Line)
+	cursor := lspUtf16ToByteOffset(line, position.Character)
+
+	for _, m := range g_lspImportRegexp.FindAllStringSubmatchIndex(line, -1) {
+		if cursor >= m[2] && cursor <= m[3] {
+			return &lspValueContext{
+				TagName: "import",
+				Value:   line[m[2]:m[3]],
+				Offset:  cursor - m[2],
+			}
+		}
+	}
+	for _, m := range g_lspAttrRegexp.FindAllStringSubmatchIndex(line, -1) {
+		if cursor >= m[4] && cursor <= m[5] {
+			return &lspValueContext{
+				TagName:  this.getTagNameBefore(uri, position.Line, m[0]),
+				AttrName: line[m[2]:m[3]],
+				Value:    line[m[4]:m[5]],
+				Offset:   cursor - m[4],
+			}
+		}
+	}
+
+	return nil
+}
+
+// same as getValueContext but only text before cursor is taken as value,
+// the value may be not closed yet when typing
+func (this *LspServer) getValuePrefixContext(
+	uri string, position lspPosition) *lspValueContext {
+
+	line := this.getDocumentLine(uri, position.Line)
+	cursor := lspUtf16ToByteOffset(line, position.Character)
+
+	m := g_lspAttrPrefixRegexp.FindStringSubmatchIndex(line[:cursor])
+	if m == nil {
+		return nil
+	}
+
+	return &lspValueContext{
+		TagName:  this.getTagNameBefore(uri, position.Line, m[0]),
+		AttrName: line[m[2]:m[3]],
+		Value:    line[m[4]:m[5]],
+		Offset:   m[5] - m[4],
+	}
+}
+
+// name of the tag an attribute at byteOffset of line belongs to,
+// attributes of a tag can be written in several lines
+func (this *LspServer) getTagNameBefore(
+	uri string, line int, byteOffset int) string {
+
+	text := this.getDocumentLine(uri, line)[:byteOffset]
+	for {
+		if index := strings.LastIndex(text, "<"); index >= 0 {
+			name := text[index+1:]
+			if end := strings.IndexAny(name, " \t/>"); end >= 0 {
+				name = name[:end]
+			}
+			return name
+		}
+		line--
+		if line < 0 {
+			return ""
+		}
+		text = this.getDocumentLine(uri, line)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// return the definition referenced at position, one of *ProtocolDef,
+// *EnumDef, *EnumItemDef, *StructDef, *StructFieldDef, *EnumMapDef and
+// *EnumMapItemDef, nil if not found. name attributes resolve to the
+// definition itself
+func (this *LspServer) findDef(
+	uri string, position lspPosition) (*ProtocolParser, any) {
+
+	parser, protoDef := this.getDocumentProtoDef(uri)
+	if protoDef == nil {
+		return nil, nil
+	}
+	context := this.getValueContext(uri, position)
+	if context == nil {
+		return nil, nil
+	}
+
+	var def any = nil
+	container := lspFindContainerDef(protoDef, position.Line+1)
+
+	if context.TagName == "import" {
+		name := UtilGetFileNameWithoutExtension(context.Value)
+		if importDef, ok := protoDef.ImportNameIndex[name]; ok {
+			def = importDef.ProtoDef
+		}
+	} else if context.AttrName == "type" {
+		def = lspResolveTypeName(protoDef,
+			context.Value, context.Offset, true)
+	} else if context.AttrName == "struct" {
+		def = lspResolveTypeName(protoDef,
+			context.Value, context.Offset, false)
+	} else if context.AttrName == "value" {
+		if enumDef, ok := container.(*EnumDef); ok {
+			def = lspResolveEnumItemName(protoDef, enumDef,
+				context.Value, context.Offset)
+		} else if enumMapDef, ok := container.(*EnumMapDef); ok {
+			if itemDef, ok := enumMapDef.ItemNameIndex[context.Value]; ok {
+				def = itemDef
+			}
+		}
+	} else if context.AttrName == "name" {
+		def = lspFindNamedDef(protoDef, container,
+			context.TagName, context.Value)
+	}
+
+	if def == nil {
+		return nil, nil
+	}
+
+	return parser, def
+}
+
+func (this *LspServer) findDefinition(
+	uri string, position lspPosition) *lspLocation {
+
+	parser, def := this.findDef(uri, position)
+	if def == nil {
+		return nil
+	}
+
+	var filePath string
+	var lineNumber int
+	var tagName string
+	if protoDef, ok := def.(*ProtocolDef); ok {
+		filePath = protoDef.FilePath
+	} else if enumDef, ok := def.(*EnumDef); ok {
+		filePath = enumDef.ParentRef.FilePath
+		lineNumber = enumDef.LineNumber
+		tagName = "enum"
+	} else if itemDef, ok := def.(*EnumItemDef); ok {
+		filePath = itemDef.ParentRef.ParentRef.FilePath
+		lineNumber = itemDef.LineNumber
+		tagName = "item"
+	} else if structDef, ok := def.(*StructDef); ok {
+		filePath = structDef.ParentRef.FilePath
+		lineNumber = structDef.LineNumber
+		tagName = "struct"
+	} else if fieldDef, ok := def.(*StructFieldDef); ok {
+		filePath = fieldDef.ParentRef.ParentRef.FilePath
+		lineNumber = fieldDef.LineNumber
+		if fieldDef.IsOptional {
+			tagName = "optional"
+		} else {
+			tagName = "required"
+		}
+	} else if enumMapDef, ok := def.(*EnumMapDef); ok {
+		filePath = enumMapDef.ParentRef.FilePath
+		lineNumber = enumMapDef.LineNumber
+		tagName = "enum_map"
+	} else if itemDef, ok := def.(*EnumMapItemDef); ok {
+		filePath = itemDef.ParentRef.ParentRef.FilePath
+		lineNumber = itemDef.LineNumber
+		tagName = "item"
+	}
+
+	location := new(lspLocation)
+	location.Uri = lspPathToUri(filePath)
+	if tagName != "" {
+		lineNumber, column := parser.getTagPosition(
+			filePath, lineNumber, tagName)
+		location.Range = lspGetTagRange(
+			parser.fileLines[filePath], lineNumber, column)
+	}
+
+	return location
+}
+
+func (this *LspServer) getHover(
+	uri string, position lspPosition) map[string]any {
+
+	_, def := this.findDef(uri, position)
+	if def == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	if protoDef, ok := def.(*ProtocolDef); ok {
+		fmt.Fprintf(&sb, "protocol %s\n", protoDef.Name)
+	} else if enumDef, ok := def.(*EnumDef); ok {
+		fmt.Fprintf(&sb, "enum %s.%s {\n",
+			enumDef.ParentRef.Name, enumDef.Name)
+		for _, itemDef := range enumDef.Items {
+			fmt.Fprintf(&sb, "    %s = %d\n",
+				itemDef.Name, itemDef.IntValue)
+		}
+		sb.WriteString("}\n")
+	} else if itemDef, ok := def.(*EnumItemDef); ok {
+		fmt.Fprintf(&sb, "%s.%s.%s = %d\n",
+			itemDef.ParentRef.ParentRef.Name, itemDef.ParentRef.Name,
+			itemDef.Name, itemDef.IntValue)
+	} else if structDef, ok := def.(*StructDef); ok {
+		fmt.Fprintf(&sb, "struct %s {\n",
+			StructDefGetQualifiedName(structDef))
+		for _, fieldDef := range structDef.Fields {
+			sb.WriteString("    " + lspGetFieldText(fieldDef) + "\n")
+		}
+		sb.WriteString("}\n")
+	} else if fieldDef, ok := def.(*StructFieldDef); ok {
+		fmt.Fprintf(&sb, "%s\n", lspGetFieldText(fieldDef))
+	} else if enumMapDef, ok := def.(*EnumMapDef); ok {
+		fmt.Fprintf(&sb, "enum_map %s.%s {\n",
+			enumMapDef.ParentRef.Name, enumMapDef.Name)
+		for _, itemDef := range enumMapDef.Items {
+			sb.WriteString("    " + lspGetEnumMapItemText(itemDef) + "\n")
+		}
+		sb.WriteString("}\n")
+	} else if itemDef, ok := def.(*EnumMapItemDef); ok {
+		fmt.Fprintf(&sb, "%s.%s.%s\n",
+			itemDef.ParentRef.ParentRef.Name, itemDef.ParentRef.Name,
+			lspGetEnumMapItemText(itemDef))
+	}
+
+	text := "```\n" + sb.String() + "```"
+	if protoDef, ok := def.(*ProtocolDef); ok {
+		text += "\n" + protoDef.FilePath
+	}
+
+	return map[string]any{
+		"contents": map[string]any{
+			"kind":  "markdown",
+			"value": text,
+		},
+	}
+}
+
+func (this *LspServer) getCompletion(
+	uri string, position lspPosition) []lspCompletionItem {
+
+	items := make([]lspCompletionItem, 0)
+
+	_, protoDef := this.getDocumentProtoDef(uri)
+	if protoDef == nil {
+		return items
+	}
+	context := this.getValuePrefixContext(uri, position)
+	if context == nil {
+		return items
+	}
+
+	// complete the last part of a dotted name
+	value := context.Value
+	if strings.HasPrefix(value, "list{") {
+		value = value[len("list{"):]
+	}
+	parts := strings.Split(value, ".")
+	qualifier := parts[:len(parts)-1]
+
+	addProtoDefs := func(refProtoDef *ProtocolDef,
+		withEnums bool, withStructs bool) {
+
+		if withEnums {
+			for _, def := range refProtoDef.Enums {
+				items = append(items, lspCompletionItem{
+					Label:  def.Name,
+					Kind:   lspCompletionItemKind_Enum,
+					Detail: "enum " + refProtoDef.Name + "." + def.Name,
+				})
+			}
+		}
+		if withStructs {
+			for _, def := range refProtoDef.Structs {
+				items = append(items, lspCompletionItem{
+					Label:  def.Name,
+					Kind:   lspCompletionItemKind_Struct,
+					Detail: "struct " + StructDefGetQualifiedName(def),
+				})
+			}
+		}
+	}
+	addImports := func() {
+		for _, importDef := range protoDef.Imports {
+			items = append(items, lspCompletionItem{
+				Label:  importDef.Name,
+				Kind:   lspCompletionItemKind_Module,
+				Detail: "protocol " + importDef.Name,
+			})
+		}
+	}
+	addEnumItems := func(enumDef *EnumDef) {
+		for _, def := range enumDef.Items {
+			items = append(items, lspCompletionItem{
+				Label:  def.Name,
+				Kind:   lspCompletionItemKind_EnumMember,
+				Detail: fmt.Sprintf("%s = %d", def.Name, def.IntValue),
+			})
+		}
+	}
+
+	container := lspFindContainerDef(protoDef, position.Line+1)
+
+	if context.AttrName == "type" || context.AttrName == "struct" {
+		withEnums := context.AttrName == "type"
+		if len(qualifier) == 0 {
+			if withEnums {
+				for t := StructFieldType_I8; t <= StructFieldType_Bool; t++ {
+					items = append(items, lspCompletionItem{
+						Label: StructFieldTypeGetName(t),
+						Kind:  lspCompletionItemKind_Keyword,
+					})
+				}
+			}
+			addProtoDefs(protoDef, withEnums, true)
+			addImports()
+		} else if len(qualifier) == 1 {
+			if importDef, ok := protoDef.ImportNameIndex[qualifier[0]]; ok {
+				addProtoDefs(importDef.ProtoDef, withEnums, true)
+			}
+		}
+	} else if context.AttrName == "value" {
+		if enumDef, ok := container.(*EnumDef); ok {
+			if len(qualifier) == 0 {
+				addEnumItems(enumDef)
+				addProtoDefs(protoDef, true, false)
+				addImports()
+			} else if len(qualifier) == 1 {
+				if refEnumDef, ok := protoDef.EnumNameIndex[qualifier[0]]; ok {
+					addEnumItems(refEnumDef)
+				} else if importDef, ok := protoDef.ImportNameIndex[qualifier[0]]; ok {
+					addProtoDefs(importDef.ProtoDef, true, false)
+				}
+			} else if len(qualifier) == 2 {
+				if importDef, ok := protoDef.ImportNameIndex[qualifier[0]]; ok {
+					if refEnumDef, ok := importDef.ProtoDef.EnumNameIndex[qualifier[1]]; ok {
+						addEnumItems(refEnumDef)
+					}
+				}
+			}
+		} else if enumMapDef, ok := container.(*EnumMapDef); ok {
+			if len(qualifier) == 0 {
+				for _, def := range enumMapDef.Items {
+					items = append(items, lspCompletionItem{
+						Label:  def.Name,
+						Kind:   lspCompletionItemKind_EnumMember,
+						Detail: lspGetEnumMapItemText(def),
+					})
+				}
+			}
+		}
+	}
+
+	return items
+}
+
+// ----------------------------------------------------------------------------
+// enum, struct or enum_map whose body contains lineNumber, nil if none
+func lspFindContainerDef(protoDef *ProtocolDef, lineNumber int) any {
+	var container any = nil
+	containerLineNumber := 0
+
+	for _, def := range protoDef.Enums {
+		if def.LineNumber <= lineNumber &&
+			def.LineNumber > containerLineNumber {
+			container = def
+			containerLineNumber = def.LineNumber
+		}
+	}
+	for _, def := range protoDef.Structs {
+		if def.LineNumber <= lineNumber &&
+			def.LineNumber > containerLineNumber {
+			container = def
+			containerLineNumber = def.LineNumber
+		}
+	}
+	for _, def := range protoDef.EnumMaps {
+		if def.LineNumber <= lineNumber &&
+			def.LineNumber > containerLineNumber {
+			container = def
+			containerLineNumber = def.LineNumber
+		}
+	}
+
+	return container
+}
+
+// typeName is `Name`, `proto.Name` or `list{...}` of them,
+// offset selects the part to resolve
+func lspResolveTypeName(protoDef *ProtocolDef,
+	typeName string, offset int, withEnums bool) any {
+
+	if m := g_fetchListTypeRegexp.FindStringSubmatchIndex(typeName); m != nil {
+		typeName = typeName[m[2]:m[3]]
+		offset -= m[2]
+	}
+	offset = max(0, min(offset, len(typeName)))
+	parts := strings.Split(typeName, ".")
+	partIndex := strings.Count(typeName[:offset], ".")
+
+	refProtoDef := protoDef
+	refDefName := parts[0]
+	if len(parts) == 2 {
+		importDef, ok := protoDef.ImportNameIndex[parts[0]]
+		if ok == false {
+			return nil
+		}
+		if partIndex == 0 {
+			return importDef.ProtoDef
+		}
+		refProtoDef = importDef.ProtoDef
+		refDefName = parts[1]
+	} else if len(parts) != 1 {
+		return nil
+	}
+
+	if withEnums {
+		if def, ok := refProtoDef.EnumNameIndex[refDefName]; ok {
+			return def
+		}
+	}
+	if def, ok := refProtoDef.StructNameIndex[refDefName]; ok {
+		return def
+	}
+
+	return nil
+}
+
+// itemName is `ITEM`, `Enum.ITEM` or `proto.Enum.ITEM`,
+// offset selects the part to resolve
+func lspResolveEnumItemName(protoDef *ProtocolDef, enumDef *EnumDef,
+	itemName string, offset int) any {
+
+	offset = max(0, min(offset, len(itemName)))
+	parts := strings.Split(itemName, ".")
+	partIndex := strings.Count(itemName[:offset], ".")
+
+	refEnumDef := enumDef
+	if len(parts) == 2 {
+		def, ok := protoDef.EnumNameIndex[parts[0]]
+		if ok == false {
+			return nil
+		}
+		if partIndex == 0 {
+			return def
+		}
+		refEnumDef = def
+	} else if len(parts) == 3 {
+		importDef, ok := protoDef.ImportNameIndex[parts[0]]
+		if ok == false {
+			return nil
+		}
+		if partIndex == 0 {
+			return importDef.ProtoDef
+		}
+		def, ok := importDef.ProtoDef.EnumNameIndex[parts[1]]
+		if ok == false {
+			return nil
+		}
+		if partIndex == 1 {
+			return def
+		}
+		refEnumDef = def
+	} else if len(parts) != 1 {
+		return nil
+	}
+
+	if def, ok := refEnumDef.ItemNameIndex[parts[len(parts)-1]]; ok {
+		return def
+	}
+
+	return nil
+}
+
+// definition declared by a name attribute
+func lspFindNamedDef(protoDef *ProtocolDef, container any,
+	tagName string, name string) any {
+
+	if tagName == "enum" {
+		if def, ok := protoDef.EnumNameIndex[name]; ok {
+			return def
+		}
+	} else if tagName == "struct" {
+		if def, ok := protoDef.StructNameIndex[name]; ok {
+			return def
+		}
+	} else if tagName == "enum_map" {
+		if def, ok := protoDef.EnumMapNameIndex[name]; ok {
+			return def
+		}
+	} else if tagName == "item" {
+		if enumDef, ok := container.(*EnumDef); ok {
+			if def, ok := enumDef.ItemNameIndex[name]; ok {
+				return def
+			}
+		} else if enumMapDef, ok := container.(*EnumMapDef); ok {
+			if def, ok := enumMapDef.ItemNameIndex[name]; ok {
+				return def
+			}
+		}
+	} else if tagName == "required" || tagName == "optional" {
+		if structDef, ok := container.(*StructDef); ok {
+			if def, ok := structDef.FieldNameIndex[name]; ok {
+				return def
+			}
+		}
+	}
+
+	return nil
+}
+
+func lspGetFieldText(fieldDef *StructFieldDef) string {
+	if fieldDef.IsOptional {
+		return "optional " + fieldDef.Name + ": " +
+			StructFieldDefGetTypeName(fieldDef)
+	} else {
+		return "required " + fieldDef.Name + ": " +
+			StructFieldDefGetTypeName(fieldDef)
+	}
+}
+
+func lspGetEnumMapItemText(itemDef *EnumMapItemDef) string {
+	if itemDef.RefStructDef == nil {
+		return fmt.Sprintf("%s = %d", itemDef.Name, itemDef.IntValue)
+	} else {
+		return fmt.Sprintf("%s = %d -> %s", itemDef.Name, itemDef.IntValue,
+			StructDefGetQualifiedName(itemDef.RefStructDef))
+	}
+}
+
+// range from column to the end of the tag in the line,
+// lineNumber and column are 1-based, 0 if unknown
+func lspGetTagRange(lines []string, lineNumber int, column int) lspRange {
+	if lineNumber <= 0 || lineNumber > len(lines) {
+		return lspRange{}
+	}
+	line := strings.TrimRight(lines[lineNumber-1], "\r")
+
+	start := 0
+	if column > 0 && column <= len(line) {
+		start = column - 1
+	}
+	end := len(line)
+	if index := strings.IndexByte(line[start:], '>'); index >= 0 {
+		end = start + index + 1
+	}
+
+	return lspRange{
+		Start: lspPosition{
+			Line:      lineNumber - 1,
+			Character: lspByteOffsetToUtf16(line, start),
+		},
+		End: lspPosition{
+			Line:      lineNumber - 1,
+			Character: lspByteOffsetToUtf16(line, end),
+		},
+	}
+}
+
+// lsp positions count utf-16 code units
+func lspByteOffsetToUtf16(line string, byteOffset int) int {
+	count := 0
+	for index, r := range line {
+		if index >= byteOffset {
+			break
+		}
+		if r >= 0x10000 {
+			count += 2
+		} else {
+			count++
+		}
+	}
+
+	return count
+}
+
+func lspUtf16ToByteOffset(line string, character int) int {
+	count := 0
+	for index, r := range line {
+		if count >= character {
+			return index
+		}
+		if r >= 0x10000 {
+			count += 2
+		} else {
+			count++
+		}
+	}
+
+	return len(line)
+}
+
+// return empty string if not a file uri
+func lspUriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func lspPathToUri(filePath string) string {
+	u := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(filePath),
+	}
+
+	return u.String()
+}
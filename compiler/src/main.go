@@ -15,29 +15,75 @@ func printUsage() {
 		"-f <protocol_file> "+
 		"-l <language>"+
 		"\n"+
+		"    [-f <protocol_file>] more protocol files, directories or globs\n"+
+		"    [--with-imports] also generate for every imported protocol\n"+
 		"    [-o <output_dir>]\n"+
+		"    [--cpp_out <output_dir>] output_dir of cpp, default is -o\n"+
+		"    [--csharp_out <output_dir>] output_dir of csharp, default is -o\n"+
+		"    [--php_out <output_dir>] output_dir of php, default is -o\n"+
+		"    [--namespace_dir] place output files in directories "+
+		"mirroring namespace\n"+
 		"    [-I <search_path>]\n"+
 		"    [-n <new_line_type>] (unix|dos) default is unix\n"+
-		"language supported: cpp php csharp\n",
-		filepath.Base(os.Args[0]))
+		"    [-M <depfile>] write make dependency file of generated files\n"+
+		"    [--diagnostics-format <format>] (text|gcc|json) "+
+		"default is text\n"+
+		"language supported: cpp php csharp proto3 jsonschema, "+
+		"html markdown for documents, other languages run generator "+
+		"plugin brexc-gen-<language> in PATH\n"+
+		"language can be a comma separated list, e.g. cpp,csharp,php\n"+
+		"\n"+
+		"commands:\n"+
+		"    build          generate code by project file brexc.json\n"+
+		"    compat         check breaking changes between protocol versions\n"+
+		"    decode         decode binary by protocol schema\n"+
+		"    encode         encode json to binary by protocol schema\n"+
+		"    fingerprint    print schema fingerprints of protocol\n"+
+		"    fmt            rewrite protocol files into canonical layout\n"+
+		"    graph          print dependency graph of protocol\n"+
+		"    import-proto   convert protobuf schema files to protocol files\n"+
+		"    lint           check protocol files against lint rules\n"+
+		"    lsp            language server of protocol files over stdio\n"+
+		"    watch          regenerate code when protocol files change\n"+
+		"run `%s <command> -h` for command usage\n",
+		filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
 }
 
 func run() int {
+	// dispatch sub command
+	if len(os.Args) > 1 {
+		if os.Args[1] == "build" {
+			return runBuildCommand(os.Args[2:])
+		} else if os.Args[1] == "compat" {
+			return runCompatCommand(os.Args[2:])
+		} else if os.Args[1] == "decode" {
+			return runDecodeCommand(os.Args[2:])
+		} else if os.Args[1] == "encode" {
+			return runEncodeCommand(os.Args[2:])
+		} else if os.Args[1] == "fingerprint" {
+			return runFingerprintCommand(os.Args[2:])
+		} else if os.Args[1] == "fmt" {
+			return runFmtCommand(os.Args[2:])
+		} else if os.Args[1] == "graph" {
+			return runGraphCommand(os.Args[2:])
+		} else if os.Args[1] == "import-proto" {
+			return runImportProtoCommand(os.Args[2:])
+		} else if os.Args[1] == "lint" {
+			return runLintCommand(os.Args[2:])
+		} else if os.Args[1] == "lsp" {
+			return runLspCommand(os.Args[2:])
+		} else if os.Args[1] == "watch" {
+			return runWatchCommand(os.Args[2:])
+		}
+	}
+
 	// parse command line options
 	var optHelp bool
-	var optProtoFilePath string
-	var optLanguage string
-	var optOutputDir string
-	var optSearchPath []string
-	var optNewLineType string
+	options := NewCompileOptions()
 
 	flagSet := flag.NewFlagSet("main", flag.ContinueOnError)
 	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
-	flagSet.StringVarP(&optProtoFilePath, "-proto_file_path", "f", "", "")
-	flagSet.StringVarP(&optLanguage, "-language", "l", "", "")
-	flagSet.StringVarP(&optOutputDir, "-output_dir", "o", "", "")
-	flagSet.StringSliceVarP(&optSearchPath, "-search_path", "I", []string{}, "")
-	flagSet.StringVarP(&optNewLineType, "-new_line_type", "n", "", "")
+	options.AddFlags(flagSet)
 
 	if flagSet.Parse(os.Args[1:]) != nil {
 		printUsage()
@@ -50,86 +96,13 @@ func run() int {
 
 	// check command line options
 	// -- required options
-	if optProtoFilePath == "" ||
-		optLanguage == "" {
+	if len(options.ProtoFilePaths) == 0 ||
+		options.Language == "" {
 		printUsage()
 		return 1
 	}
 
-	// -- option default value
-	if optOutputDir == "" {
-		optOutputDir = "."
-	}
-	if optNewLineType == "" {
-		optNewLineType = "unix"
-	}
-
-	// -- check option proto_file_path
-	if UtilCheckFileExists(optProtoFilePath) == false {
-		fmt.Fprintf(os.Stderr,
-			"error: can not find protocol file `%s`\n",
-			optProtoFilePath)
-		return 1
-	}
-
-	// -- check option language
-	if optLanguage != "cpp" &&
-		optLanguage != "php" &&
-		optLanguage != "csharp" {
-		fmt.Fprintf(os.Stderr,
-			"error: language `%s` is not supported\n",
-			optLanguage)
-		return 1
-	}
-
-	// -- check option output_dir
-	if UtilCheckDirExists(optOutputDir) == false {
-		fmt.Fprintf(os.Stderr,
-			"error: can not find output directory `%s`\n",
-			optOutputDir)
-		return 1
-	}
-
-	// -- check option new_line_type
-	if optNewLineType != "dos" &&
-		optNewLineType != "unix" {
-		fmt.Fprintf(os.Stderr,
-			"error: new_line_type `%s` is invalid\n",
-			optNewLineType)
-		return 1
-	}
-
-	// create parser
-	parser := NewProtocolParser()
-	if parser.Parse(optProtoFilePath, optSearchPath) == false {
-		return 1
-	}
-	defer parser.Close()
-
-	// create generator
-	var generator CodeGenerator = nil
-	if optLanguage == "cpp" {
-		generator = NewCppCodeGenerator()
-	} else if optLanguage == "php" {
-		generator = NewPhpCodeGenerator()
-	} else if optLanguage == "csharp" {
-		generator = NewCSharpCodeGenerator()
-	} else {
-		return 1
-	}
-	defer generator.Close()
-
-	// generate code
-	newLineType := NewLineType_Unix
-	if optNewLineType == "dos" {
-		newLineType = NewLineType_Dos
-	}
-	if generator.Generate(parser.Descriptor,
-		optOutputDir, newLineType) == false {
-		return 1
-	}
-
-	return 0
+	return runCompile(options)
 }
 
 func main() {
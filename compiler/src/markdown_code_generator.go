@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type MarkdownCodeGenerator struct {
+	BaseCodeGenerator
+
+	// names of protocols having a page
+	pages map[string]bool
+}
+
+// protoDefs are all protocols generated, pages only link to them
+func NewMarkdownCodeGenerator(protoDefs []*ProtocolDef) *MarkdownCodeGenerator {
+	newObj := new(MarkdownCodeGenerator)
+	newObj.pages = docGetPages(protoDefs)
+
+	return newObj
+}
+
+func (this *MarkdownCodeGenerator) Close() {
+	this.close()
+}
+
+// pages link each other by relative path, so namespace directories
+// are not used
+func (this *MarkdownCodeGenerator) Generate(
+	descriptor *ProtocolDescriptor,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
+
+	this.init(descriptor, newLineType, false)
+
+	docFilePath := this.getOutputFilePath(outputDir, "markdown",
+		this.descriptor.ProtoDef.Name+".md")
+	docFileContent := this.generateDocFile()
+	if this.writeOutputFile(docFilePath, docFileContent) == false {
+		return false
+	}
+
+	return true
+}
+
+func (this *MarkdownCodeGenerator) generateDocFile() string {
+	var sb strings.Builder
+
+	this.writeDontEditComment(&sb)
+	this.writeTitle(&sb)
+	this.writeNamespaces(&sb)
+	this.writeImports(&sb)
+	this.writeEnums(&sb)
+	this.writeStructs(&sb)
+	this.writeEnumMaps(&sb)
+
+	return sb.String()
+}
+
+func (this *MarkdownCodeGenerator) writeDontEditComment(
+	sb *strings.Builder) {
+
+	this.writeLine(sb,
+		"<!-- Generated by brickred exchange compiler. Do not edit. -->")
+	this.writeEmptyLine(sb)
+}
+
+func (this *MarkdownCodeGenerator) getLink(text string, href string) string {
+	if href == "" {
+		return text
+	} else {
+		return "[" + text + "](" + href + ")"
+	}
+}
+
+func (this *MarkdownCodeGenerator) getAnchorTag(anchor string) string {
+	return "<a id=\"" + anchor + "\"></a>"
+}
+
+func (this *MarkdownCodeGenerator) writeTitle(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+
+	this.writeLineFormat(sb, "# Protocol %s", protoDef.Name)
+	this.writeEmptyLine(sb)
+
+	// navigation to all protocols
+	links := make([]string, 0)
+	for _, def := range docGetSortedProtoDefs(this.descriptor, this.pages) {
+		if def == protoDef {
+			links = append(links, "**"+def.Name+"**")
+		} else {
+			links = append(links, this.getLink(def.Name, def.Name+".md"))
+		}
+	}
+	this.writeLineFormat(sb, "Protocols: %s", strings.Join(links, " | "))
+	this.writeEmptyLine(sb)
+
+	this.writeLineFormat(sb, "Fingerprint: `%s`",
+		FingerprintProtocol(protoDef))
+	this.writeEmptyLine(sb)
+}
+
+func (this *MarkdownCodeGenerator) writeNamespaces(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Namespaces) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "## Namespaces")
+	this.writeEmptyLine(sb)
+	this.writeLine(sb, "| Language | Namespace |")
+	this.writeLine(sb, "| --- | --- |")
+	for _, language := range docGetNamespaceLanguages(protoDef) {
+		this.writeLineFormat(sb, "| %s | `%s` |",
+			language, protoDef.Namespaces[language].Namespace)
+	}
+	this.writeEmptyLine(sb)
+}
+
+func (this *MarkdownCodeGenerator) writeImports(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Imports) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "## Imports")
+	this.writeEmptyLine(sb)
+	for _, def := range protoDef.Imports {
+		this.writeLineFormat(sb, "- %s",
+			this.getLink(def.Name, docGetPageHref(def.Name, ".md", this.pages)))
+	}
+	this.writeEmptyLine(sb)
+}
+
+func (this *MarkdownCodeGenerator) writeEnums(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Enums) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "## Enums")
+	this.writeEmptyLine(sb)
+	for _, enumDef := range protoDef.Enums {
+		this.writeLineFormat(sb, "### %s%s",
+			this.getAnchorTag(docGetAnchor("enum", enumDef.Name)),
+			enumDef.Name)
+		this.writeEmptyLine(sb)
+		this.writeLine(sb, "| Name | Value |")
+		this.writeLine(sb, "| --- | --- |")
+		for _, def := range enumDef.Items {
+			this.writeLineFormat(sb, "| %s | %s |",
+				def.Name, this.getEnumItemValue(def))
+		}
+		this.writeEmptyLine(sb)
+	}
+}
+
+// value with the item it refers to, e.g. 6 (= attr.AttrType.MAX)
+func (this *MarkdownCodeGenerator) getEnumItemValue(
+	enumItemDef *EnumItemDef) string {
+
+	refDef := enumItemDef.RefEnumItemDef
+	if refDef == nil {
+		return fmt.Sprintf("%d", enumItemDef.IntValue)
+	}
+
+	protoDef := this.descriptor.ProtoDef
+	var refName string
+	if refDef.ParentRef == enumItemDef.ParentRef {
+		refName = refDef.Name
+	} else {
+		refName = docGetRefName(protoDef, refDef.ParentRef.ParentRef,
+			refDef.ParentRef.Name) + "." + refDef.Name
+	}
+	href := docGetHref(protoDef, refDef.ParentRef.ParentRef,
+		docGetAnchor("enum", refDef.ParentRef.Name), ".md",
+		this.pages)
+
+	return fmt.Sprintf("%d (= %s)",
+		enumItemDef.IntValue, this.getLink(refName, href))
+}
+
+func (this *MarkdownCodeGenerator) writeStructs(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Structs) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "## Structs")
+	this.writeEmptyLine(sb)
+	for _, structDef := range protoDef.Structs {
+		this.writeLineFormat(sb, "### %s%s",
+			this.getAnchorTag(docGetAnchor("struct", structDef.Name)),
+			structDef.Name)
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, "Fingerprint: `%s`",
+			FingerprintStruct(structDef))
+		this.writeEmptyLine(sb)
+		if len(structDef.Fields) == 0 {
+			this.writeLine(sb, "No fields.")
+			this.writeEmptyLine(sb)
+			continue
+		}
+		this.writeLine(sb, "| Name | Type | Optional |")
+		this.writeLine(sb, "| --- | --- | --- |")
+		for _, def := range structDef.Fields {
+			optional := ""
+			if def.IsOptional {
+				optional = "optional"
+			}
+			this.writeLineFormat(sb, "| %s | %s | %s |",
+				def.Name, this.getFieldType(def), optional)
+		}
+		this.writeEmptyLine(sb)
+	}
+}
+
+// e.g. list{[attr.Attr](attr.md#struct-Attr)}
+func (this *MarkdownCodeGenerator) getFieldType(
+	fieldDef *StructFieldDef) string {
+
+	name, href := docGetFieldItemType(
+		this.descriptor.ProtoDef, fieldDef, ".md", this.pages)
+	if fieldDef.Type == StructFieldType_List {
+		return "list{" + this.getLink(name, href) + "}"
+	} else {
+		return this.getLink(name, href)
+	}
+}
+
+func (this *MarkdownCodeGenerator) writeEnumMaps(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.EnumMaps) == 0 {
+		return
+	}
+
+	this.writeLine(sb, "## Enum Maps")
+	this.writeEmptyLine(sb)
+	for _, enumMapDef := range protoDef.EnumMaps {
+		this.writeLineFormat(sb, "### %s%s",
+			this.getAnchorTag(docGetAnchor("enum_map", enumMapDef.Name)),
+			enumMapDef.Name)
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, "Fingerprint: `%s`",
+			FingerprintEnumMap(enumMapDef))
+		this.writeEmptyLine(sb)
+		this.writeLine(sb, "| Name | Id | Struct |")
+		this.writeLine(sb, "| --- | --- | --- |")
+		for _, def := range enumMapDef.Items {
+			structLink := ""
+			if def.RefStructDef != nil {
+				refDef := def.RefStructDef
+				structLink = this.getLink(
+					docGetRefName(protoDef, refDef.ParentRef, refDef.Name),
+					docGetHref(protoDef, refDef.ParentRef,
+						docGetAnchor("struct", refDef.Name), ".md",
+						this.pages))
+			}
+			this.writeLineFormat(sb, "| %s | %d | %s |",
+				def.Name, def.IntValue, structLink)
+		}
+		this.writeEmptyLine(sb)
+	}
+}
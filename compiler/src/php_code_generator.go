@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"path/filepath"
 	"strings"
 )
 
@@ -22,20 +21,36 @@ func (this *PhpCodeGenerator) Close() {
 
 func (this *PhpCodeGenerator) Generate(
 	descriptor *ProtocolDescriptor,
-	outputDir string, newLineType NewLineType) bool {
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
 
-	this.init(descriptor, newLineType)
+	this.init(descriptor, newLineType, useNamespaceDir)
 
-	sourceFilePath := filepath.Join(
-		outputDir, this.descriptor.ProtoDef.Name+".php")
+	sourceFilePath := this.getOutputFilePath(outputDir, "php",
+		this.descriptor.ProtoDef.Name+".php")
 	sourceFileContent := this.generateSourceFile()
-	if UtilWriteAllText(sourceFilePath, sourceFileContent) == false {
+	if this.writeOutputFile(sourceFilePath, sourceFileContent) == false {
 		return false
 	}
 
 	return true
 }
 
+func (this *PhpCodeGenerator) getEnumFullQualifiedName(
+	enumDef *EnumDef) string {
+
+	protoDef := enumDef.ParentRef
+	namespaceDef, ok := protoDef.Namespaces["php"]
+	if ok {
+		return fmt.Sprintf(
+			"\\%s\\%s",
+			strings.Join(namespaceDef.NamespaceParts, "\\"),
+			enumDef.Name)
+	} else {
+		return enumDef.Name
+	}
+}
+
 func (this *PhpCodeGenerator) getEnumItemFullQualifiedName(
 	enumItemDef *EnumItemDef) string {
 
@@ -125,6 +140,7 @@ func (this *PhpCodeGenerator) generateSourceFile() string {
 	this.writeEnumDecl(&sb)
 	this.writeStructDecl(&sb)
 	this.writeEnumMapDecl(&sb)
+	this.writeProtocolDecl(&sb)
 	this.writePhpTagEnd(&sb)
 
 	return sb.String()
@@ -183,13 +199,24 @@ func (this *PhpCodeGenerator) writeUseStatementsDecl(
 
 	protoDef := this.descriptor.ProtoDef
 
+	useBrickredExchangeBaseStruct := false
 	useBrickredExchangeCodec := false
+	useBrickredExchangeEnumDescriptor := false
+	useBrickredExchangeFieldDescriptor := false
 	useBrickredExchangeInt64 := false
 	useBrickredExchangeUInt64 := false
 
+	if len(protoDef.Enums) > 0 {
+		useBrickredExchangeEnumDescriptor = true
+	}
+	if len(protoDef.Structs) > 0 {
+		useBrickredExchangeBaseStruct = true
+	}
+
 	for _, structDef := range protoDef.Structs {
 		if len(structDef.Fields) > 0 {
 			useBrickredExchangeCodec = true
+			useBrickredExchangeFieldDescriptor = true
 		}
 		for _, def := range structDef.Fields {
 			var checkType StructFieldType
@@ -208,21 +235,43 @@ func (this *PhpCodeGenerator) writeUseStatementsDecl(
 		}
 	}
 
-	if useBrickredExchangeCodec == false &&
+	if useBrickredExchangeBaseStruct == false &&
+		useBrickredExchangeCodec == false &&
+		useBrickredExchangeEnumDescriptor == false &&
 		useBrickredExchangeInt64 == false &&
 		useBrickredExchangeUInt64 == false {
 		return
 	}
 
 	this.writeEmptyLine(sb)
+	if useBrickredExchangeBaseStruct {
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\BaseStruct;")
+	}
 	if useBrickredExchangeCodec {
 		this.writeLine(sb,
 			"use \\Brickred\\Exchange\\Codec;")
 	}
+	if useBrickredExchangeEnumDescriptor {
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\EnumDescriptor;")
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\EnumItemDescriptor;")
+	}
+	if useBrickredExchangeFieldDescriptor {
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\FieldDescriptor;")
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\FieldType;")
+	}
 	if useBrickredExchangeInt64 {
 		this.writeLine(sb,
 			"use \\Brickred\\Exchange\\Int64;")
 	}
+	if useBrickredExchangeBaseStruct {
+		this.writeLine(sb,
+			"use \\Brickred\\Exchange\\StructDescriptor;")
+	}
 	if useBrickredExchangeUInt64 {
 		this.writeLine(sb,
 			"use \\Brickred\\Exchange\\UInt64;")
@@ -267,10 +316,49 @@ func (this *PhpCodeGenerator) writeOneEnumDecl(
 		}
 	}
 
+	this.writeOneEnumDeclDescriptorFunc(sb, enumDef)
+
 	this.writeLine(sb,
 		"}")
 }
 
+func (this *PhpCodeGenerator) writeOneEnumDeclDescriptorFunc(
+	sb *strings.Builder, enumDef *EnumDef) {
+
+	if len(enumDef.Items) > 0 {
+		this.writeEmptyLine(sb)
+	}
+	this.writeLine(sb,
+		"    private static $s_descriptor_ = null;")
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    public static function descriptor()")
+	this.writeLine(sb,
+		"    {")
+	this.writeLine(sb,
+		"        if (self::$s_descriptor_ === null) {")
+	this.writeLine(sb,
+		"            self::$s_descriptor_ = new EnumDescriptor(")
+	this.writeLineFormat(sb,
+		"                '%s', '%s.%s', [",
+		enumDef.Name, enumDef.ParentRef.Name, enumDef.Name)
+	for _, def := range enumDef.Items {
+		this.writeLineFormat(sb,
+			"                    new EnumItemDescriptor('%s', %d),",
+			def.Name, def.IntValue)
+	}
+	this.writeLine(sb,
+		"                ]);")
+	this.writeLine(sb,
+		"        }")
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"        return self::$s_descriptor_;")
+	this.writeLine(sb,
+		"    }")
+}
+
 func (this *PhpCodeGenerator) writeStructDecl(
 	sb *strings.Builder) {
 
@@ -286,10 +374,11 @@ func (this *PhpCodeGenerator) writeOneStructDecl(
 
 	this.writeEmptyLine(sb)
 	this.writeLineFormat(sb,
-		"final class %s",
+		"final class %s extends BaseStruct",
 		structDef.Name)
 	this.writeLine(sb,
 		"{")
+	this.writeOneStructDeclFingerprintDecl(sb, structDef)
 	this.writeOneStructDeclFieldDecl(sb, structDef)
 	this.writeOneStructDeclConstructor(sb, structDef)
 	this.writeOneStructDeclEncodeFunc(sb, structDef)
@@ -297,11 +386,23 @@ func (this *PhpCodeGenerator) writeOneStructDecl(
 	this.writeOneStructDeclToArrayFunc(sb, structDef)
 	this.writeOneStructDeclFromArrayFunc(sb, structDef)
 	this.writeOneStructDeclJsonFunc(sb)
+	this.writeOneStructDeclDumpFunc(sb, structDef)
+	this.writeOneStructDeclParseTextFieldsFunc(sb, structDef)
+	this.writeOneStructDeclDescriptorFunc(sb, structDef)
 	this.writeOneStructDeclOptionalFunc(sb, structDef)
 	this.writeLine(sb,
 		"}")
 }
 
+func (this *PhpCodeGenerator) writeOneStructDeclFingerprintDecl(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeLineFormat(sb,
+		"    const FINGERPRINT = '%s';",
+		FingerprintStruct(structDef))
+	this.writeEmptyLine(sb)
+}
+
 func (this *PhpCodeGenerator) writeOneStructDeclFieldDecl(
 	sb *strings.Builder, structDef *StructDef) {
 
@@ -870,6 +971,279 @@ func (this *PhpCodeGenerator) writeOneStructDeclJsonFunc(
 		"    }")
 }
 
+func (this *PhpCodeGenerator) writeOneStructDeclDumpFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    public function dump()")
+	this.writeLine(sb,
+		"    {")
+
+	if len(structDef.Fields) <= 0 {
+		this.writeLine(sb,
+			"        return '';")
+	} else {
+		this.writeLine(sb,
+			"        $output = [];")
+		this.writeEmptyLine(sb)
+
+		for _, def := range structDef.Fields {
+			this.writeOneStructDeclDumpFuncWriteStatement(sb, def)
+		}
+
+		this.writeEmptyLine(sb)
+		this.writeLine(sb,
+			"        return implode(' ', $output);")
+	}
+
+	this.writeLine(sb,
+		"    }")
+}
+
+func (this *PhpCodeGenerator) writeOneStructDeclDumpFuncWriteStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef) {
+
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"        if ($this->has_%s()) {",
+			fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	// %s is field value
+	var writeStatement string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_I64V ||
+		checkType == StructFieldType_U64V {
+		writeStatement = "'" + fieldDef.Name + ": '.%s->toString()"
+	} else if checkType == StructFieldType_Bool {
+		writeStatement = "'" + fieldDef.Name + ": '.(%s ? 1 : 0)"
+	} else if checkType == StructFieldType_String {
+		writeStatement = "'" + fieldDef.Name +
+			": \"'.self::dumpString(%s).'\"'"
+	} else if checkType == StructFieldType_Bytes {
+		writeStatement = "'" + fieldDef.Name +
+			": \"'.self::dumpBytes(%s).'\"'"
+	} else if checkType == StructFieldType_Struct {
+		writeStatement = "'" + fieldDef.Name + ": { '.%s->dump().' }'"
+	} else {
+		writeStatement = "'" + fieldDef.Name + ": '.%s"
+	}
+
+	var indent string
+	if fieldDef.IsOptional {
+		indent = "            "
+	} else {
+		indent = "        "
+	}
+	if isList {
+		this.writeLineFormat(sb,
+			"%sfor ($i = 0; $i < count($this->%s); ++$i) {",
+			indent, fieldDef.Name)
+		this.writeLineFormat(sb,
+			"%s    $output[] = %s;",
+			indent, fmt.Sprintf(writeStatement,
+				"$this->"+fieldDef.Name+"[$i]"))
+		this.writeLineFormat(sb,
+			"%s}",
+			indent)
+	} else {
+		this.writeLineFormat(sb,
+			"%s$output[] = %s;",
+			indent, fmt.Sprintf(writeStatement,
+				"$this->"+fieldDef.Name))
+	}
+
+	if fieldDef.IsOptional {
+		this.writeLine(sb,
+			"        }")
+	}
+}
+
+func (this *PhpCodeGenerator) writeOneStructDeclParseTextFieldsFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    public function parseTextFields($parser)")
+	this.writeLine(sb,
+		"    {")
+
+	if len(structDef.Fields) <= 0 {
+		this.writeLine(sb,
+			"        $name = $parser->readFieldName();")
+		this.writeLine(sb,
+			"        if ($name !== null) {")
+		this.writeLine(sb,
+			"            throw $parser->unknownField($name);")
+		this.writeLine(sb,
+			"        }")
+		this.writeLine(sb,
+			"    }")
+		return
+	}
+
+	if structDef.OptionalFieldCount > 0 {
+		zeroList := strings.Repeat("0, ", structDef.OptionalByteCount)
+		zeroList = zeroList[:len(zeroList)-2]
+		this.writeLineFormat(sb,
+			"        $this->_has_bits_ = [%s];",
+			zeroList)
+	}
+	for _, def := range structDef.Fields {
+		this.writeLineFormat(sb,
+			"        $this->%s = %s;",
+			def.Name,
+			this.getStructFieldPhpTypeDefaultValue(def))
+	}
+	this.writeEmptyLine(sb)
+
+	this.writeLine(sb,
+		"        while (($name = $parser->readFieldName()) !== null) {")
+	for i, def := range structDef.Fields {
+		this.writeOneStructDeclParseTextFieldsFuncReadStatement(
+			sb, def, i == 0)
+	}
+	this.writeLine(sb,
+		"            } else {")
+	this.writeLine(sb,
+		"                throw $parser->unknownField($name);")
+	this.writeLine(sb,
+		"            }")
+	this.writeLine(sb,
+		"        }")
+
+	this.writeLine(sb,
+		"    }")
+}
+
+func (this *PhpCodeGenerator) writeOneStructDeclParseTextFieldsFuncReadStatement(
+	sb *strings.Builder, fieldDef *StructFieldDef, isFirstField bool) {
+
+	if isFirstField {
+		this.writeLineFormat(sb,
+			"            if ($name === '%s') {",
+			fieldDef.Name)
+	} else {
+		this.writeLineFormat(sb,
+			"            } else if ($name === '%s') {",
+			fieldDef.Name)
+	}
+
+	isList := fieldDef.Type == StructFieldType_List
+	var checkType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		checkType = fieldDef.ListType
+	} else {
+		checkType = fieldDef.Type
+	}
+
+	var readStatement string
+	if checkType == StructFieldType_I64 ||
+		checkType == StructFieldType_I64V {
+		readStatement = "$parser->readInt64()"
+	} else if checkType == StructFieldType_U64 ||
+		checkType == StructFieldType_U64V {
+		readStatement = "$parser->readUInt64()"
+	} else if checkType == StructFieldType_String {
+		readStatement = "$parser->readString()"
+	} else if checkType == StructFieldType_Bytes {
+		readStatement = "$parser->readBytes()"
+	} else if checkType == StructFieldType_Bool {
+		readStatement = "$parser->readBool()"
+	} else if checkType == StructFieldType_Struct {
+		readStatement = fmt.Sprintf("$parser->readStruct('%s')",
+			this.getStructFullQualifiedName(fieldDef.RefStructDef))
+	} else {
+		readStatement = "$parser->readInt()"
+	}
+
+	if isList {
+		this.writeLineFormat(sb,
+			"                $this->%s[] = %s;",
+			fieldDef.Name, readStatement)
+	} else {
+		this.writeLineFormat(sb,
+			"                $this->%s = %s;",
+			fieldDef.Name, readStatement)
+	}
+	if fieldDef.IsOptional {
+		this.writeLineFormat(sb,
+			"                $this->set_has_%s();",
+			fieldDef.Name)
+	}
+}
+
+func (this *PhpCodeGenerator) writeOneStructDeclDescriptorFunc(
+	sb *strings.Builder, structDef *StructDef) {
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    private static $s_descriptor_ = null;")
+
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"    public static function descriptor()")
+	this.writeLine(sb,
+		"    {")
+	this.writeLine(sb,
+		"        if (self::$s_descriptor_ === null) {")
+	this.writeLine(sb,
+		"            self::$s_descriptor_ = new StructDescriptor(")
+	this.writeLineFormat(sb,
+		"                '%s', '%s.%s', __CLASS__, [",
+		structDef.Name, structDef.ParentRef.Name, structDef.Name)
+	for _, def := range structDef.Fields {
+		isList := def.Type == StructFieldType_List
+		var checkType StructFieldType
+		if isList {
+			checkType = def.ListType
+		} else {
+			checkType = def.Type
+		}
+
+		optionalIndex := -1
+		if def.IsOptional {
+			optionalIndex = def.OptionalFieldIndex
+		}
+		refEnum := "null"
+		if def.RefEnumDef != nil {
+			refEnum = this.getEnumFullQualifiedName(def.RefEnumDef) +
+				"::descriptor()"
+		}
+		refStruct := "null"
+		if def.RefStructDef != nil {
+			refStruct = this.getStructFullQualifiedName(def.RefStructDef) +
+				"::descriptor()"
+		}
+
+		this.writeLineFormat(sb, ""+
+			"                    new FieldDescriptor('%s', FieldType::%s, "+
+			"%t, %d, %s, %s),",
+			def.Name,
+			strings.ToUpper(StructFieldTypeGetName(checkType)),
+			isList, optionalIndex, refEnum, refStruct)
+	}
+	this.writeLine(sb,
+		"                ]);")
+	this.writeLine(sb,
+		"        }")
+	this.writeEmptyLine(sb)
+	this.writeLine(sb,
+		"        return self::$s_descriptor_;")
+	this.writeLine(sb,
+		"    }")
+}
+
 func (this *PhpCodeGenerator) writeOneStructDeclOptionalFunc(
 	sb *strings.Builder, structDef *StructDef) {
 
@@ -974,7 +1348,12 @@ func (this *PhpCodeGenerator) writeOneEnumMapDecl(
 		this.writeEmptyLine(sb)
 	}
 
+	this.writeLineFormat(sb,
+		"    const FINGERPRINT = '%s';",
+		FingerprintEnumMap(enumMapDef))
+
 	// name id map
+	this.writeEmptyLine(sb)
 	this.writeLine(sb,
 		"    private static $s_name_id_map_ = [")
 	for _, def := range enumMapDef.Items {
@@ -1081,3 +1460,21 @@ func (this *PhpCodeGenerator) writeOneEnumMapDecl(
 	this.writeLine(sb,
 		"}")
 }
+
+func (this *PhpCodeGenerator) writeProtocolDecl(
+	sb *strings.Builder) {
+
+	protoDef := this.descriptor.ProtoDef
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb,
+		"final class %sProtocol",
+		UtilGetCamelCaseName(protoDef.Name))
+	this.writeLine(sb,
+		"{")
+	this.writeLineFormat(sb,
+		"    const FINGERPRINT = '%s';",
+		FingerprintProtocol(protoDef))
+	this.writeLine(sb,
+		"}")
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// language without builtin generator is generated by plugin program
+// `brexc-gen-<language>` found in PATH. the program reads a PluginRequest
+// in json from stdin and writes a PluginResponse in json to stdout,
+// it is run once for every protocol to generate
+const PluginProgramPrefix = "brexc-gen-"
+
+// version of request and response format, increased on incompatible
+// changes
+const PluginProtocolVersion = 1
+
+// return full path of plugin program of language, empty if not found
+func PluginFindProgram(language string) string {
+	if g_isPluginLanguageRegexp.MatchString(language) == false {
+		return ""
+	}
+
+	programPath, err := exec.LookPath(PluginProgramPrefix + language)
+	if err != nil {
+		return ""
+	}
+
+	return programPath
+}
+
+// ----------------------------------------------------------------------------
+type PluginRequest struct {
+	Version  int    `json:"version"`
+	Language string `json:"language"`
+	// name of protocol to generate
+	Protocol string                `json:"protocol"`
+	Options  *PluginRequestOptions `json:"options"`
+	// all protocols parsed, sorted by name, references are protocol
+	// qualified names of them, e.g. `attr.Attr`
+	Protocols []*PluginProtocol `json:"protocols"`
+}
+
+type PluginRequestOptions struct {
+	// unix or dos
+	NewLineType  string `json:"new_line_type"`
+	NamespaceDir bool   `json:"namespace_dir"`
+}
+
+type PluginProtocol struct {
+	Name        string `json:"name"`
+	FilePath    string `json:"file_path"`
+	Fingerprint string `json:"fingerprint"`
+	// language -> namespace, e.g. cpp -> protocol.client
+	Namespaces map[string]string `json:"namespaces"`
+	// names of imported protocols
+	Imports  []string         `json:"imports"`
+	Enums    []*PluginEnum    `json:"enums"`
+	Structs  []*PluginStruct  `json:"structs"`
+	EnumMaps []*PluginEnumMap `json:"enum_maps"`
+}
+
+type PluginEnum struct {
+	Name  string            `json:"name"`
+	Line  int               `json:"line"`
+	Items []*PluginEnumItem `json:"items"`
+}
+
+// value of enum map item, struct is set if the item is mapped to one
+type PluginEnumItem struct {
+	Name   string `json:"name"`
+	Line   int    `json:"line"`
+	Value  int    `json:"value"`
+	Struct string `json:"struct,omitempty"`
+}
+
+type PluginStruct struct {
+	Name              string         `json:"name"`
+	Line              int            `json:"line"`
+	Fingerprint       string         `json:"fingerprint"`
+	OptionalByteCount int            `json:"optional_byte_count"`
+	Fields            []*PluginField `json:"fields"`
+}
+
+type PluginField struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	// type name as written in protocol file, e.g. list{attr.Attr}
+	Type string `json:"type"`
+	// i8 ... bool, enum, struct or list
+	Kind string `json:"kind"`
+	// kind of list item
+	ListKind string `json:"list_kind,omitempty"`
+	// qualified name of enum or struct
+	Ref      string `json:"ref,omitempty"`
+	Optional bool   `json:"optional"`
+	// bit index in optional bytes, only for optional field
+	OptionalIndex *int `json:"optional_index,omitempty"`
+}
+
+type PluginEnumMap struct {
+	Name        string            `json:"name"`
+	Line        int               `json:"line"`
+	Fingerprint string            `json:"fingerprint"`
+	Items       []*PluginEnumItem `json:"items"`
+}
+
+type PluginResponse struct {
+	// set if failed, reported as error of compiler
+	Error string                `json:"error"`
+	Files []*PluginResponseFile `json:"files"`
+}
+
+type PluginResponseFile struct {
+	// slash separated path relative to output directory
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// ----------------------------------------------------------------------------
+func NewPluginProtocol(protoDef *ProtocolDef) *PluginProtocol {
+	newObj := new(PluginProtocol)
+	newObj.Name = protoDef.Name
+	newObj.FilePath = protoDef.FilePath
+	newObj.Fingerprint = FingerprintProtocol(protoDef)
+	newObj.Namespaces = make(map[string]string)
+	newObj.Imports = make([]string, 0, len(protoDef.Imports))
+	newObj.Enums = make([]*PluginEnum, 0, len(protoDef.Enums))
+	newObj.Structs = make([]*PluginStruct, 0, len(protoDef.Structs))
+	newObj.EnumMaps = make([]*PluginEnumMap, 0, len(protoDef.EnumMaps))
+
+	for language, def := range protoDef.Namespaces {
+		newObj.Namespaces[language] = def.Namespace
+	}
+	for _, def := range protoDef.Imports {
+		newObj.Imports = append(newObj.Imports, def.Name)
+	}
+
+	for _, enumDef := range protoDef.Enums {
+		enum := &PluginEnum{
+			Name:  enumDef.Name,
+			Line:  enumDef.LineNumber,
+			Items: make([]*PluginEnumItem, 0, len(enumDef.Items)),
+		}
+		for _, def := range enumDef.Items {
+			enum.Items = append(enum.Items, &PluginEnumItem{
+				Name:  def.Name,
+				Line:  def.LineNumber,
+				Value: def.IntValue,
+			})
+		}
+		newObj.Enums = append(newObj.Enums, enum)
+	}
+
+	for _, structDef := range protoDef.Structs {
+		st := &PluginStruct{
+			Name:              structDef.Name,
+			Line:              structDef.LineNumber,
+			Fingerprint:       FingerprintStruct(structDef),
+			OptionalByteCount: structDef.OptionalByteCount,
+			Fields:            make([]*PluginField, 0, len(structDef.Fields)),
+		}
+		for _, def := range structDef.Fields {
+			st.Fields = append(st.Fields, newPluginField(def))
+		}
+		newObj.Structs = append(newObj.Structs, st)
+	}
+
+	for _, enumMapDef := range protoDef.EnumMaps {
+		enumMap := &PluginEnumMap{
+			Name:        enumMapDef.Name,
+			Line:        enumMapDef.LineNumber,
+			Fingerprint: FingerprintEnumMap(enumMapDef),
+			Items:       make([]*PluginEnumItem, 0, len(enumMapDef.Items)),
+		}
+		for _, def := range enumMapDef.Items {
+			item := &PluginEnumItem{
+				Name:  def.Name,
+				Line:  def.LineNumber,
+				Value: def.IntValue,
+			}
+			if def.RefStructDef != nil {
+				item.Struct = StructDefGetQualifiedName(def.RefStructDef)
+			}
+			enumMap.Items = append(enumMap.Items, item)
+		}
+		newObj.EnumMaps = append(newObj.EnumMaps, enumMap)
+	}
+
+	return newObj
+}
+
+func newPluginField(fieldDef *StructFieldDef) *PluginField {
+	field := &PluginField{
+		Name:     fieldDef.Name,
+		Line:     fieldDef.LineNumber,
+		Type:     StructFieldDefGetTypeName(fieldDef),
+		Kind:     StructFieldTypeGetName(fieldDef.Type),
+		Optional: fieldDef.IsOptional,
+	}
+
+	itemType := fieldDef.Type
+	if fieldDef.Type == StructFieldType_List {
+		itemType = fieldDef.ListType
+		field.ListKind = StructFieldTypeGetName(fieldDef.ListType)
+	}
+	if itemType == StructFieldType_Enum {
+		field.Ref = fieldDef.RefEnumDef.ParentRef.Name + "." +
+			fieldDef.RefEnumDef.Name
+	} else if itemType == StructFieldType_Struct {
+		field.Ref = StructDefGetQualifiedName(fieldDef.RefStructDef)
+	}
+	if fieldDef.IsOptional {
+		optionalIndex := fieldDef.OptionalFieldIndex
+		field.OptionalIndex = &optionalIndex
+	}
+
+	return field
+}
+
+// ----------------------------------------------------------------------------
+type PluginCodeGenerator struct {
+	BaseCodeGenerator
+
+	language string
+	// all protocols of descriptor, built once for every protocol
+	protocols []*PluginProtocol
+}
+
+func NewPluginCodeGenerator(language string) *PluginCodeGenerator {
+	newObj := new(PluginCodeGenerator)
+	newObj.language = language
+
+	return newObj
+}
+
+func (this *PluginCodeGenerator) Close() {
+	this.protocols = nil
+	this.close()
+}
+
+func (this *PluginCodeGenerator) Generate(
+	descriptor *ProtocolDescriptor,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
+
+	this.init(descriptor, newLineType, useNamespaceDir)
+
+	programName := PluginProgramPrefix + this.language
+	programPath := PluginFindProgram(this.language)
+	if programPath == "" {
+		fmt.Fprintf(os.Stderr,
+			"error: can not find generator plugin `%s` in PATH\n",
+			programName)
+		return false
+	}
+
+	// run plugin
+	requestBin, _ := json.Marshal(this.getRequest(newLineType))
+	var stdout bytes.Buffer
+	cmd := exec.Command(programPath)
+	cmd.Stdin = bytes.NewReader(requestBin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: generator plugin `%s` failed: %s\n",
+			programName, err.Error())
+		return false
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: generator plugin `%s` returned invalid response: %s\n",
+			programName, err.Error())
+		return false
+	}
+	if response.Error != "" {
+		fmt.Fprintf(os.Stderr,
+			"error: generator plugin `%s` failed: %s\n",
+			programName, response.Error)
+		return false
+	}
+
+	// check all file names before writing any
+	for _, file := range response.Files {
+		if this.checkFileName(file.Name) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: generator plugin `%s` returned invalid "+
+					"file name `%s`\n",
+				programName, file.Name)
+			return false
+		}
+	}
+
+	for _, file := range response.Files {
+		filePath := filepath.Join(outputDir, filepath.FromSlash(file.Name))
+		dirPath := filepath.Dir(filePath)
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			fmt.Fprintf(os.Stderr,
+				"error: create directory %s failed: %s\n",
+				dirPath, err.Error())
+			return false
+		}
+		if this.writeOutputFile(filePath, file.Content) == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (this *PluginCodeGenerator) getRequest(
+	newLineType NewLineType) *PluginRequest {
+
+	if this.protocols == nil {
+		protoDefs := make([]*ProtocolDef, 0,
+			len(this.descriptor.ImportedProtos))
+		for _, protoDef := range this.descriptor.ImportedProtos {
+			protoDefs = append(protoDefs, protoDef)
+		}
+		sort.Slice(protoDefs, func(i, j int) bool {
+			return protoDefs[i].Name < protoDefs[j].Name
+		})
+
+		this.protocols = make([]*PluginProtocol, 0, len(protoDefs))
+		for _, protoDef := range protoDefs {
+			this.protocols = append(this.protocols,
+				NewPluginProtocol(protoDef))
+		}
+	}
+
+	options := new(PluginRequestOptions)
+	if newLineType == NewLineType_Dos {
+		options.NewLineType = "dos"
+	} else {
+		options.NewLineType = "unix"
+	}
+	options.NamespaceDir = this.useNamespaceDir
+
+	request := new(PluginRequest)
+	request.Version = PluginProtocolVersion
+	request.Language = this.language
+	request.Protocol = this.descriptor.ProtoDef.Name
+	request.Options = options
+	request.Protocols = this.protocols
+
+	return request
+}
+
+// file must stay inside output directory
+func (this *PluginCodeGenerator) checkFileName(fileName string) bool {
+	if fileName == "" ||
+		strings.HasPrefix(fileName, "/") ||
+		filepath.IsAbs(filepath.FromSlash(fileName)) {
+		return false
+	}
+
+	cleanName := filepath.ToSlash(filepath.Clean(filepath.FromSlash(fileName)))
+	if cleanName == "." || cleanName == ".." ||
+		strings.HasPrefix(cleanName, "../") {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// brexc.json, all paths are relative to the directory of the file,
+// output_dir is the directory of the file by default
+//
+//	{
+//	    "proto_files": ["proto"],
+//	    "search_paths": ["proto"],
+//	    "with_imports": true,
+//	    "languages": ["cpp", "csharp", "php"],
+//	    "output_dir": "gen",
+//	    "cpp_out": "gen/cpp",
+//	    "csharp_out": "gen/csharp",
+//	    "php_out": "gen/php",
+//	    "namespace_dir": false,
+//	    "new_line_type": "unix",
+//	    "depfile": "gen/brexc.d",
+//	    "lint": { "rules": { "missing-doc": "warning" } }
+//	}
+type ProjectFile struct {
+	ProtoFiles   []string `json:"proto_files"`
+	SearchPaths  []string `json:"search_paths"`
+	WithImports  bool     `json:"with_imports"`
+	Languages    []string `json:"languages"`
+	OutputDir    string   `json:"output_dir"`
+	CppOut       string   `json:"cpp_out"`
+	CSharpOut    string   `json:"csharp_out"`
+	PhpOut       string   `json:"php_out"`
+	NamespaceDir bool     `json:"namespace_dir"`
+	NewLineType  string   `json:"new_line_type"`
+	DepFile      string   `json:"depfile"`
+	// see LintConfig
+	Lint *LintConfig `json:"lint"`
+}
+
+// return nil if failed
+func LoadProjectFile(filePath string) *ProjectFile {
+	fileBin, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: can not read project file `%s`: %s\n",
+			filePath, err.Error())
+		return nil
+	}
+
+	projectFile := new(ProjectFile)
+	// values in file are merged into default config
+	projectFile.Lint = NewLintConfig()
+
+	decoder := json.NewDecoder(bytes.NewReader(fileBin))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(projectFile); err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: can not parse project file `%s`: %s\n",
+			filePath, err.Error())
+		return nil
+	}
+
+	projectFile.resolvePaths(filepath.Dir(filePath))
+
+	return projectFile
+}
+
+func (this *ProjectFile) resolvePaths(baseDir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(baseDir, path)
+	}
+
+	for i := range this.ProtoFiles {
+		this.ProtoFiles[i] = resolve(this.ProtoFiles[i])
+	}
+	for i := range this.SearchPaths {
+		this.SearchPaths[i] = resolve(this.SearchPaths[i])
+	}
+	// output to the directory of project file by default
+	if this.OutputDir == "" {
+		this.OutputDir = "."
+	}
+	this.OutputDir = resolve(this.OutputDir)
+	this.CppOut = resolve(this.CppOut)
+	this.CSharpOut = resolve(this.CSharpOut)
+	this.PhpOut = resolve(this.PhpOut)
+	this.DepFile = resolve(this.DepFile)
+}
+
+func (this *ProjectFile) ToCompileOptions() *CompileOptions {
+	options := NewCompileOptions()
+	if this.ProtoFiles != nil {
+		options.ProtoFilePaths = this.ProtoFiles
+	}
+	if this.SearchPaths != nil {
+		options.SearchPath = this.SearchPaths
+	}
+	options.WithImports = this.WithImports
+	options.Language = strings.Join(this.Languages, ",")
+	options.OutputDir = this.OutputDir
+	options.CppOutputDir = this.CppOut
+	options.CSharpOutputDir = this.CSharpOut
+	options.PhpOutputDir = this.PhpOut
+	options.NamespaceDir = this.NamespaceDir
+	options.NewLineType = this.NewLineType
+	options.DepFilePath = this.DepFile
+
+	return options
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type Proto3CodeGenerator struct {
+	BaseCodeGenerator
+}
+
+func NewProto3CodeGenerator() *Proto3CodeGenerator {
+	newObj := new(Proto3CodeGenerator)
+
+	return newObj
+}
+
+func (this *Proto3CodeGenerator) Close() {
+	this.close()
+}
+
+func (this *Proto3CodeGenerator) Generate(
+	descriptor *ProtocolDescriptor,
+	outputDir string, newLineType NewLineType,
+	useNamespaceDir bool) bool {
+
+	this.init(descriptor, newLineType, useNamespaceDir)
+
+	sourceFilePath := filepath.Join(outputDir, filepath.FromSlash(
+		this.getImportFilePath(this.descriptor.ProtoDef)))
+	sourceFileContent := this.generateSourceFile()
+	if this.writeOutputFile(sourceFilePath, sourceFileContent) == false {
+		return false
+	}
+
+	return true
+}
+
+// namespace of proto3, or of cpp which is named the same way
+// as protobuf package, nil if neither is defined
+func (this *Proto3CodeGenerator) getPackageDef(
+	protoDef *ProtocolDef) *NamespaceDef {
+
+	if namespaceDef, ok := protoDef.Namespaces["proto3"]; ok {
+		return namespaceDef
+	} else if namespaceDef, ok := protoDef.Namespaces["cpp"]; ok {
+		return namespaceDef
+	} else {
+		return nil
+	}
+}
+
+// relative to output directory, includes package directory if
+// namespace directory is used
+func (this *Proto3CodeGenerator) getImportFilePath(
+	protoDef *ProtocolDef) string {
+
+	packageDef := this.getPackageDef(protoDef)
+	if this.useNamespaceDir == false || packageDef == nil {
+		return protoDef.Name + ".proto"
+	}
+
+	return path.Join(path.Join(packageDef.NamespaceParts...),
+		protoDef.Name+".proto")
+}
+
+// definitions of imported protocols are referenced by full name
+func (this *Proto3CodeGenerator) getTypeName(
+	protoDef *ProtocolDef, name string) string {
+
+	if protoDef == this.descriptor.ProtoDef {
+		return name
+	}
+
+	packageDef := this.getPackageDef(protoDef)
+	if packageDef == nil {
+		return "." + name
+	} else {
+		return "." + strings.Join(packageDef.NamespaceParts, ".") +
+			"." + name
+	}
+}
+
+// values of all enums share the package scope in protobuf,
+// so they are prefixed by enum name, e.g. AttrType -> ATTR_TYPE_
+func (this *Proto3CodeGenerator) getEnumValuePrefix(name string) string {
+	return UtilGetUpperSnakeCaseName(name) + "_"
+}
+
+func (this *Proto3CodeGenerator) getFieldItemType(
+	fieldDef *StructFieldDef, itemType StructFieldType) string {
+
+	if itemType == StructFieldType_I8 ||
+		itemType == StructFieldType_I16 ||
+		itemType == StructFieldType_I16V ||
+		itemType == StructFieldType_I32V {
+		return "int32"
+	} else if itemType == StructFieldType_U8 ||
+		itemType == StructFieldType_U16 ||
+		itemType == StructFieldType_U16V ||
+		itemType == StructFieldType_U32V {
+		return "uint32"
+	} else if itemType == StructFieldType_I32 {
+		return "sfixed32"
+	} else if itemType == StructFieldType_U32 {
+		return "fixed32"
+	} else if itemType == StructFieldType_I64 {
+		return "sfixed64"
+	} else if itemType == StructFieldType_U64 {
+		return "fixed64"
+	} else if itemType == StructFieldType_I64V {
+		return "int64"
+	} else if itemType == StructFieldType_U64V {
+		return "uint64"
+	} else if itemType == StructFieldType_String {
+		return "string"
+	} else if itemType == StructFieldType_Bytes {
+		return "bytes"
+	} else if itemType == StructFieldType_Bool {
+		return "bool"
+	} else if itemType == StructFieldType_Enum {
+		refDef := fieldDef.RefEnumDef
+		return this.getTypeName(refDef.ParentRef, refDef.Name)
+	} else if itemType == StructFieldType_Struct {
+		refDef := fieldDef.RefStructDef
+		return this.getTypeName(refDef.ParentRef, refDef.Name)
+	} else {
+		return ""
+	}
+}
+
+func (this *Proto3CodeGenerator) generateSourceFile() string {
+	var sb strings.Builder
+
+	this.writeDontEditComment(&sb)
+	this.writeSyntaxDecl(&sb)
+	this.writePackageDecl(&sb)
+	this.writeImportDecl(&sb)
+	this.writeEnumDecl(&sb)
+	this.writeStructDecl(&sb)
+	this.writeEnumMapDecl(&sb)
+
+	return sb.String()
+}
+
+func (this *Proto3CodeGenerator) writeDontEditComment(
+	sb *strings.Builder) {
+
+	this.writeLine(sb,
+		"// Generated by brickred exchange compiler.")
+	this.writeLine(sb,
+		"// Do not edit unless you are sure that you know what you are doing.")
+	this.writeLine(sb,
+		"//")
+	this.writeLine(sb,
+		"// Schema only, the protobuf wire format is not compatible with")
+	this.writeLine(sb,
+		"// brickred binary, see `Export to Protocol Buffers` in README.")
+}
+
+func (this *Proto3CodeGenerator) writeSyntaxDecl(sb *strings.Builder) {
+	this.writeEmptyLine(sb)
+	this.writeLine(sb, "syntax = \"proto3\";")
+}
+
+func (this *Proto3CodeGenerator) writePackageDecl(sb *strings.Builder) {
+	packageDef := this.getPackageDef(this.descriptor.ProtoDef)
+	if packageDef == nil {
+		return
+	}
+
+	this.writeEmptyLine(sb)
+	this.writeLineFormat(sb, "package %s;",
+		strings.Join(packageDef.NamespaceParts, "."))
+}
+
+func (this *Proto3CodeGenerator) writeImportDecl(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+	if len(protoDef.Imports) == 0 {
+		return
+	}
+
+	this.writeEmptyLine(sb)
+	for _, importDef := range protoDef.Imports {
+		this.writeLineFormat(sb, "import \"%s\";",
+			this.getImportFilePath(importDef.ProtoDef))
+	}
+}
+
+// proto3 enum must start with a zero value, the item of zero is moved
+// first, or a placeholder is added if there is none,
+// items of the same value need allow_alias
+func (this *Proto3CodeGenerator) writeEnumValues(
+	sb *strings.Builder, prefix string,
+	names []string, values []int, comments []string) {
+
+	zeroIndex := -1
+	hasAlias := false
+	valueSet := make(map[int]bool)
+	for i, value := range values {
+		if value == 0 && zeroIndex < 0 {
+			zeroIndex = i
+		}
+		if valueSet[value] {
+			hasAlias = true
+		}
+		valueSet[value] = true
+	}
+
+	if hasAlias {
+		this.writeLine(sb, "    option allow_alias = true;")
+	}
+	order := make([]int, 0, len(values))
+	if zeroIndex < 0 {
+		this.writeLineFormat(sb,
+			"    %sUNSPECIFIED = 0; // placeholder, not in brickred",
+			prefix)
+	} else {
+		order = append(order, zeroIndex)
+	}
+	for i := range values {
+		if i != zeroIndex {
+			order = append(order, i)
+		}
+	}
+	for _, i := range order {
+		if comments[i] == "" {
+			this.writeLineFormat(sb, "    %s%s = %d;",
+				prefix, names[i], values[i])
+		} else {
+			this.writeLineFormat(sb, "    %s%s = %d; // %s",
+				prefix, names[i], values[i], comments[i])
+		}
+	}
+}
+
+func (this *Proto3CodeGenerator) writeEnumDecl(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+
+	for _, enumDef := range protoDef.Enums {
+		names := make([]string, 0, len(enumDef.Items))
+		values := make([]int, 0, len(enumDef.Items))
+		comments := make([]string, 0, len(enumDef.Items))
+		for _, def := range enumDef.Items {
+			names = append(names, def.Name)
+			values = append(values, def.IntValue)
+			comments = append(comments, "")
+		}
+
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, "enum %s {", enumDef.Name)
+		this.writeEnumValues(sb, this.getEnumValuePrefix(enumDef.Name),
+			names, values, comments)
+		this.writeLine(sb, "}")
+	}
+}
+
+// field number is field index plus 1, brickred fields can only be
+// appended to stay compatible, so the numbers are stable
+func (this *Proto3CodeGenerator) writeStructDecl(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+
+	for _, structDef := range protoDef.Structs {
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb, "message %s {", structDef.Name)
+		for i, def := range structDef.Fields {
+			var typeStr string
+			if def.Type == StructFieldType_List {
+				typeStr = "repeated " +
+					this.getFieldItemType(def, def.ListType)
+			} else if def.IsOptional {
+				typeStr = "optional " +
+					this.getFieldItemType(def, def.Type)
+			} else {
+				typeStr = this.getFieldItemType(def, def.Type)
+			}
+			this.writeLineFormat(sb, "    %s %s = %d;",
+				typeStr, def.Name, i+1)
+		}
+		this.writeLine(sb, "}")
+	}
+}
+
+// enum_map becomes an enum of ids, mapped messages are in comments
+func (this *Proto3CodeGenerator) writeEnumMapDecl(sb *strings.Builder) {
+	protoDef := this.descriptor.ProtoDef
+
+	for _, enumMapDef := range protoDef.EnumMaps {
+		names := make([]string, 0, len(enumMapDef.Items))
+		values := make([]int, 0, len(enumMapDef.Items))
+		comments := make([]string, 0, len(enumMapDef.Items))
+		for _, def := range enumMapDef.Items {
+			names = append(names, def.Name)
+			values = append(values, def.IntValue)
+			if def.RefStructDef != nil {
+				comments = append(comments, strings.TrimPrefix(
+					this.getTypeName(def.RefStructDef.ParentRef,
+						def.RefStructDef.Name), "."))
+			} else {
+				comments = append(comments, "")
+			}
+		}
+
+		this.writeEmptyLine(sb)
+		this.writeLineFormat(sb,
+			"// enum_map %s, message of each id is in comment", enumMapDef.Name)
+		this.writeLineFormat(sb, "enum %s {", enumMapDef.Name)
+		this.writeEnumValues(sb, this.getEnumValuePrefix(enumMapDef.Name),
+			names, values, comments)
+		this.writeLine(sb, "}")
+	}
+}
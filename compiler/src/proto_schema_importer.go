@@ -0,0 +1,643 @@
+package main
+
+import (
+	"html"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// protobuf scalar type -> brickred type, float and double have none
+var g_protoSchemaScalarTypes map[string]string = map[string]string{
+	"int32":    "i32v",
+	"uint32":   "u32v",
+	"sint32":   "i32v",
+	"int64":    "i64v",
+	"uint64":   "u64v",
+	"sint64":   "i64v",
+	"fixed32":  "u32",
+	"sfixed32": "i32",
+	"fixed64":  "u64",
+	"sfixed64": "i64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "bytes",
+}
+
+// message or enum, nested ones are flattened, e.g. Outer.Inner -> Outer_Inner
+type protoSchemaTypeDef struct {
+	File    *ProtoSchemaFile
+	Name    string
+	IsEnum  bool
+	Message *ProtoSchemaMessage
+}
+
+// struct to write, fields are in field number order
+type protoSchemaStructEntry struct {
+	Name    string
+	Message *ProtoSchemaMessage
+	// message path of scope to resolve field types
+	Scope  []string
+	Fields []*protoSchemaFieldEntry
+}
+
+type protoSchemaFieldEntry struct {
+	// required or optional
+	Label string
+	Name  string
+	Type  string
+	Field *ProtoSchemaField
+	// struct of the same file, must be written before
+	RefStruct *protoSchemaStructEntry
+}
+
+// ----------------------------------------------------------------------------
+type ProtoSchemaImporter struct {
+	Diagnostics *DiagnosticList
+	// all loaded files, imported ones first
+	Files []*ProtoSchemaFile
+
+	parser      *ProtoSchemaParser
+	searchPaths []string
+	// full path -> file
+	fileIndex map[string]*ProtoSchemaFile
+	// full path -> true while its imports are loading
+	loadingFiles map[string]bool
+	// file -> files it imports
+	importFiles map[*ProtoSchemaFile][]*ProtoSchemaFile
+	// full name -> def, e.g. foo.bar.Outer.Inner
+	typeIndex map[string]*protoSchemaTypeDef
+	// protocol name -> file
+	protoNameIndex map[string]*ProtoSchemaFile
+}
+
+func NewProtoSchemaImporter(searchPaths []string) *ProtoSchemaImporter {
+	newObj := new(ProtoSchemaImporter)
+	newObj.Diagnostics = NewDiagnosticList()
+	newObj.Files = make([]*ProtoSchemaFile, 0)
+	newObj.parser = NewProtoSchemaParser()
+	newObj.parser.Diagnostics = newObj.Diagnostics
+	newObj.searchPaths = searchPaths
+	newObj.fileIndex = make(map[string]*ProtoSchemaFile)
+	newObj.loadingFiles = make(map[string]bool)
+	newObj.importFiles = make(map[*ProtoSchemaFile][]*ProtoSchemaFile)
+	newObj.typeIndex = make(map[string]*protoSchemaTypeDef)
+	newObj.protoNameIndex = make(map[string]*ProtoSchemaFile)
+
+	return newObj
+}
+
+// protocol name of converted file, e.g. foo/attr.proto -> attr
+func ProtoSchemaGetProtocolName(file *ProtoSchemaFile) string {
+	return UtilGetFileNameWithoutExtension(file.FilePath)
+}
+
+// load files and all files they import, return loaded files of
+// filePaths or nil if failed
+func (this *ProtoSchemaImporter) Load(filePaths []string) []*ProtoSchemaFile {
+	files := make([]*ProtoSchemaFile, 0)
+	for _, filePath := range filePaths {
+		file := this.loadFile(filePath)
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	if this.Diagnostics.ErrorCount() > 0 {
+		return nil
+	}
+
+	if this.indexTypes() == false {
+		return nil
+	}
+
+	return files
+}
+
+func (this *ProtoSchemaImporter) loadFile(filePath string) *ProtoSchemaFile {
+	fullPath := UtilGetFullPath(filePath)
+	if file, ok := this.fileIndex[fullPath]; ok {
+		return file
+	}
+
+	file := this.parser.ParseFile(filePath)
+	if file == nil {
+		return nil
+	}
+
+	protoName := ProtoSchemaGetProtocolName(file)
+	if otherFile, ok := this.protoNameIndex[protoName]; ok {
+		this.Diagnostics.Add(filePath, 0, 0,
+			DiagnosticSeverity_Error, DiagnosticCode_DuplicatedName,
+			"protocol name `%s` is already used by `%s`",
+			protoName, otherFile.FilePath)
+		return nil
+	}
+	this.protoNameIndex[protoName] = file
+
+	this.loadingFiles[fullPath] = true
+	importFiles := make([]*ProtoSchemaFile, 0)
+	for _, importDef := range file.Imports {
+		importFilePath := this.findImportFile(file, importDef.Path)
+		if importFilePath == "" {
+			if strings.HasPrefix(importDef.Path, "google/protobuf/") {
+				this.Diagnostics.Add(filePath,
+					importDef.LineNumber, importDef.Column,
+					DiagnosticSeverity_Warning,
+					DiagnosticCode_ProtoNotSupported,
+					"well-known types of `%s` have no brickred "+
+						"equivalent, import is skipped", importDef.Path)
+			} else {
+				this.Diagnostics.Add(filePath,
+					importDef.LineNumber, importDef.Column,
+					DiagnosticSeverity_Error, DiagnosticCode_FileNotFound,
+					"can not find imported proto file `%s`", importDef.Path)
+			}
+			continue
+		}
+		if this.loadingFiles[UtilGetFullPath(importFilePath)] {
+			this.Diagnostics.Add(filePath,
+				importDef.LineNumber, importDef.Column,
+				DiagnosticSeverity_Error, DiagnosticCode_ImportFailed,
+				"proto file `%s` is imported recursively", importDef.Path)
+			continue
+		}
+
+		importFile := this.loadFile(importFilePath)
+		if importFile != nil {
+			importFiles = append(importFiles, importFile)
+		}
+	}
+	delete(this.loadingFiles, fullPath)
+
+	this.fileIndex[fullPath] = file
+	this.importFiles[file] = importFiles
+	this.Files = append(this.Files, file)
+
+	return file
+}
+
+// import path is relative to a search path or the importing file
+func (this *ProtoSchemaImporter) findImportFile(
+	file *ProtoSchemaFile, importPath string) string {
+
+	dirs := append([]string{}, this.searchPaths...)
+	dirs = append(dirs, filepath.Dir(file.FilePath))
+
+	for _, dir := range dirs {
+		filePath := filepath.Join(dir, filepath.FromSlash(importPath))
+		if UtilCheckFileExists(filePath) {
+			return filePath
+		}
+	}
+
+	return ""
+}
+
+func (this *ProtoSchemaImporter) indexTypes() bool {
+	for _, file := range this.Files {
+		// flattened name -> def, names must be unique in protocol
+		nameIndex := make(map[string]*protoSchemaTypeDef)
+
+		var addDef func(path []string, def *protoSchemaTypeDef,
+			lineNumber int, column int)
+		addDef = func(path []string, def *protoSchemaTypeDef,
+			lineNumber int, column int) {
+
+			fullName := strings.Join(path, ".")
+			if file.Package != "" {
+				fullName = file.Package + "." + fullName
+			}
+			if _, ok := nameIndex[def.Name]; ok {
+				this.Diagnostics.Add(file.FilePath, lineNumber, column,
+					DiagnosticSeverity_Error, DiagnosticCode_DuplicatedName,
+					"`%s` is already defined", def.Name)
+				return
+			}
+			nameIndex[def.Name] = def
+			this.typeIndex[fullName] = def
+		}
+
+		var addMessage func(path []string, message *ProtoSchemaMessage)
+		addMessage = func(path []string, message *ProtoSchemaMessage) {
+			path = append(append([]string{}, path...), message.Name)
+			addDef(path, &protoSchemaTypeDef{
+				File: file, Name: strings.Join(path, "_"),
+				Message: message},
+				message.LineNumber, message.Column)
+			for _, enum := range message.Enums {
+				enumPath := append(append([]string{}, path...), enum.Name)
+				addDef(enumPath, &protoSchemaTypeDef{
+					File: file, Name: strings.Join(enumPath, "_"),
+					IsEnum: true},
+					enum.LineNumber, enum.Column)
+			}
+			for _, nested := range message.Messages {
+				addMessage(path, nested)
+			}
+		}
+
+		for _, enum := range file.Enums {
+			addDef([]string{enum.Name}, &protoSchemaTypeDef{
+				File: file, Name: enum.Name, IsEnum: true},
+				enum.LineNumber, enum.Column)
+		}
+		for _, message := range file.Messages {
+			addMessage(nil, message)
+		}
+	}
+
+	return this.Diagnostics.ErrorCount() == 0
+}
+
+// type name is resolved from the innermost scope outwards,
+// a leading dot means full name
+func (this *ProtoSchemaImporter) resolveType(file *ProtoSchemaFile,
+	scope []string, typeName string) *protoSchemaTypeDef {
+
+	if strings.HasPrefix(typeName, ".") {
+		return this.typeIndex[typeName[1:]]
+	}
+
+	scopeParts := make([]string, 0)
+	if file.Package != "" {
+		scopeParts = append(scopeParts, strings.Split(file.Package, ".")...)
+	}
+	scopeParts = append(scopeParts, scope...)
+
+	for i := len(scopeParts); i >= 0; i-- {
+		fullName := strings.Join(append(
+			append([]string{}, scopeParts[:i]...), typeName), ".")
+		if def, ok := this.typeIndex[fullName]; ok {
+			return def
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+func (this *ProtoSchemaImporter) addWarning(file *ProtoSchemaFile,
+	lineNumber int, column int, format string, args ...any) {
+
+	this.Diagnostics.Add(file.FilePath, lineNumber, column,
+		DiagnosticSeverity_Warning, DiagnosticCode_ProtoNotSupported,
+		format, args...)
+}
+
+func (this *ProtoSchemaImporter) addUnsupportedWarnings(
+	file *ProtoSchemaFile, unsupported []*ProtoSchemaUnsupported) {
+
+	for _, def := range unsupported {
+		if def.Name == "" {
+			this.addWarning(file, def.LineNumber, def.Column,
+				"`%s` has no brickred equivalent and is skipped", def.Kind)
+		} else {
+			this.addWarning(file, def.LineNumber, def.Column,
+				"`%s %s` has no brickred equivalent and is skipped",
+				def.Kind, def.Name)
+		}
+	}
+}
+
+// return brickred protocol xml of file, constructs without brickred
+// equivalent are reported as warnings
+func (this *ProtoSchemaImporter) Convert(
+	file *ProtoSchemaFile) (string, bool) {
+
+	errorCount := this.Diagnostics.ErrorCount()
+	// diagnostics of file are reported in line order
+	firstItemIndex := len(this.Diagnostics.Items)
+	defer func() {
+		items := this.Diagnostics.Items[firstItemIndex:]
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].LineNumber < items[j].LineNumber
+		})
+	}()
+
+	if file.Syntax != "proto2" && file.Syntax != "proto3" {
+		this.addWarning(file, 0, 0,
+			"only proto2 and proto3 syntax are supported")
+	}
+	this.addUnsupportedWarnings(file, file.Unsupported)
+
+	// collect definitions, nested ones after their parent
+	enums := make([]*ProtoSchemaEnum, 0)
+	enumNames := make([]string, 0)
+	structs := make([]*protoSchemaStructEntry, 0)
+	structIndex := make(map[*ProtoSchemaMessage]*protoSchemaStructEntry)
+
+	var addMessage func(path []string, message *ProtoSchemaMessage)
+	addMessage = func(path []string, message *ProtoSchemaMessage) {
+		path = append(append([]string{}, path...), message.Name)
+		entry := &protoSchemaStructEntry{
+			Name:    strings.Join(path, "_"),
+			Message: message,
+			Scope:   path,
+		}
+		structs = append(structs, entry)
+		structIndex[message] = entry
+		this.addUnsupportedWarnings(file, message.Unsupported)
+
+		for _, enum := range message.Enums {
+			enums = append(enums, enum)
+			enumNames = append(enumNames,
+				strings.Join(path, "_")+"_"+enum.Name)
+		}
+		for _, nested := range message.Messages {
+			addMessage(path, nested)
+		}
+	}
+	for _, enum := range file.Enums {
+		enums = append(enums, enum)
+		enumNames = append(enumNames, enum.Name)
+	}
+	for _, message := range file.Messages {
+		addMessage(nil, message)
+	}
+
+	// convert fields
+	usedImportFiles := make(map[*ProtoSchemaFile]bool)
+	for _, entry := range structs {
+		this.convertFields(file, entry, structIndex, usedImportFiles)
+	}
+
+	// structs must be defined before being referenced
+	sortedStructs := this.sortStructs(file, structs)
+
+	if this.Diagnostics.ErrorCount() > errorCount {
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<protocol>\n\n")
+	this.writeNamespaces(&sb, file)
+	this.writeImports(&sb, file, usedImportFiles)
+	for i, enum := range enums {
+		this.writeEnum(&sb, enumNames[i], enum)
+	}
+	for _, entry := range sortedStructs {
+		this.writeStruct(&sb, entry)
+	}
+	sb.WriteString("</protocol>\n")
+
+	// align attributes in canonical layout
+	formatter := NewProtocolFormatter()
+	text, ok := formatter.Format(file.FilePath, sb.String())
+	if ok == false {
+		return sb.String(), true
+	}
+
+	return text, true
+}
+
+func (this *ProtoSchemaImporter) convertFields(file *ProtoSchemaFile,
+	entry *protoSchemaStructEntry,
+	structIndex map[*ProtoSchemaMessage]*protoSchemaStructEntry,
+	usedImportFiles map[*ProtoSchemaFile]bool) {
+
+	fields := append([]*ProtoSchemaField{}, entry.Message.Fields...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Number < fields[j].Number
+	})
+
+	reportedOneofs := make(map[string]bool)
+	entry.Fields = make([]*protoSchemaFieldEntry, 0, len(fields))
+	for _, field := range fields {
+		if field.MapKeyType != "" {
+			this.addWarning(file, field.LineNumber, field.Column,
+				"map field `%s` has no brickred equivalent and is skipped",
+				field.Name)
+			continue
+		}
+		if field.Type == "group" {
+			this.addWarning(file, field.LineNumber, field.Column,
+				"group `%s` has no brickred equivalent and is skipped",
+				field.Name)
+			continue
+		}
+		if field.HasDefault {
+			this.addWarning(file, field.LineNumber, field.Column,
+				"default value of field `%s` is ignored", field.Name)
+		}
+		if field.OneofName != "" && reportedOneofs[field.OneofName] == false {
+			reportedOneofs[field.OneofName] = true
+			this.addWarning(file, field.LineNumber, field.Column,
+				"oneof `%s` is converted to optional fields, "+
+					"only one being set is not checked", field.OneofName)
+		}
+
+		fieldEntry := &protoSchemaFieldEntry{
+			Label: "required",
+			Name:  field.Name,
+			Field: field,
+		}
+		if field.Label == "optional" || field.OneofName != "" {
+			fieldEntry.Label = "optional"
+		}
+
+		itemType, ok := g_protoSchemaScalarTypes[field.Type]
+		if ok == false {
+			if field.Type == "float" || field.Type == "double" {
+				this.addWarning(file, field.LineNumber, field.Column,
+					"type `%s` of field `%s` has no brickred equivalent, "+
+						"field is skipped", field.Type, field.Name)
+				continue
+			}
+
+			def := this.resolveType(file, entry.Scope, field.Type)
+			if def == nil {
+				if strings.HasPrefix(strings.TrimPrefix(field.Type, "."),
+					"google.protobuf.") {
+					this.addWarning(file, field.LineNumber, field.Column,
+						"well-known type `%s` of field `%s` has no "+
+							"brickred equivalent, field is skipped",
+						field.Type, field.Name)
+				} else {
+					this.Diagnostics.Add(file.FilePath,
+						field.LineNumber, field.Column,
+						DiagnosticSeverity_Error,
+						DiagnosticCode_UndefinedType,
+						"type `%s` is undefined", field.Type)
+				}
+				continue
+			}
+
+			if def.File == file {
+				itemType = def.Name
+				if def.IsEnum == false {
+					fieldEntry.RefStruct = structIndex[def.Message]
+				}
+			} else {
+				itemType = ProtoSchemaGetProtocolName(def.File) +
+					"." + def.Name
+				usedImportFiles[def.File] = true
+			}
+		}
+
+		if field.Label == "repeated" {
+			fieldEntry.Type = "list{" + itemType + "}"
+		} else {
+			fieldEntry.Type = itemType
+		}
+		entry.Fields = append(entry.Fields, fieldEntry)
+	}
+}
+
+// referenced structs first, fields making a cycle are skipped since
+// brickred struct can not contain itself
+func (this *ProtoSchemaImporter) sortStructs(file *ProtoSchemaFile,
+	structs []*protoSchemaStructEntry) []*protoSchemaStructEntry {
+
+	sortedStructs := make([]*protoSchemaStructEntry, 0, len(structs))
+	// 1 visiting, 2 visited
+	states := make(map[*protoSchemaStructEntry]int)
+
+	var visit func(entry *protoSchemaStructEntry)
+	visit = func(entry *protoSchemaStructEntry) {
+		states[entry] = 1
+		fields := make([]*protoSchemaFieldEntry, 0, len(entry.Fields))
+		for _, fieldEntry := range entry.Fields {
+			refStruct := fieldEntry.RefStruct
+			if refStruct != nil && states[refStruct] == 1 {
+				this.addWarning(file,
+					fieldEntry.Field.LineNumber, fieldEntry.Field.Column,
+					"field `%s` refers to `%s` recursively, which has no "+
+						"brickred equivalent, field is skipped",
+					fieldEntry.Name, refStruct.Name)
+				continue
+			}
+			if refStruct != nil && states[refStruct] == 0 {
+				visit(refStruct)
+			}
+			fields = append(fields, fieldEntry)
+		}
+		entry.Fields = fields
+		states[entry] = 2
+		sortedStructs = append(sortedStructs, entry)
+	}
+
+	for _, entry := range structs {
+		if states[entry] == 0 {
+			visit(entry)
+		}
+	}
+
+	return sortedStructs
+}
+
+// ----------------------------------------------------------------------------
+// package is cpp namespace, csharp and php use their file options
+// or camel case parts of package
+func (this *ProtoSchemaImporter) writeNamespaces(
+	sb *strings.Builder, file *ProtoSchemaFile) {
+
+	camelCaseParts := make([]string, 0)
+	if file.Package != "" {
+		for _, part := range strings.Split(file.Package, ".") {
+			camelCaseParts = append(camelCaseParts,
+				UtilGetCamelCaseName(part))
+		}
+	}
+	camelCaseNamespace := strings.Join(camelCaseParts, ".")
+
+	csharpNamespace := file.Options["csharp_namespace"]
+	if csharpNamespace == "" {
+		csharpNamespace = camelCaseNamespace
+	}
+	phpNamespace := strings.ReplaceAll(
+		file.Options["php_namespace"], "\\", ".")
+	if phpNamespace == "" {
+		phpNamespace = camelCaseNamespace
+	}
+
+	if file.Package == "" && csharpNamespace == "" && phpNamespace == "" {
+		return
+	}
+	if file.Package != "" {
+		sb.WriteString("<namespace lang=\"cpp\">" +
+			html.EscapeString(file.Package) + "</namespace>\n")
+	}
+	if phpNamespace != "" {
+		sb.WriteString("<namespace lang=\"php\">" +
+			html.EscapeString(phpNamespace) + "</namespace>\n")
+	}
+	if csharpNamespace != "" {
+		sb.WriteString("<namespace lang=\"csharp\">" +
+			html.EscapeString(csharpNamespace) + "</namespace>\n")
+	}
+	sb.WriteString("\n")
+}
+
+// only imports with referenced types, in import order
+func (this *ProtoSchemaImporter) writeImports(sb *strings.Builder,
+	file *ProtoSchemaFile, usedImportFiles map[*ProtoSchemaFile]bool) {
+
+	importFiles := make([]*ProtoSchemaFile, 0)
+	for _, importFile := range this.importFiles[file] {
+		if usedImportFiles[importFile] {
+			importFiles = append(importFiles, importFile)
+			delete(usedImportFiles, importFile)
+		}
+	}
+	// types of public imports
+	otherFiles := make([]*ProtoSchemaFile, 0)
+	for importFile := range usedImportFiles {
+		otherFiles = append(otherFiles, importFile)
+	}
+	sort.Slice(otherFiles, func(i, j int) bool {
+		return ProtoSchemaGetProtocolName(otherFiles[i]) <
+			ProtoSchemaGetProtocolName(otherFiles[j])
+	})
+	importFiles = append(importFiles, otherFiles...)
+
+	if len(importFiles) == 0 {
+		return
+	}
+	for _, importFile := range importFiles {
+		sb.WriteString("<import>" +
+			ProtoSchemaGetProtocolName(importFile) + ".xml</import>\n")
+	}
+	sb.WriteString("\n")
+}
+
+// common prefix of protobuf style, e.g. ATTR_TYPE_ of AttrType, is removed
+func (this *ProtoSchemaImporter) writeEnum(sb *strings.Builder,
+	name string, enum *ProtoSchemaEnum) {
+
+	prefix := UtilGetUpperSnakeCaseName(enum.Name) + "_"
+	for _, item := range enum.Items {
+		itemName := strings.TrimPrefix(item.Name, prefix)
+		if itemName == item.Name ||
+			g_isVarNameRegexp.MatchString(itemName) == false ||
+			(itemName[0] >= '0' && itemName[0] <= '9') {
+			prefix = ""
+			break
+		}
+	}
+
+	sb.WriteString("<enum name=\"" + name + "\">\n")
+	for i, item := range enum.Items {
+		itemName := strings.TrimPrefix(item.Name, prefix)
+		// value is written only if not previous plus 1
+		if i == 0 || item.Value != enum.Items[i-1].Value+1 {
+			sb.WriteString("  <item name=\"" + itemName +
+				"\" value=\"" + strconv.Itoa(item.Value) + "\"/>\n")
+		} else {
+			sb.WriteString("  <item name=\"" + itemName + "\"/>\n")
+		}
+	}
+	sb.WriteString("</enum>\n\n")
+}
+
+func (this *ProtoSchemaImporter) writeStruct(
+	sb *strings.Builder, entry *protoSchemaStructEntry) {
+
+	sb.WriteString("<struct name=\"" + entry.Name + "\">\n")
+	for _, fieldEntry := range entry.Fields {
+		sb.WriteString("  <" + fieldEntry.Label +
+			" name=\"" + fieldEntry.Name +
+			"\" type=\"" + fieldEntry.Type + "\"/>\n")
+	}
+	sb.WriteString("</struct>\n\n")
+}
@@ -0,0 +1,905 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parser of protobuf schema files (proto2 and proto3), only keeps what
+// can be converted to brickred protocol, other constructs are recorded
+// as unsupported and converter reports them
+
+// ----------------------------------------------------------------------------
+type ProtoSchemaFile struct {
+	FilePath string
+	// proto2 or proto3
+	Syntax  string
+	Package string
+	// file level options, e.g. csharp_namespace -> Foo.Bar
+	Options     map[string]string
+	Imports     []*ProtoSchemaImport
+	Enums       []*ProtoSchemaEnum
+	Messages    []*ProtoSchemaMessage
+	Unsupported []*ProtoSchemaUnsupported
+}
+
+type ProtoSchemaImport struct {
+	Path       string
+	LineNumber int
+	Column     int
+}
+
+type ProtoSchemaEnumItem struct {
+	Name       string
+	Value      int
+	LineNumber int
+	Column     int
+}
+
+type ProtoSchemaEnum struct {
+	Name       string
+	Items      []*ProtoSchemaEnumItem
+	LineNumber int
+	Column     int
+}
+
+type ProtoSchemaField struct {
+	// empty, optional, required or repeated
+	Label string
+	// scalar type or referenced type name as written
+	Type string
+	// key and value types of map field
+	MapKeyType string
+	Name       string
+	Number     int
+	// name of oneof containing the field
+	OneofName  string
+	HasDefault bool
+	LineNumber int
+	Column     int
+}
+
+type ProtoSchemaMessage struct {
+	Name        string
+	Fields      []*ProtoSchemaField
+	Enums       []*ProtoSchemaEnum
+	Messages    []*ProtoSchemaMessage
+	Unsupported []*ProtoSchemaUnsupported
+	LineNumber  int
+	Column      int
+}
+
+// e.g. service, extend, extensions
+type ProtoSchemaUnsupported struct {
+	Kind       string
+	Name       string
+	LineNumber int
+	Column     int
+}
+
+// ----------------------------------------------------------------------------
+type protoSchemaTokenType int
+
+const (
+	protoSchemaTokenType_EOF protoSchemaTokenType = iota
+	protoSchemaTokenType_Ident
+	protoSchemaTokenType_Int
+	protoSchemaTokenType_Float
+	protoSchemaTokenType_String
+	protoSchemaTokenType_Symbol
+)
+
+type protoSchemaToken struct {
+	Type protoSchemaTokenType
+	// decoded value for string
+	Text       string
+	LineNumber int
+	Column     int
+}
+
+// ----------------------------------------------------------------------------
+type ProtoSchemaParser struct {
+	Diagnostics *DiagnosticList
+
+	filePath string
+	tokens   []*protoSchemaToken
+	pos      int
+}
+
+func NewProtoSchemaParser() *ProtoSchemaParser {
+	newObj := new(ProtoSchemaParser)
+	newObj.Diagnostics = NewDiagnosticList()
+
+	return newObj
+}
+
+// return nil if failed, the first syntax error is reported
+func (this *ProtoSchemaParser) ParseFile(filePath string) *ProtoSchemaFile {
+	fileBin, err := os.ReadFile(filePath)
+	if err != nil {
+		this.Diagnostics.Add(filePath, 0, 0,
+			DiagnosticSeverity_Error, DiagnosticCode_FileReadFailed,
+			"can not read proto file: %s", err.Error())
+		return nil
+	}
+
+	this.filePath = filePath
+	this.tokens = nil
+	this.pos = 0
+	if this.tokenize(string(fileBin)) == false {
+		return nil
+	}
+
+	file := &ProtoSchemaFile{
+		FilePath:    filePath,
+		Syntax:      "proto2",
+		Options:     make(map[string]string),
+		Imports:     make([]*ProtoSchemaImport, 0),
+		Enums:       make([]*ProtoSchemaEnum, 0),
+		Messages:    make([]*ProtoSchemaMessage, 0),
+		Unsupported: make([]*ProtoSchemaUnsupported, 0),
+	}
+	if this.parseFile(file) == false {
+		return nil
+	}
+
+	return file
+}
+
+func (this *ProtoSchemaParser) addError(
+	lineNumber int, column int, format string, args ...any) {
+
+	this.Diagnostics.Add(this.filePath, lineNumber, column,
+		DiagnosticSeverity_Error, DiagnosticCode_ProtoSyntaxError,
+		format, args...)
+}
+
+func (this *ProtoSchemaParser) addTokenError(
+	token *protoSchemaToken, expected string) {
+
+	if token.Type == protoSchemaTokenType_EOF {
+		this.addError(token.LineNumber, token.Column,
+			"expect %s, but reach end of file", expected)
+	} else {
+		this.addError(token.LineNumber, token.Column,
+			"expect %s, but get `%s`", expected, token.Text)
+	}
+}
+
+// ----------------------------------------------------------------------------
+func protoSchemaIsIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func protoSchemaIsDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (this *ProtoSchemaParser) tokenize(text string) bool {
+	lineNumber := 1
+	lineStart := 0
+	i := 0
+
+	for i < len(text) {
+		c := text[i]
+		column := i - lineStart + 1
+
+		if c == '\n' {
+			lineNumber++
+			i++
+			lineStart = i
+		} else if c == ' ' || c == '\t' || c == '\r' ||
+			c == '\v' || c == '\f' {
+			i++
+		} else if strings.HasPrefix(text[i:], "//") {
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		} else if strings.HasPrefix(text[i:], "/*") {
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				this.addError(lineNumber, column, "comment is not closed")
+				return false
+			}
+			comment := text[i : i+2+end+2]
+			if count := strings.Count(comment, "\n"); count > 0 {
+				lineNumber += count
+				lineStart = i + strings.LastIndex(comment, "\n") + 1
+			}
+			i += len(comment)
+		} else if protoSchemaIsIdentStart(c) {
+			start := i
+			for i < len(text) &&
+				(protoSchemaIsIdentStart(text[i]) ||
+					protoSchemaIsDigit(text[i])) {
+				i++
+			}
+			this.addToken(protoSchemaTokenType_Ident,
+				text[start:i], lineNumber, column)
+		} else if protoSchemaIsDigit(c) ||
+			(c == '.' && i+1 < len(text) && protoSchemaIsDigit(text[i+1])) {
+			start := i
+			isFloat := false
+			isHex := strings.HasPrefix(text[i:], "0x") ||
+				strings.HasPrefix(text[i:], "0X")
+			for i < len(text) {
+				d := text[i]
+				if d == '.' {
+					isFloat = true
+				} else if (d == 'e' || d == 'E') && isHex == false {
+					isFloat = true
+					if i+1 < len(text) &&
+						(text[i+1] == '+' || text[i+1] == '-') {
+						i++
+					}
+				} else if protoSchemaIsIdentStart(d) == false &&
+					protoSchemaIsDigit(d) == false {
+					break
+				}
+				i++
+			}
+			if isFloat {
+				this.addToken(protoSchemaTokenType_Float,
+					text[start:i], lineNumber, column)
+			} else {
+				this.addToken(protoSchemaTokenType_Int,
+					text[start:i], lineNumber, column)
+			}
+		} else if c == '"' || c == '\'' {
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(text) || text[i] == '\n' {
+					this.addError(lineNumber, column,
+						"string is not closed")
+					return false
+				}
+				if text[i] == c {
+					i++
+					break
+				}
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+					if text[i] == 'n' {
+						sb.WriteByte('\n')
+					} else if text[i] == 't' {
+						sb.WriteByte('\t')
+					} else if text[i] == 'r' {
+						sb.WriteByte('\r')
+					} else {
+						sb.WriteByte(text[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteByte(text[i])
+				i++
+			}
+			this.addToken(protoSchemaTokenType_String,
+				sb.String(), lineNumber, column)
+		} else if strings.IndexByte("{}[]()<>;=,.:-+/", c) >= 0 {
+			this.addToken(protoSchemaTokenType_Symbol,
+				text[i:i+1], lineNumber, column)
+			i++
+		} else {
+			this.addError(lineNumber, column,
+				"unexpected character `%c`", c)
+			return false
+		}
+	}
+	this.addToken(protoSchemaTokenType_EOF, "",
+		lineNumber, len(text)-lineStart+1)
+
+	return true
+}
+
+func (this *ProtoSchemaParser) addToken(tokenType protoSchemaTokenType,
+	text string, lineNumber int, column int) {
+
+	this.tokens = append(this.tokens, &protoSchemaToken{
+		Type:       tokenType,
+		Text:       text,
+		LineNumber: lineNumber,
+		Column:     column,
+	})
+}
+
+// ----------------------------------------------------------------------------
+func (this *ProtoSchemaParser) peek() *protoSchemaToken {
+	return this.tokens[this.pos]
+}
+
+func (this *ProtoSchemaParser) next() *protoSchemaToken {
+	token := this.tokens[this.pos]
+	if token.Type != protoSchemaTokenType_EOF {
+		this.pos++
+	}
+
+	return token
+}
+
+func (this *ProtoSchemaParser) peekSymbol(symbol string) bool {
+	token := this.peek()
+	return token.Type == protoSchemaTokenType_Symbol && token.Text == symbol
+}
+
+func (this *ProtoSchemaParser) peekIdent(ident string) bool {
+	token := this.peek()
+	return token.Type == protoSchemaTokenType_Ident && token.Text == ident
+}
+
+func (this *ProtoSchemaParser) expectSymbol(symbol string) bool {
+	token := this.next()
+	if token.Type != protoSchemaTokenType_Symbol || token.Text != symbol {
+		this.addTokenError(token, "`"+symbol+"`")
+		return false
+	}
+
+	return true
+}
+
+func (this *ProtoSchemaParser) expectIdent() (*protoSchemaToken, bool) {
+	token := this.next()
+	if token.Type != protoSchemaTokenType_Ident {
+		this.addTokenError(token, "identifier")
+		return nil, false
+	}
+
+	return token, true
+}
+
+func (this *ProtoSchemaParser) expectString() (string, bool) {
+	token := this.next()
+	if token.Type != protoSchemaTokenType_String {
+		this.addTokenError(token, "string")
+		return "", false
+	}
+
+	// adjacent strings are concatenated
+	value := token.Text
+	for this.peek().Type == protoSchemaTokenType_String {
+		value += this.next().Text
+	}
+
+	return value, true
+}
+
+// e.g. foo.Bar or .foo.Bar
+func (this *ProtoSchemaParser) expectFullIdent() (string, bool) {
+	var sb strings.Builder
+	if this.peekSymbol(".") {
+		this.next()
+		sb.WriteString(".")
+	}
+
+	for {
+		token, ok := this.expectIdent()
+		if ok == false {
+			return "", false
+		}
+		sb.WriteString(token.Text)
+		if this.peekSymbol(".") == false {
+			break
+		}
+		this.next()
+		sb.WriteString(".")
+	}
+
+	return sb.String(), true
+}
+
+func (this *ProtoSchemaParser) expectInt() (int, bool) {
+	negative := false
+	if this.peekSymbol("-") {
+		this.next()
+		negative = true
+	}
+
+	token := this.next()
+	if token.Type != protoSchemaTokenType_Int {
+		this.addTokenError(token, "integer")
+		return 0, false
+	}
+	value, err := strconv.ParseInt(token.Text, 0, 64)
+	if err != nil {
+		this.addError(token.LineNumber, token.Column,
+			"integer `%s` is invalid", token.Text)
+		return 0, false
+	}
+	if negative {
+		value = -value
+	}
+
+	return int(value), true
+}
+
+// option name, e.g. java_package or (my.ext).field
+func (this *ProtoSchemaParser) expectOptionName() (string, bool) {
+	var sb strings.Builder
+
+	for {
+		if this.peekSymbol("(") {
+			this.next()
+			name, ok := this.expectFullIdent()
+			if ok == false {
+				return "", false
+			}
+			if this.expectSymbol(")") == false {
+				return "", false
+			}
+			sb.WriteString("(" + name + ")")
+		} else {
+			token, ok := this.expectIdent()
+			if ok == false {
+				return "", false
+			}
+			sb.WriteString(token.Text)
+		}
+		if this.peekSymbol(".") == false {
+			break
+		}
+		this.next()
+		sb.WriteString(".")
+	}
+
+	return sb.String(), true
+}
+
+// return text of scalar constant, aggregate value is skipped
+func (this *ProtoSchemaParser) expectConstant() (string, bool) {
+	if this.peekSymbol("{") {
+		return "", this.skipBlock()
+	}
+
+	sign := ""
+	if this.peekSymbol("-") || this.peekSymbol("+") {
+		sign = this.next().Text
+	}
+
+	token := this.peek()
+	if token.Type == protoSchemaTokenType_String {
+		return this.expectString()
+	} else if token.Type == protoSchemaTokenType_Ident {
+		return this.expectFullIdent()
+	} else if token.Type == protoSchemaTokenType_Int ||
+		token.Type == protoSchemaTokenType_Float {
+		this.next()
+		return sign + token.Text, true
+	} else {
+		this.addTokenError(token, "constant")
+		return "", false
+	}
+}
+
+// skip balanced `{ ... }`
+func (this *ProtoSchemaParser) skipBlock() bool {
+	if this.expectSymbol("{") == false {
+		return false
+	}
+
+	depth := 1
+	for depth > 0 {
+		token := this.next()
+		if token.Type == protoSchemaTokenType_EOF {
+			this.addTokenError(token, "`}`")
+			return false
+		}
+		if token.Type != protoSchemaTokenType_Symbol {
+			continue
+		}
+		if token.Text == "{" {
+			depth++
+		} else if token.Text == "}" {
+			depth--
+		}
+	}
+
+	return true
+}
+
+// skip to `;` of current statement, e.g. reserved or extensions
+func (this *ProtoSchemaParser) skipStatement() bool {
+	for {
+		token := this.next()
+		if token.Type == protoSchemaTokenType_EOF {
+			this.addTokenError(token, "`;`")
+			return false
+		}
+		if token.Type == protoSchemaTokenType_Symbol && token.Text == ";" {
+			return true
+		}
+	}
+}
+
+// `option name = constant;`, return name and value
+func (this *ProtoSchemaParser) parseOption() (string, string, bool) {
+	this.next()
+	name, ok := this.expectOptionName()
+	if ok == false {
+		return "", "", false
+	}
+	if this.expectSymbol("=") == false {
+		return "", "", false
+	}
+	value, ok := this.expectConstant()
+	if ok == false {
+		return "", "", false
+	}
+	if this.expectSymbol(";") == false {
+		return "", "", false
+	}
+
+	return name, value, true
+}
+
+// `[name = constant, ...]`, return options
+func (this *ProtoSchemaParser) parseFieldOptions() (map[string]string, bool) {
+	options := make(map[string]string)
+	if this.peekSymbol("[") == false {
+		return options, true
+	}
+	this.next()
+
+	for {
+		name, ok := this.expectOptionName()
+		if ok == false {
+			return nil, false
+		}
+		if this.expectSymbol("=") == false {
+			return nil, false
+		}
+		value, ok := this.expectConstant()
+		if ok == false {
+			return nil, false
+		}
+		options[name] = value
+		if this.peekSymbol(",") == false {
+			break
+		}
+		this.next()
+	}
+	if this.expectSymbol("]") == false {
+		return nil, false
+	}
+
+	return options, true
+}
+
+// ----------------------------------------------------------------------------
+func (this *ProtoSchemaParser) parseFile(file *ProtoSchemaFile) bool {
+	for {
+		token := this.peek()
+		if token.Type == protoSchemaTokenType_EOF {
+			break
+		}
+
+		if this.peekSymbol(";") {
+			this.next()
+		} else if this.peekIdent("syntax") || this.peekIdent("edition") {
+			this.next()
+			if this.expectSymbol("=") == false {
+				return false
+			}
+			syntax, ok := this.expectString()
+			if ok == false {
+				return false
+			}
+			if this.expectSymbol(";") == false {
+				return false
+			}
+			if token.Text == "edition" {
+				file.Unsupported = append(file.Unsupported,
+					&ProtoSchemaUnsupported{"edition", syntax,
+						token.LineNumber, token.Column})
+				file.Syntax = "proto3"
+			} else if syntax == "proto2" || syntax == "proto3" {
+				file.Syntax = syntax
+			} else {
+				this.addError(token.LineNumber, token.Column,
+					"syntax `%s` is invalid", syntax)
+				return false
+			}
+		} else if this.peekIdent("package") {
+			this.next()
+			name, ok := this.expectFullIdent()
+			if ok == false {
+				return false
+			}
+			if this.expectSymbol(";") == false {
+				return false
+			}
+			file.Package = name
+		} else if this.peekIdent("import") {
+			this.next()
+			if this.peekIdent("public") || this.peekIdent("weak") {
+				this.next()
+			}
+			pathToken := this.peek()
+			importPath, ok := this.expectString()
+			if ok == false {
+				return false
+			}
+			if this.expectSymbol(";") == false {
+				return false
+			}
+			file.Imports = append(file.Imports, &ProtoSchemaImport{
+				importPath, pathToken.LineNumber, pathToken.Column})
+		} else if this.peekIdent("option") {
+			name, value, ok := this.parseOption()
+			if ok == false {
+				return false
+			}
+			file.Options[name] = value
+		} else if this.peekIdent("message") {
+			message := this.parseMessage()
+			if message == nil {
+				return false
+			}
+			file.Messages = append(file.Messages, message)
+		} else if this.peekIdent("enum") {
+			enum := this.parseEnum()
+			if enum == nil {
+				return false
+			}
+			file.Enums = append(file.Enums, enum)
+		} else if this.peekIdent("service") || this.peekIdent("extend") {
+			unsupported := this.parseUnsupportedBlock()
+			if unsupported == nil {
+				return false
+			}
+			file.Unsupported = append(file.Unsupported, unsupported)
+		} else {
+			this.addTokenError(token, "top level definition")
+			return false
+		}
+	}
+
+	return true
+}
+
+// `service Name { ... }` or `extend Name { ... }`
+func (this *ProtoSchemaParser) parseUnsupportedBlock() *ProtoSchemaUnsupported {
+	token := this.next()
+	name, ok := this.expectFullIdent()
+	if ok == false {
+		return nil
+	}
+	if this.skipBlock() == false {
+		return nil
+	}
+
+	return &ProtoSchemaUnsupported{
+		token.Text, name, token.LineNumber, token.Column}
+}
+
+func (this *ProtoSchemaParser) parseEnum() *ProtoSchemaEnum {
+	this.next()
+	nameToken, ok := this.expectIdent()
+	if ok == false {
+		return nil
+	}
+	if this.expectSymbol("{") == false {
+		return nil
+	}
+
+	enum := &ProtoSchemaEnum{
+		Name:       nameToken.Text,
+		Items:      make([]*ProtoSchemaEnumItem, 0),
+		LineNumber: nameToken.LineNumber,
+		Column:     nameToken.Column,
+	}
+	for this.peekSymbol("}") == false {
+		if this.peekSymbol(";") {
+			this.next()
+		} else if this.peekIdent("option") {
+			if _, _, ok := this.parseOption(); ok == false {
+				return nil
+			}
+		} else if this.peekIdent("reserved") {
+			if this.skipStatement() == false {
+				return nil
+			}
+		} else {
+			itemToken, ok := this.expectIdent()
+			if ok == false {
+				return nil
+			}
+			if this.expectSymbol("=") == false {
+				return nil
+			}
+			value, ok := this.expectInt()
+			if ok == false {
+				return nil
+			}
+			if _, ok := this.parseFieldOptions(); ok == false {
+				return nil
+			}
+			if this.expectSymbol(";") == false {
+				return nil
+			}
+			enum.Items = append(enum.Items, &ProtoSchemaEnumItem{
+				itemToken.Text, value,
+				itemToken.LineNumber, itemToken.Column})
+		}
+	}
+	this.next()
+
+	return enum
+}
+
+func (this *ProtoSchemaParser) parseMessage() *ProtoSchemaMessage {
+	this.next()
+	nameToken, ok := this.expectIdent()
+	if ok == false {
+		return nil
+	}
+
+	message := &ProtoSchemaMessage{
+		Name:        nameToken.Text,
+		Fields:      make([]*ProtoSchemaField, 0),
+		Enums:       make([]*ProtoSchemaEnum, 0),
+		Messages:    make([]*ProtoSchemaMessage, 0),
+		Unsupported: make([]*ProtoSchemaUnsupported, 0),
+		LineNumber:  nameToken.LineNumber,
+		Column:      nameToken.Column,
+	}
+	if this.parseMessageBody(message, "") == false {
+		return nil
+	}
+
+	return message
+}
+
+// body of message, or of oneof if oneofName is not empty
+func (this *ProtoSchemaParser) parseMessageBody(
+	message *ProtoSchemaMessage, oneofName string) bool {
+
+	if this.expectSymbol("{") == false {
+		return false
+	}
+
+	for this.peekSymbol("}") == false {
+		token := this.peek()
+
+		if this.peekSymbol(";") {
+			this.next()
+		} else if this.peekIdent("option") {
+			if _, _, ok := this.parseOption(); ok == false {
+				return false
+			}
+		} else if oneofName != "" {
+			field := this.parseField(message, "", oneofName)
+			if field == nil {
+				return false
+			}
+		} else if this.peekIdent("reserved") ||
+			this.peekIdent("extensions") {
+			this.next()
+			if token.Text == "extensions" {
+				message.Unsupported = append(message.Unsupported,
+					&ProtoSchemaUnsupported{"extensions", "",
+						token.LineNumber, token.Column})
+			}
+			if this.skipStatement() == false {
+				return false
+			}
+		} else if this.peekIdent("message") {
+			nested := this.parseMessage()
+			if nested == nil {
+				return false
+			}
+			message.Messages = append(message.Messages, nested)
+		} else if this.peekIdent("enum") {
+			enum := this.parseEnum()
+			if enum == nil {
+				return false
+			}
+			message.Enums = append(message.Enums, enum)
+		} else if this.peekIdent("extend") {
+			unsupported := this.parseUnsupportedBlock()
+			if unsupported == nil {
+				return false
+			}
+			message.Unsupported = append(message.Unsupported, unsupported)
+		} else if this.peekIdent("oneof") {
+			this.next()
+			nameToken, ok := this.expectIdent()
+			if ok == false {
+				return false
+			}
+			if this.parseMessageBody(message, nameToken.Text) == false {
+				return false
+			}
+		} else {
+			label := ""
+			if this.peekIdent("optional") || this.peekIdent("required") ||
+				this.peekIdent("repeated") {
+				label = this.next().Text
+			}
+			if this.parseField(message, label, "") == nil {
+				return false
+			}
+		}
+	}
+	this.next()
+
+	return true
+}
+
+// `type name = number [options];`, map and group fields included,
+// field is appended to message
+func (this *ProtoSchemaParser) parseField(message *ProtoSchemaMessage,
+	label string, oneofName string) *ProtoSchemaField {
+
+	field := &ProtoSchemaField{
+		Label:     label,
+		OneofName: oneofName,
+	}
+
+	if this.peekIdent("map") &&
+		this.tokens[this.pos+1].Type == protoSchemaTokenType_Symbol &&
+		this.tokens[this.pos+1].Text == "<" {
+		this.next()
+		this.next()
+		keyType, ok := this.expectFullIdent()
+		if ok == false {
+			return nil
+		}
+		if this.expectSymbol(",") == false {
+			return nil
+		}
+		valueType, ok := this.expectFullIdent()
+		if ok == false {
+			return nil
+		}
+		if this.expectSymbol(">") == false {
+			return nil
+		}
+		field.MapKeyType = keyType
+		field.Type = valueType
+	} else if this.peekIdent("group") {
+		this.next()
+		field.Type = "group"
+	} else {
+		fieldType, ok := this.expectFullIdent()
+		if ok == false {
+			return nil
+		}
+		field.Type = fieldType
+	}
+
+	nameToken, ok := this.expectIdent()
+	if ok == false {
+		return nil
+	}
+	field.Name = nameToken.Text
+	field.LineNumber = nameToken.LineNumber
+	field.Column = nameToken.Column
+	if this.expectSymbol("=") == false {
+		return nil
+	}
+	field.Number, ok = this.expectInt()
+	if ok == false {
+		return nil
+	}
+	options, ok := this.parseFieldOptions()
+	if ok == false {
+		return nil
+	}
+	if _, ok := options["default"]; ok {
+		field.HasDefault = true
+	}
+
+	if field.Type == "group" {
+		// group body is a nested message, only skipped
+		if this.skipBlock() == false {
+			return nil
+		}
+	} else if this.expectSymbol(";") == false {
+		return nil
+	}
+	message.Fields = append(message.Fields, field)
+
+	return field
+}
@@ -1,5 +1,9 @@
 package main
 
+import (
+	"strings"
+)
+
 type ProtocolDescriptor struct {
 	ProtoDef *ProtocolDef
 
@@ -29,6 +33,37 @@ func (this *ProtocolDescriptor) Close() {
 	}
 }
 
+// name is `Struct` defined in main protocol or `proto.Struct`
+func (this *ProtocolDescriptor) FindStructDef(name string) *StructDef {
+	protoDef, defName := this.splitDefName(name)
+	if protoDef == nil {
+		return nil
+	}
+
+	return protoDef.StructNameIndex[defName]
+}
+
+// name is `EnumMap` defined in main protocol or `proto.EnumMap`
+func (this *ProtocolDescriptor) FindEnumMapDef(name string) *EnumMapDef {
+	protoDef, defName := this.splitDefName(name)
+	if protoDef == nil {
+		return nil
+	}
+
+	return protoDef.EnumMapNameIndex[defName]
+}
+
+func (this *ProtocolDescriptor) splitDefName(
+	name string) (*ProtocolDef, string) {
+
+	index := strings.LastIndex(name, ".")
+	if index < 0 {
+		return this.ProtoDef, name
+	}
+
+	return this.ImportedProtos[name[:index]], name[index+1:]
+}
+
 // ----------------------------------------------------------------------------
 type ProtocolDef struct {
 	Name     string
@@ -313,6 +348,52 @@ func StructFieldTypeIsInteger(t StructFieldType) bool {
 	return t >= StructFieldType_I8 && t <= StructFieldType_U64V
 }
 
+func StructFieldTypeGetName(t StructFieldType) string {
+	if t == StructFieldType_I8 {
+		return "i8"
+	} else if t == StructFieldType_U8 {
+		return "u8"
+	} else if t == StructFieldType_I16 {
+		return "i16"
+	} else if t == StructFieldType_U16 {
+		return "u16"
+	} else if t == StructFieldType_I32 {
+		return "i32"
+	} else if t == StructFieldType_U32 {
+		return "u32"
+	} else if t == StructFieldType_I64 {
+		return "i64"
+	} else if t == StructFieldType_U64 {
+		return "u64"
+	} else if t == StructFieldType_I16V {
+		return "i16v"
+	} else if t == StructFieldType_U16V {
+		return "u16v"
+	} else if t == StructFieldType_I32V {
+		return "i32v"
+	} else if t == StructFieldType_U32V {
+		return "u32v"
+	} else if t == StructFieldType_I64V {
+		return "i64v"
+	} else if t == StructFieldType_U64V {
+		return "u64v"
+	} else if t == StructFieldType_String {
+		return "string"
+	} else if t == StructFieldType_Bytes {
+		return "bytes"
+	} else if t == StructFieldType_Bool {
+		return "bool"
+	} else if t == StructFieldType_Enum {
+		return "enum"
+	} else if t == StructFieldType_Struct {
+		return "struct"
+	} else if t == StructFieldType_List {
+		return "list"
+	} else {
+		return ""
+	}
+}
+
 // ----------------------------------------------------------------------------
 type StructFieldDef struct {
 	// link to parent define
@@ -347,6 +428,33 @@ func (this *StructFieldDef) Close() {
 	this.ParentRef = nil
 }
 
+// type name as written in protocol file with protocol qualified
+// enum and struct name, e.g. `list{attr.Attr}`
+func StructFieldDefGetTypeName(fieldDef *StructFieldDef) string {
+	var itemType StructFieldType
+	if fieldDef.Type == StructFieldType_List {
+		itemType = fieldDef.ListType
+	} else {
+		itemType = fieldDef.Type
+	}
+
+	var itemTypeName string
+	if itemType == StructFieldType_Enum {
+		itemTypeName = fieldDef.RefEnumDef.ParentRef.Name + "." +
+			fieldDef.RefEnumDef.Name
+	} else if itemType == StructFieldType_Struct {
+		itemTypeName = StructDefGetQualifiedName(fieldDef.RefStructDef)
+	} else {
+		itemTypeName = StructFieldTypeGetName(itemType)
+	}
+
+	if fieldDef.Type == StructFieldType_List {
+		return "list{" + itemTypeName + "}"
+	} else {
+		return itemTypeName
+	}
+}
+
 // ----------------------------------------------------------------------------
 type StructDef struct {
 	// link to parent define
@@ -393,6 +501,11 @@ func (this *StructDef) Close() {
 	this.ParentRef = nil
 }
 
+// protocol qualified name, e.g. `attr.Attr`
+func StructDefGetQualifiedName(structDef *StructDef) string {
+	return structDef.ParentRef.Name + "." + structDef.Name
+}
+
 // ----------------------------------------------------------------------------
 type EnumMapItemType int
 
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// a node of protocol file with the comments belong to it
+type formatEntry struct {
+	Node *xmlquery.Node
+	// comments in lines above the node
+	LeadingComments []*xmlquery.Node
+	// comment in the same line after the node
+	TrailingComment *xmlquery.Node
+	// source has blank lines before the node or its comments
+	HasBlankLineBefore bool
+}
+
+// rewrite protocol file into canonical layout
+//   - namespace, import, enum, struct and enum_map nodes in this order,
+//     same kind of nodes keep their order in file
+//   - one blank line between sections and definitions, blank lines
+//     inside a definition are kept as one
+//   - attributes in fixed order and aligned in columns, blank lines
+//     split the columns
+//   - comments stay with the node below them, or the node before them
+//     in the same line
+type ProtocolFormatter struct {
+	filePath   string
+	newLineStr string
+	sb         strings.Builder
+}
+
+func NewProtocolFormatter() *ProtocolFormatter {
+	newObj := new(ProtocolFormatter)
+
+	return newObj
+}
+
+// return formatted text, ok is false if file is not a valid protocol
+func (this *ProtocolFormatter) Format(
+	filePath string, text string) (string, bool) {
+
+	this.filePath = filePath
+	this.sb.Reset()
+	if strings.Contains(text, "\r\n") {
+		this.newLineStr = "\r\n"
+	} else {
+		this.newLineStr = "\n"
+	}
+
+	xmlDoc, err := xmlquery.ParseWithOptions(strings.NewReader(text),
+		xmlquery.ParserOptions{
+			WithLineNumbers: true,
+		})
+	if err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: can not parse protocol file `%s`: %s\n",
+			filePath, err.Error())
+		return "", false
+	}
+
+	hasRootNode := false
+	for _, node := range xmlDoc.ChildNodes() {
+		if node.Type == xmlquery.DeclarationNode {
+			// parser adds a declaration if file has none
+			if strings.Contains(text, "<?"+node.Data) == false {
+				continue
+			}
+			this.writeLine(0, "<?"+node.Data+
+				this.getAttrsText(node, nil)+"?>")
+		} else if node.Type == xmlquery.CommentNode {
+			this.writeLine(0, this.getCommentText(node))
+		} else if node.Type == xmlquery.ElementNode {
+			if hasRootNode || node.Data != "protocol" {
+				this.printNodeError(node,
+					"root node must be `protocol` node")
+				return "", false
+			}
+			hasRootNode = true
+			if this.writeProtocol(node) == false {
+				return "", false
+			}
+		}
+	}
+	if hasRootNode == false {
+		fmt.Fprintf(os.Stderr,
+			"error:%s: root node must be `protocol` node\n",
+			filePath)
+		return "", false
+	}
+
+	return this.sb.String(), true
+}
+
+func (this *ProtocolFormatter) printNodeError(
+	node *xmlquery.Node, format string, args ...any) {
+
+	fmt.Fprintf(os.Stderr,
+		"error:%s:%d: %s\n",
+		this.filePath, node.LineNumber,
+		fmt.Sprintf(format, args...))
+}
+
+func (this *ProtocolFormatter) writeLine(indent int, line string) {
+	this.sb.WriteString(strings.Repeat("  ", indent))
+	this.sb.WriteString(line)
+	this.sb.WriteString(this.newLineStr)
+}
+
+func (this *ProtocolFormatter) writeEmptyLine() {
+	this.sb.WriteString(this.newLineStr)
+}
+
+// split child nodes into entries, return nil if node contains text
+func (this *ProtocolFormatter) getEntries(
+	node *xmlquery.Node) []*formatEntry {
+
+	entries := make([]*formatEntry, 0)
+	pendingComments := make([]*xmlquery.Node, 0)
+	var lastEntry *formatEntry = nil
+	newLineCount := 0
+	hasBlankLine := false
+
+	for _, child := range node.ChildNodes() {
+		if child.Type == xmlquery.TextNode ||
+			child.Type == xmlquery.CharDataNode {
+			if strings.TrimSpace(child.Data) != "" {
+				this.printNodeError(child,
+					"unexpected text in `%s` node", node.Data)
+				return nil
+			}
+			count := strings.Count(child.Data, "\n")
+			newLineCount += count
+			if count >= 2 {
+				hasBlankLine = true
+			}
+		} else if child.Type == xmlquery.CommentNode {
+			if lastEntry != nil && newLineCount == 0 &&
+				lastEntry.TrailingComment == nil &&
+				len(pendingComments) == 0 {
+				lastEntry.TrailingComment = child
+			} else {
+				pendingComments = append(pendingComments, child)
+			}
+			newLineCount = 0
+		} else if child.Type == xmlquery.ElementNode {
+			entry := new(formatEntry)
+			entry.Node = child
+			entry.LeadingComments = pendingComments
+			entry.HasBlankLineBefore = hasBlankLine
+			entries = append(entries, entry)
+
+			pendingComments = make([]*xmlquery.Node, 0)
+			lastEntry = entry
+			newLineCount = 0
+			hasBlankLine = false
+		}
+	}
+
+	// comments after the last node
+	if len(pendingComments) > 0 {
+		entry := new(formatEntry)
+		entry.LeadingComments = pendingComments
+		entry.HasBlankLineBefore = hasBlankLine
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func (this *ProtocolFormatter) writeProtocol(node *xmlquery.Node) bool {
+	entries := this.getEntries(node)
+	if entries == nil {
+		return false
+	}
+
+	// group by node kind
+	sectionNames := []string{
+		"namespace", "import", "enum", "struct", "enum_map"}
+	sections := make(map[string][]*formatEntry)
+	footerEntries := make([]*formatEntry, 0)
+	for _, entry := range entries {
+		if entry.Node == nil {
+			footerEntries = append(footerEntries, entry)
+			continue
+		}
+		if _, ok := sections[entry.Node.Data]; ok == false {
+			isValid := false
+			for _, name := range sectionNames {
+				if entry.Node.Data == name {
+					isValid = true
+					break
+				}
+			}
+			if isValid == false {
+				this.printNodeError(entry.Node,
+					"unexpected `%s` node", entry.Node.Data)
+				return false
+			}
+		}
+		sections[entry.Node.Data] = append(
+			sections[entry.Node.Data], entry)
+	}
+
+	this.writeLine(0, "<protocol"+this.getAttrsText(node, nil)+">")
+	for _, name := range sectionNames {
+		sectionEntries := sections[name]
+		if len(sectionEntries) == 0 {
+			continue
+		}
+
+		if name == "namespace" || name == "import" {
+			// one line each, no blank line between
+			this.writeEmptyLine()
+			for _, entry := range sectionEntries {
+				if this.writeSingleLineNode(entry) == false {
+					return false
+				}
+			}
+		} else {
+			for _, entry := range sectionEntries {
+				this.writeEmptyLine()
+				if this.writeDefinition(entry) == false {
+					return false
+				}
+			}
+		}
+	}
+	for _, entry := range footerEntries {
+		this.writeEmptyLine()
+		for _, comment := range entry.LeadingComments {
+			this.writeLine(0, this.getCommentText(comment))
+		}
+	}
+	if len(entries) > 0 {
+		this.writeEmptyLine()
+	}
+	this.writeLine(0, "</protocol>")
+
+	return true
+}
+
+// namespace and import node, e.g. <import>attr.xml</import>
+func (this *ProtocolFormatter) writeSingleLineNode(entry *formatEntry) bool {
+	node := entry.Node
+	for _, child := range node.ChildNodes() {
+		if child.Type == xmlquery.ElementNode {
+			this.printNodeError(child,
+				"unexpected `%s` node", child.Data)
+			return false
+		}
+	}
+
+	for _, comment := range entry.LeadingComments {
+		this.writeLine(0, this.getCommentText(comment))
+	}
+	line := "<" + node.Data +
+		this.getAttrsText(node, []string{"lang"}) + ">" +
+		this.escapeText(strings.TrimSpace(node.InnerText())) +
+		"</" + node.Data + ">"
+	if entry.TrailingComment != nil {
+		line += " " + this.getCommentText(entry.TrailingComment)
+	}
+	this.writeLine(0, line)
+
+	return true
+}
+
+// enum, struct or enum_map node with item or field nodes
+func (this *ProtocolFormatter) writeDefinition(entry *formatEntry) bool {
+	node := entry.Node
+
+	var childNames []string
+	var attrOrder []string
+	if node.Data == "struct" {
+		childNames = []string{"required", "optional"}
+		attrOrder = []string{"name", "type"}
+	} else if node.Data == "enum" {
+		childNames = []string{"item"}
+		attrOrder = []string{"name", "value"}
+	} else {
+		childNames = []string{"item"}
+		attrOrder = []string{"name", "value", "struct"}
+	}
+
+	entries := this.getEntries(node)
+	if entries == nil {
+		return false
+	}
+	for _, childEntry := range entries {
+		if childEntry.Node == nil {
+			continue
+		}
+		isValid := false
+		for _, name := range childNames {
+			if childEntry.Node.Data == name {
+				isValid = true
+				break
+			}
+		}
+		if isValid == false {
+			this.printNodeError(childEntry.Node,
+				"unexpected `%s` node", childEntry.Node.Data)
+			return false
+		}
+		for _, child := range childEntry.Node.ChildNodes() {
+			if child.Type != xmlquery.TextNode ||
+				strings.TrimSpace(child.Data) != "" {
+				this.printNodeError(childEntry.Node,
+					"`%s` node can not have content",
+					childEntry.Node.Data)
+				return false
+			}
+		}
+	}
+
+	for _, comment := range entry.LeadingComments {
+		this.writeLine(0, this.getCommentText(comment))
+	}
+	line := "<" + node.Data + this.getAttrsText(node, []string{"name"}) + ">"
+	if entry.TrailingComment != nil {
+		line += " " + this.getCommentText(entry.TrailingComment)
+	}
+	this.writeLine(0, line)
+
+	// columns are aligned in each group split by blank lines
+	groupStart := 0
+	for i := 0; i <= len(entries); i++ {
+		if i < len(entries) &&
+			(i == groupStart || entries[i].HasBlankLineBefore == false) {
+			continue
+		}
+		if groupStart > 0 {
+			this.writeEmptyLine()
+		}
+		this.writeAlignedEntries(entries[groupStart:i], attrOrder)
+		groupStart = i
+	}
+
+	this.writeLine(0, "</"+node.Data+">")
+
+	return true
+}
+
+func (this *ProtocolFormatter) writeAlignedEntries(
+	entries []*formatEntry, attrOrder []string) {
+
+	// known attributes first, then the others in order of appearance
+	columns := make([]string, 0)
+	columns = append(columns, attrOrder...)
+	tagWidth := 0
+	for _, entry := range entries {
+		if entry.Node == nil {
+			continue
+		}
+		tagWidth = max(tagWidth, len(entry.Node.Data))
+		for _, attr := range entry.Node.Attr {
+			isKnown := false
+			for _, name := range columns {
+				if attr.Name.Local == name {
+					isKnown = true
+					break
+				}
+			}
+			if isKnown == false {
+				columns = append(columns, attr.Name.Local)
+			}
+		}
+	}
+
+	columnWidths := make([]int, len(columns))
+	for _, entry := range entries {
+		if entry.Node == nil {
+			continue
+		}
+		for i, name := range columns {
+			if attrText := this.getAttrText(entry.Node, name); attrText != "" {
+				columnWidths[i] = max(columnWidths[i], len(attrText))
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		for _, comment := range entry.LeadingComments {
+			this.writeLine(1, this.getCommentText(comment))
+		}
+		if entry.Node == nil {
+			continue
+		}
+
+		var sb strings.Builder
+		sb.WriteString("<" + entry.Node.Data)
+		sb.WriteString(strings.Repeat(" ", tagWidth-len(entry.Node.Data)))
+		for i, name := range columns {
+			if columnWidths[i] == 0 {
+				continue
+			}
+			attrText := this.getAttrText(entry.Node, name)
+			sb.WriteString(" " + attrText)
+			sb.WriteString(strings.Repeat(" ", columnWidths[i]-len(attrText)))
+		}
+		line := strings.TrimRight(sb.String(), " ") + "/>"
+		if entry.TrailingComment != nil {
+			line += " " + this.getCommentText(entry.TrailingComment)
+		}
+		this.writeLine(1, line)
+	}
+}
+
+// e.g. name="value", empty string if node has no such attribute
+func (this *ProtocolFormatter) getAttrText(
+	node *xmlquery.Node, attrName string) string {
+
+	for _, attr := range node.Attr {
+		if attr.Name.Local == attrName {
+			return attr.Name.Local + "=\"" +
+				this.escapeAttr(attr.Value) + "\""
+		}
+	}
+
+	return ""
+}
+
+// attributes in attrOrder first, then the others in file order,
+// each prefixed by a space
+func (this *ProtocolFormatter) getAttrsText(
+	node *xmlquery.Node, attrOrder []string) string {
+
+	var sb strings.Builder
+	for _, name := range attrOrder {
+		if attrText := this.getAttrText(node, name); attrText != "" {
+			sb.WriteString(" " + attrText)
+		}
+	}
+	for _, attr := range node.Attr {
+		isOrdered := false
+		for _, name := range attrOrder {
+			if attr.Name.Local == name {
+				isOrdered = true
+				break
+			}
+		}
+		if isOrdered == false {
+			sb.WriteString(" " + this.getAttrText(node, attr.Name.Local))
+		}
+	}
+
+	return sb.String()
+}
+
+func (this *ProtocolFormatter) getCommentText(node *xmlquery.Node) string {
+	return "<!--" + node.Data + "-->"
+}
+
+func (this *ProtocolFormatter) escapeText(text string) string {
+	text = strings.ReplaceAll(text, "&", "&amp;")
+	text = strings.ReplaceAll(text, "<", "&lt;")
+	text = strings.ReplaceAll(text, ">", "&gt;")
+
+	return text
+}
+
+func (this *ProtocolFormatter) escapeAttr(text string) string {
+	return strings.ReplaceAll(this.escapeText(text), "\"", "&quot;")
+}
@@ -0,0 +1,568 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// rule names are also the diagnostic codes, never rename
+const (
+	LintRule_Naming                   = "naming"
+	LintRule_UnusedDefinition         = "unused-definition"
+	LintRule_EnumMapGap               = "enum-map-gap"
+	LintRule_EnumMapItemWithoutStruct = "enum-map-item-without-struct"
+	LintRule_KeywordName              = "keyword-name"
+	LintRule_OversizedStruct          = "oversized-struct"
+	LintRule_MissingDoc               = "missing-doc"
+)
+
+// rule name -> default severity
+var g_lintRuleDefaultSeverities map[string]string = map[string]string{
+	LintRule_Naming:                   "warning",
+	LintRule_UnusedDefinition:         "warning",
+	LintRule_EnumMapGap:               "warning",
+	LintRule_EnumMapItemWithoutStruct: "warning",
+	LintRule_KeywordName:              "warning",
+	LintRule_OversizedStruct:          "warning",
+	LintRule_MissingDoc:               "off",
+}
+
+// node type -> default name pattern
+var g_lintDefaultNamingPatterns map[string]string = map[string]string{
+	"enum":          `^[A-Z][a-zA-Z0-9]*$`,
+	"enum_item":     `^[A-Z][A-Z0-9_]*$`,
+	"struct":        `^[A-Z][a-zA-Z0-9]*$`,
+	"field":         `^[a-z][a-z0-9_]*$`,
+	"enum_map":      `^[A-Z][a-zA-Z0-9]*$`,
+	"enum_map_item": `^[A-Z][A-Z0-9_]*$`,
+}
+
+var g_lintCppKeywords map[string]bool = lintMakeStringSet(strings.Fields(`
+	alignas alignof and and_eq asm auto bitand bitor bool break case catch
+	char char8_t char16_t char32_t class compl concept const consteval
+	constexpr constinit const_cast continue co_await co_return co_yield
+	decltype default delete do double dynamic_cast else enum explicit
+	export extern false float for friend goto if inline int long mutable
+	namespace new noexcept not not_eq nullptr operator or or_eq private
+	protected public register reinterpret_cast requires return short
+	signed sizeof static static_assert static_cast struct switch template
+	this thread_local throw true try typedef typeid typename union
+	unsigned using virtual void volatile wchar_t while xor xor_eq`))
+
+var g_lintCSharpKeywords map[string]bool = lintMakeStringSet(strings.Fields(`
+	abstract as base bool break byte case catch char checked class const
+	continue decimal default delegate do double else enum event explicit
+	extern false finally fixed float for foreach goto if implicit in int
+	interface internal is lock long namespace new null object operator
+	out override params private protected public readonly ref return
+	sbyte sealed short sizeof stackalloc static string struct switch this
+	throw true try typeof uint ulong unchecked unsafe ushort using virtual
+	void volatile while`))
+
+// lower case, php keywords are case insensitive, only checked on class
+// names since properties and constants can use most of them
+var g_lintPhpKeywords map[string]bool = lintMakeStringSet(strings.Fields(`
+	abstract and array as bool break callable case catch class clone const
+	continue declare default do echo else elseif empty enddeclare endfor
+	endforeach endif endswitch endwhile enum eval exit extends false final
+	finally float fn for foreach function global goto if implements
+	include include_once instanceof insteadof int interface isset iterable
+	list match mixed namespace never new null object or print private
+	protected public readonly require require_once return static string
+	switch throw trait true try unset use var void while xor yield`))
+
+func lintMakeStringSet(items []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range items {
+		set[item] = true
+	}
+
+	return set
+}
+
+// ----------------------------------------------------------------------------
+// `lint` section of project file
+//
+//	"lint": {
+//	    "rules": { "missing-doc": "warning", "enum-map-gap": "off" },
+//	    "naming": { "field": "^[a-z][a-zA-Z0-9]*$" },
+//	    "max_struct_fields": 64,
+//	    "enum_map_items_without_struct": "^(MIN|MAX)$"
+//	}
+type LintConfig struct {
+	// rule name -> off, warning or error
+	Rules map[string]string `json:"rules"`
+	// node type -> name pattern, node types are enum, enum_item,
+	// struct, field, enum_map and enum_map_item
+	Naming          map[string]string `json:"naming"`
+	MaxStructFields int               `json:"max_struct_fields"`
+	// enum_map items matching it are allowed to have no struct
+	EnumMapItemsWithoutStruct string `json:"enum_map_items_without_struct"`
+}
+
+func NewLintConfig() *LintConfig {
+	newObj := new(LintConfig)
+	newObj.Rules = make(map[string]string)
+	for name, severity := range g_lintRuleDefaultSeverities {
+		newObj.Rules[name] = severity
+	}
+	newObj.Naming = make(map[string]string)
+	for nodeType, pattern := range g_lintDefaultNamingPatterns {
+		newObj.Naming[nodeType] = pattern
+	}
+	newObj.MaxStructFields = 64
+	newObj.EnumMapItemsWithoutStruct = `^(MIN|MAX)$`
+
+	return newObj
+}
+
+// print errors and return false if config is invalid
+func (this *LintConfig) Check() bool {
+	for name, severity := range this.Rules {
+		if _, ok := g_lintRuleDefaultSeverities[name]; ok == false {
+			fmt.Fprintf(os.Stderr,
+				"error: lint rule `%s` is invalid\n", name)
+			return false
+		}
+		if severity != "off" &&
+			severity != "warning" &&
+			severity != "error" {
+			fmt.Fprintf(os.Stderr,
+				"error: severity `%s` of lint rule `%s` is invalid\n",
+				severity, name)
+			return false
+		}
+	}
+	for nodeType, pattern := range this.Naming {
+		if _, ok := g_lintDefaultNamingPatterns[nodeType]; ok == false {
+			fmt.Fprintf(os.Stderr,
+				"error: naming node type `%s` is invalid\n", nodeType)
+			return false
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			fmt.Fprintf(os.Stderr,
+				"error: naming pattern `%s` is invalid: %s\n",
+				pattern, err.Error())
+			return false
+		}
+	}
+	if this.MaxStructFields <= 0 {
+		fmt.Fprintf(os.Stderr,
+			"error: max_struct_fields `%d` is invalid\n",
+			this.MaxStructFields)
+		return false
+	}
+	if _, err := regexp.Compile(this.EnumMapItemsWithoutStruct); err != nil {
+		fmt.Fprintf(os.Stderr,
+			"error: enum_map_items_without_struct `%s` is invalid: %s\n",
+			this.EnumMapItemsWithoutStruct, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// ----------------------------------------------------------------------------
+// comments found in a protocol file
+type lintFileAnnotations struct {
+	// node line number -> rules ignored by `brexc:lint-ignore` comment,
+	// `*` for all rules
+	ignoredRules map[int][]string
+	// line numbers of nodes with a doc comment
+	documentedLines map[int]bool
+}
+
+// check protocols against lint rules, a node is skipped by a comment
+// above it or after it in the same line, which also covers its children
+//
+//	<!-- brexc:lint-ignore naming,keyword-name -->
+type ProtocolLinter struct {
+	Diagnostics *DiagnosticList
+
+	config        *LintConfig
+	parser        *ProtocolParser
+	namingRegexps map[string]*regexp.Regexp
+	// file full path -> annotations
+	annotations map[string]*lintFileAnnotations
+}
+
+// config must be checked
+func NewProtocolLinter(config *LintConfig) *ProtocolLinter {
+	newObj := new(ProtocolLinter)
+	newObj.Diagnostics = NewDiagnosticList()
+	newObj.config = config
+	newObj.namingRegexps = make(map[string]*regexp.Regexp)
+	for nodeType, pattern := range config.Naming {
+		newObj.namingRegexps[nodeType] = regexp.MustCompile(pattern)
+	}
+	newObj.annotations = make(map[string]*lintFileAnnotations)
+
+	return newObj
+}
+
+// lint protoDefs, definitions of all protocols in parser are
+// taken into account to find unused ones
+func (this *ProtocolLinter) Lint(
+	parser *ProtocolParser, protoDefs []*ProtocolDef) {
+
+	this.parser = parser
+
+	for _, protoDef := range protoDefs {
+		this.checkNaming(protoDef)
+		this.checkKeywordName(protoDef)
+		this.checkEnumMaps(protoDef)
+		this.checkOversizedStruct(protoDef)
+		this.checkMissingDoc(protoDef)
+	}
+	this.checkUnusedDefinition(protoDefs)
+
+	this.parser = nil
+}
+
+func (this *ProtocolLinter) report(
+	filePath string, lineNumber int, tagName string,
+	rule string, format string, args ...any) {
+
+	severity := this.config.Rules[rule]
+	if severity == "off" {
+		return
+	}
+	if this.isRuleIgnored(filePath, lineNumber, rule) {
+		return
+	}
+
+	lineNumber, column := this.parser.getTagPosition(
+		filePath, lineNumber, tagName)
+	if severity == "error" {
+		this.Diagnostics.Add(filePath, lineNumber, column,
+			DiagnosticSeverity_Error, rule, format, args...)
+	} else {
+		this.Diagnostics.Add(filePath, lineNumber, column,
+			DiagnosticSeverity_Warning, rule, format, args...)
+	}
+}
+
+func (this *ProtocolLinter) isRuleIgnored(
+	filePath string, lineNumber int, rule string) bool {
+
+	rules := this.getAnnotations(filePath).ignoredRules[lineNumber]
+	for _, name := range rules {
+		if name == rule || name == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *ProtocolLinter) getAnnotations(
+	filePath string) *lintFileAnnotations {
+
+	if annotations, ok := this.annotations[filePath]; ok {
+		return annotations
+	}
+
+	annotations := new(lintFileAnnotations)
+	annotations.ignoredRules = make(map[int][]string)
+	annotations.documentedLines = make(map[int]bool)
+	this.annotations[filePath] = annotations
+
+	// file is already parsed, comments of both xml and text syntax
+	// are kept in the document
+	if doc, ok := this.parser.fileDocs[filePath]; ok {
+		this.collectAnnotations(doc, annotations, nil)
+	}
+
+	return annotations
+}
+
+// rules ignored by parent node are also ignored by its children
+func (this *ProtocolLinter) collectAnnotations(
+	node *xmlquery.Node, annotations *lintFileAnnotations,
+	parentIgnoredRules []string) {
+
+	pendingComments := make([]*xmlquery.Node, 0)
+	var lastElement *xmlquery.Node = nil
+	hasNewLine := true
+
+	addComment := func(element *xmlquery.Node, comment *xmlquery.Node) {
+		text := strings.TrimSpace(comment.Data)
+		if rest, ok := strings.CutPrefix(text, "brexc:lint-ignore"); ok {
+			rules := make([]string, 0)
+			for _, name := range strings.Split(rest, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					rules = append(rules, name)
+				}
+			}
+			if len(rules) == 0 {
+				rules = append(rules, "*")
+			}
+			annotations.ignoredRules[element.LineNumber] = append(
+				annotations.ignoredRules[element.LineNumber], rules...)
+		} else {
+			annotations.documentedLines[element.LineNumber] = true
+		}
+	}
+
+	elements := make([]*xmlquery.Node, 0)
+	for _, child := range node.ChildNodes() {
+		if child.Type == xmlquery.TextNode {
+			if strings.Contains(child.Data, "\n") {
+				hasNewLine = true
+			}
+		} else if child.Type == xmlquery.CommentNode {
+			if lastElement != nil && hasNewLine == false {
+				addComment(lastElement, child)
+			} else {
+				pendingComments = append(pendingComments, child)
+			}
+		} else if child.Type == xmlquery.ElementNode {
+			if parentIgnoredRules != nil {
+				annotations.ignoredRules[child.LineNumber] = append(
+					annotations.ignoredRules[child.LineNumber],
+					parentIgnoredRules...)
+			}
+			for _, comment := range pendingComments {
+				addComment(child, comment)
+			}
+			pendingComments = pendingComments[:0]
+			lastElement = child
+			hasNewLine = false
+			elements = append(elements, child)
+		}
+	}
+
+	for _, element := range elements {
+		this.collectAnnotations(element, annotations,
+			annotations.ignoredRules[element.LineNumber])
+	}
+}
+
+// ----------------------------------------------------------------------------
+func (this *ProtocolLinter) checkName(filePath string, lineNumber int,
+	tagName string, nodeType string, name string) {
+
+	re := this.namingRegexps[nodeType]
+	if re == nil || re.MatchString(name) {
+		return
+	}
+	this.report(filePath, lineNumber, tagName, LintRule_Naming,
+		"%s name `%s` does not match `%s`", nodeType, name, re.String())
+}
+
+func (this *ProtocolLinter) checkNaming(protoDef *ProtocolDef) {
+	filePath := protoDef.FilePath
+
+	for _, enumDef := range protoDef.Enums {
+		this.checkName(filePath, enumDef.LineNumber,
+			"enum", "enum", enumDef.Name)
+		for _, def := range enumDef.Items {
+			this.checkName(filePath, def.LineNumber,
+				"item", "enum_item", def.Name)
+		}
+	}
+	for _, structDef := range protoDef.Structs {
+		this.checkName(filePath, structDef.LineNumber,
+			"struct", "struct", structDef.Name)
+		for _, def := range structDef.Fields {
+			this.checkName(filePath, def.LineNumber,
+				lintGetFieldTagName(def), "field", def.Name)
+		}
+	}
+	for _, enumMapDef := range protoDef.EnumMaps {
+		this.checkName(filePath, enumMapDef.LineNumber,
+			"enum_map", "enum_map", enumMapDef.Name)
+		for _, def := range enumMapDef.Items {
+			this.checkName(filePath, def.LineNumber,
+				"item", "enum_map_item", def.Name)
+		}
+	}
+}
+
+// class names are checked against keywords of all languages,
+// member names against c++ and c# only
+func (this *ProtocolLinter) checkKeyword(filePath string, lineNumber int,
+	tagName string, name string, isClassName bool) {
+
+	languages := make([]string, 0)
+	if g_lintCppKeywords[name] {
+		languages = append(languages, "cpp")
+	}
+	if g_lintCSharpKeywords[name] {
+		languages = append(languages, "csharp")
+	}
+	if isClassName && g_lintPhpKeywords[strings.ToLower(name)] {
+		languages = append(languages, "php")
+	}
+	if len(languages) == 0 {
+		return
+	}
+	this.report(filePath, lineNumber, tagName, LintRule_KeywordName,
+		"name `%s` is a keyword of %s",
+		name, strings.Join(languages, ", "))
+}
+
+func (this *ProtocolLinter) checkKeywordName(protoDef *ProtocolDef) {
+	filePath := protoDef.FilePath
+
+	for _, enumDef := range protoDef.Enums {
+		this.checkKeyword(filePath, enumDef.LineNumber,
+			"enum", enumDef.Name, true)
+		for _, def := range enumDef.Items {
+			this.checkKeyword(filePath, def.LineNumber,
+				"item", def.Name, false)
+		}
+	}
+	for _, structDef := range protoDef.Structs {
+		this.checkKeyword(filePath, structDef.LineNumber,
+			"struct", structDef.Name, true)
+		for _, def := range structDef.Fields {
+			this.checkKeyword(filePath, def.LineNumber,
+				lintGetFieldTagName(def), def.Name, false)
+		}
+	}
+	for _, enumMapDef := range protoDef.EnumMaps {
+		this.checkKeyword(filePath, enumMapDef.LineNumber,
+			"enum_map", enumMapDef.Name, true)
+		for _, def := range enumMapDef.Items {
+			this.checkKeyword(filePath, def.LineNumber,
+				"item", def.Name, false)
+		}
+	}
+}
+
+func (this *ProtocolLinter) checkEnumMaps(protoDef *ProtocolDef) {
+	filePath := protoDef.FilePath
+	itemsWithoutStruct := regexp.MustCompile(
+		this.config.EnumMapItemsWithoutStruct)
+
+	for _, enumMapDef := range protoDef.EnumMaps {
+		for i, def := range enumMapDef.Items {
+			if i > 0 {
+				prevDef := enumMapDef.Items[i-1]
+				if def.IntValue > prevDef.IntValue+1 {
+					this.report(filePath, def.LineNumber, "item",
+						LintRule_EnumMapGap,
+						"enum_map `%s` has a gap between `%s` (%d) "+
+							"and `%s` (%d)",
+						enumMapDef.Name, prevDef.Name, prevDef.IntValue,
+						def.Name, def.IntValue)
+				}
+			}
+			if def.RefStructDef == nil &&
+				itemsWithoutStruct.MatchString(def.Name) == false {
+				this.report(filePath, def.LineNumber, "item",
+					LintRule_EnumMapItemWithoutStruct,
+					"enum_map item `%s.%s` has no struct",
+					enumMapDef.Name, def.Name)
+			}
+		}
+	}
+}
+
+func (this *ProtocolLinter) checkOversizedStruct(protoDef *ProtocolDef) {
+	for _, structDef := range protoDef.Structs {
+		if len(structDef.Fields) > this.config.MaxStructFields {
+			this.report(protoDef.FilePath, structDef.LineNumber, "struct",
+				LintRule_OversizedStruct,
+				"struct `%s` has %d fields, more than %d",
+				structDef.Name, len(structDef.Fields),
+				this.config.MaxStructFields)
+		}
+	}
+}
+
+func (this *ProtocolLinter) checkMissingDoc(protoDef *ProtocolDef) {
+	if this.config.Rules[LintRule_MissingDoc] == "off" {
+		return
+	}
+	filePath := protoDef.FilePath
+	documentedLines := this.getAnnotations(filePath).documentedLines
+
+	for _, def := range protoDef.Enums {
+		if documentedLines[def.LineNumber] == false {
+			this.report(filePath, def.LineNumber, "enum",
+				LintRule_MissingDoc,
+				"enum `%s` has no doc comment", def.Name)
+		}
+	}
+	for _, def := range protoDef.Structs {
+		if documentedLines[def.LineNumber] == false {
+			this.report(filePath, def.LineNumber, "struct",
+				LintRule_MissingDoc,
+				"struct `%s` has no doc comment", def.Name)
+		}
+	}
+	for _, def := range protoDef.EnumMaps {
+		if documentedLines[def.LineNumber] == false {
+			this.report(filePath, def.LineNumber, "enum_map",
+				LintRule_MissingDoc,
+				"enum_map `%s` has no doc comment", def.Name)
+		}
+	}
+}
+
+// enums and structs not referenced by any protocol parsed, structs
+// mapped by enum_map are used by the application
+func (this *ProtocolLinter) checkUnusedDefinition(protoDefs []*ProtocolDef) {
+	usedEnums := make(map[*EnumDef]bool)
+	usedStructs := make(map[*StructDef]bool)
+
+	for _, protoDef := range this.parser.Descriptor.ImportedProtos {
+		for _, enumDef := range protoDef.Enums {
+			for _, def := range enumDef.Items {
+				if def.RefEnumItemDef != nil &&
+					def.RefEnumItemDef.ParentRef != enumDef {
+					usedEnums[def.RefEnumItemDef.ParentRef] = true
+				}
+			}
+		}
+		for _, structDef := range protoDef.Structs {
+			for _, def := range structDef.Fields {
+				if def.RefEnumDef != nil {
+					usedEnums[def.RefEnumDef] = true
+				}
+				if def.RefStructDef != nil &&
+					def.RefStructDef != structDef {
+					usedStructs[def.RefStructDef] = true
+				}
+			}
+		}
+		for _, enumMapDef := range protoDef.EnumMaps {
+			for _, def := range enumMapDef.Items {
+				if def.RefStructDef != nil {
+					usedStructs[def.RefStructDef] = true
+				}
+			}
+		}
+	}
+
+	for _, protoDef := range protoDefs {
+		for _, def := range protoDef.Enums {
+			if usedEnums[def] == false {
+				this.report(protoDef.FilePath, def.LineNumber, "enum",
+					LintRule_UnusedDefinition,
+					"enum `%s` is not used", def.Name)
+			}
+		}
+		for _, def := range protoDef.Structs {
+			if usedStructs[def] == false {
+				this.report(protoDef.FilePath, def.LineNumber, "struct",
+					LintRule_UnusedDefinition,
+					"struct `%s` is not used", def.Name)
+			}
+		}
+	}
+}
+
+func lintGetFieldTagName(fieldDef *StructFieldDef) string {
+	if fieldDef.IsOptional {
+		return "optional"
+	} else {
+		return "required"
+	}
+}
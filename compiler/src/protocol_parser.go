@@ -1,7 +1,8 @@
 package main
 
 import (
-	"fmt"
+	"encoding/xml"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,10 +12,30 @@ import (
 
 type ProtocolParser struct {
 	Descriptor *ProtocolDescriptor
+	// errors and warnings found by the last parse
+	Diagnostics *DiagnosticList
+	// format used to print diagnostics to stderr after parsing,
+	// see DiagnosticList.Print, empty to not print
+	DiagnosticsFormat string
+	// file full path -> text used instead of the file on disk,
+	// e.g. unsaved editor buffers
+	FileTexts map[string]string
+	// file full path -> lines, used to locate nodes
+	fileLines map[string][]string
+	// file full path -> document, comments are kept for lint annotations
+	fileDocs map[string]*xmlquery.Node
+	// file full path -> element node -> column, text syntax only
+	fileNodeColumns map[string]map[*xmlquery.Node]int
 }
 
 func NewProtocolParser() *ProtocolParser {
 	newObj := new(ProtocolParser)
+	newObj.Diagnostics = NewDiagnosticList()
+	newObj.DiagnosticsFormat = "text"
+	newObj.FileTexts = make(map[string]string)
+	newObj.fileLines = make(map[string][]string)
+	newObj.fileDocs = make(map[string]*xmlquery.Node)
+	newObj.fileNodeColumns = make(map[string]map[*xmlquery.Node]int)
 
 	return newObj
 }
@@ -29,10 +50,49 @@ func (this *ProtocolParser) Close() {
 func (this *ProtocolParser) Parse(
 	protoFilePath string, protoSearchPath []string) bool {
 
+	return this.ParseFiles([]string{protoFilePath}, protoSearchPath)
+}
+
+// parse several protocol files into one descriptor, imports shared
+// by them are parsed once. Descriptor.ProtoDef is the first one,
+// others can be found in Descriptor.ImportedProtos
+func (this *ProtocolParser) ParseFiles(
+	protoFilePaths []string, protoSearchPath []string) bool {
+
+	return this.ParseFilesReusing(protoFilePaths, protoSearchPath, nil)
+}
+
+// same as ParseFiles, but protocols in reusedProtos are taken as already
+// parsed instead of loading their files again. reusedProtos must be
+// closed under imports, and the descriptor they come from must not be
+// closed since they are owned by the new descriptor now.
+// parsing goes on after an error to collect as many diagnostics as
+// possible, the descriptor may be incomplete if false is returned
+func (this *ProtocolParser) ParseFilesReusing(
+	protoFilePaths []string, protoSearchPath []string,
+	reusedProtos map[string]*ProtocolDef) bool {
+
 	this.Descriptor = NewProtocolDescriptor()
-	this.Descriptor.ProtoDef =
-		this.parseProtocol(protoFilePath, protoSearchPath)
-	if this.Descriptor.ProtoDef == nil {
+	for name, protoDef := range reusedProtos {
+		this.Descriptor.ImportedProtos[name] = protoDef
+	}
+
+	for _, protoFilePath := range protoFilePaths {
+		protoDef := this.parseProtocol(protoFilePath, protoSearchPath)
+		if protoDef == nil {
+			continue
+		}
+		if this.Descriptor.ProtoDef == nil {
+			this.Descriptor.ProtoDef = protoDef
+		}
+	}
+
+	if this.DiagnosticsFormat != "" {
+		this.Diagnostics.Print(os.Stderr, this.DiagnosticsFormat)
+	}
+
+	if this.Descriptor.ProtoDef == nil ||
+		this.Diagnostics.ErrorCount() > 0 {
 		return false
 	}
 
@@ -47,21 +107,92 @@ func (this *ProtocolParser) isStrNumber(str string) bool {
 	return g_isNumberRegexp.MatchString(str)
 }
 
-func (this *ProtocolParser) printLineError(
-	fileName string, lineNumber int, format string, args ...any) {
+func (this *ProtocolParser) addFileError(
+	filePath string, code string, format string, args ...any) {
 
-	fmt.Fprintf(os.Stderr,
-		"error:%s:%d: %s\n",
-		fileName, lineNumber,
-		fmt.Sprintf(format, args...))
+	this.Diagnostics.Add(filePath, 0, 0,
+		DiagnosticSeverity_Error, code, format, args...)
 }
 
-func (this *ProtocolParser) printNodeError(
-	protoDef *ProtocolDef, node *xmlquery.Node,
+func (this *ProtocolParser) addNodeError(
+	protoDef *ProtocolDef, node *xmlquery.Node, code string,
 	format string, args ...any) {
 
-	this.printLineError(
-		protoDef.FilePath, node.LineNumber, format, args...)
+	lineNumber, column := this.getTagPosition(
+		protoDef.FilePath, node.LineNumber, node.Data)
+	// text syntax knows the column of the node, e.g. items of an enum
+	// written in one line
+	nodeColumns := this.fileNodeColumns[protoDef.FilePath]
+	if nodeColumn, ok := nodeColumns[node]; ok {
+		column = nodeColumn
+	}
+	this.Diagnostics.Add(protoDef.FilePath, lineNumber, column,
+		DiagnosticSeverity_Error, code, format, args...)
+}
+
+// xml decoder reports the line where the start tag ends, search
+// backward for the tag beginning to get the line and column
+func (this *ProtocolParser) getTagPosition(
+	filePath string, tagLineNumber int, tagName string) (int, int) {
+
+	lines := this.fileLines[filePath]
+	if tagLineNumber <= 0 || tagLineNumber > len(lines) {
+		return tagLineNumber, 0
+	}
+	if ProtocolFileIsText(filePath) {
+		return tagLineNumber, this.getTextStatementColumn(
+			lines[tagLineNumber-1], tagName)
+	}
+
+	tag := "<" + tagName
+	for lineNumber := tagLineNumber; lineNumber >= 1; lineNumber-- {
+		line := lines[lineNumber-1]
+		index := strings.LastIndex(line, tag)
+		for index >= 0 {
+			// skip tags only sharing the prefix, e.g. enum_map for enum
+			end := index + len(tag)
+			if end == len(line) ||
+				strings.IndexByte(" \t\r/>", line[end]) >= 0 {
+				return lineNumber, index + 1
+			}
+			index = strings.LastIndex(line[:index], tag)
+		}
+	}
+
+	return tagLineNumber, 0
+}
+
+// text syntax has no tags, the line of a node is where its statement
+// begins, e.g. `struct Attr {`, fields and items are located at the
+// first word of the line. addNodeError uses the exact column recorded
+// by the text parser instead
+func (this *ProtocolParser) getTextStatementColumn(
+	line string, tagName string) int {
+
+	if tagName == "namespace" || tagName == "import" ||
+		tagName == "enum" || tagName == "struct" || tagName == "enum_map" {
+		index := strings.Index(line, tagName)
+		for index >= 0 {
+			// skip words only sharing the prefix, e.g. enum_map for enum
+			end := index + len(tagName)
+			if (index == 0 || protocolTextIsWordChar(line[index-1]) == false) &&
+				(end == len(line) || protocolTextIsWordChar(line[end]) == false) {
+				return index + 1
+			}
+			next := strings.Index(line[end:], tagName)
+			if next < 0 {
+				break
+			}
+			index = end + next
+		}
+	}
+
+	trimmedLine := strings.TrimLeft(line, " \t")
+	if trimmedLine == "" {
+		return 0
+	}
+
+	return len(line) - len(trimmedLine) + 1
 }
 
 func (this *ProtocolParser) getNodeAttr(
@@ -82,13 +213,13 @@ func (this *ProtocolParser) getProtoFileFullPath(
 
 	fileExists := false
 	// find proto file path directly first
-	if UtilCheckFileExists(protoFilePath) {
+	if this.checkFileExists(protoFilePath) {
 		fileExists = true
 	} else {
 		// find in the search path
 		for _, path := range protoSearchPath {
 			checkPath := filepath.Join(path, protoFilePath)
-			if UtilCheckFileExists(checkPath) {
+			if this.checkFileExists(checkPath) {
 				fileExists = true
 				protoFilePath = checkPath
 				break
@@ -103,26 +234,54 @@ func (this *ProtocolParser) getProtoFileFullPath(
 	}
 }
 
+func (this *ProtocolParser) checkFileExists(filePath string) bool {
+	if _, ok := this.FileTexts[UtilGetFullPath(filePath)]; ok {
+		return true
+	}
+
+	return UtilCheckFileExists(filePath)
+}
+
 func (this *ProtocolParser) loadProtoFile(filePath string) *xmlquery.Node {
-	fileBin, err := os.ReadFile(filePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr,
-			"error: can not read protocol file `%s`: %s\n",
-			filePath, err.Error())
-		return nil
+	fileText, ok := this.FileTexts[filePath]
+	if ok == false {
+		fileBin, err := os.ReadFile(filePath)
+		if err != nil {
+			this.addFileError(filePath, DiagnosticCode_FileReadFailed,
+				"can not read protocol file: %s", err.Error())
+			return nil
+		}
+		fileText = string(fileBin)
+	}
+	this.fileLines[filePath] = strings.Split(fileText, "\n")
+
+	if ProtocolFileIsText(filePath) {
+		textParser := NewProtocolTextParser(this.Diagnostics)
+		textDoc := textParser.Parse(filePath, fileText)
+		if textDoc != nil {
+			this.fileDocs[filePath] = textDoc
+			this.fileNodeColumns[filePath] = textParser.NodeColumns
+		}
+		return textDoc
 	}
-	fileText := string(fileBin)
 
 	xmlDoc, err := xmlquery.ParseWithOptions(strings.NewReader(fileText),
 		xmlquery.ParserOptions{
 			WithLineNumbers: true,
 		})
 	if err != nil {
-		fmt.Fprintf(os.Stderr,
-			"error: can not parse protocol file `%s`: %s\n",
-			filePath, err.Error())
+		var syntaxError *xml.SyntaxError
+		if errors.As(err, &syntaxError) {
+			this.Diagnostics.Add(filePath, syntaxError.Line, 0,
+				DiagnosticSeverity_Error, DiagnosticCode_XmlSyntaxError,
+				"can not parse protocol file: %s", syntaxError.Msg)
+		} else {
+			this.addFileError(filePath, DiagnosticCode_XmlSyntaxError,
+				"can not parse protocol file: %s", err.Error())
+		}
 		return nil
 	}
+	this.fileDocs[filePath] = xmlDoc
 
 	return xmlDoc
 }
@@ -142,9 +301,8 @@ func (this *ProtocolParser) parseProtocol(
 	protoFileFullPath := this.getProtoFileFullPath(
 		protoFilePath, protoSearchPath)
 	if protoFileFullPath == "" {
-		fmt.Fprintf(os.Stderr,
-			"error: can not find protocol file `%s`\n",
-			protoFilePath)
+		this.addFileError("", DiagnosticCode_FileNotFound,
+			"can not find protocol file `%s`", protoFilePath)
 		return nil
 	}
 
@@ -170,10 +328,16 @@ func (this *ProtocolParser) parseProtocol(
 	if rootNode == nil ||
 		rootNode.Type != xmlquery.ElementNode ||
 		rootNode.Data != "protocol" {
-		fmt.Fprintf(os.Stderr,
-			"error:%s: root node must be `protocol` node\n",
-			protoDef.FilePath)
-		return nil
+		if rootNode == nil {
+			this.addFileError(protoDef.FilePath,
+				DiagnosticCode_InvalidRootNode,
+				"root node must be `protocol` node")
+		} else {
+			this.addNodeError(protoDef, rootNode,
+				DiagnosticCode_InvalidRootNode,
+				"root node must be `protocol` node")
+		}
+		return protoDef
 	}
 
 	// parse imports
@@ -184,22 +348,22 @@ func (this *ProtocolParser) parseProtocol(
 			refProtoPath := node.InnerText()
 			refProtoName := UtilGetFileNameWithoutExtension(refProtoPath)
 			if refProtoName == protoName {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_ImportSelf,
 					"can not import self")
-				return nil
+				continue
 			}
 			externalProtoDef := this.parseProtocol(
 				refProtoPath, protoSearchPath)
 			if externalProtoDef == nil {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_ImportFailed,
 					"load external file `%s` failed",
 					refProtoPath)
-				return nil
+				continue
 			}
 
-			if this.addImportDef(protoDef, node, externalProtoDef) == false {
-				return nil
-			}
+			this.addImportDef(protoDef, node, externalProtoDef)
 		}
 	}
 
@@ -207,9 +371,7 @@ func (this *ProtocolParser) parseProtocol(
 	{
 		nodes := xmlquery.Find(rootNode, "/namespace")
 		for _, node := range nodes {
-			if this.addNamespaceDef(protoDef, node) == false {
-				return nil
-			}
+			this.addNamespaceDef(protoDef, node)
 		}
 	}
 
@@ -217,9 +379,7 @@ func (this *ProtocolParser) parseProtocol(
 	{
 		nodes := xmlquery.Find(rootNode, "/enum")
 		for _, node := range nodes {
-			if this.addEnumDef(protoDef, node) == false {
-				return nil
-			}
+			this.addEnumDef(protoDef, node)
 		}
 	}
 
@@ -227,9 +387,7 @@ func (this *ProtocolParser) parseProtocol(
 	{
 		nodes := xmlquery.Find(rootNode, "/struct")
 		for _, node := range nodes {
-			if this.addStructDef(protoDef, node) == false {
-				return nil
-			}
+			this.addStructDef(protoDef, node)
 		}
 	}
 
@@ -237,9 +395,7 @@ func (this *ProtocolParser) parseProtocol(
 	{
 		nodes := xmlquery.Find(rootNode, "/enum_map")
 		for _, node := range nodes {
-			if this.addEnumMapDef(protoDef, node) == false {
-				return nil
-			}
+			this.addEnumMapDef(protoDef, node)
 		}
 	}
 
@@ -253,7 +409,8 @@ func (this *ProtocolParser) addImportDef(
 	externalProtoDef *ProtocolDef) bool {
 
 	if _, ok := protoDef.ImportNameIndex[externalProtoDef.Name]; ok {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_ImportDuplicated,
 			"import `%s` duplicated", externalProtoDef.Name)
 		return false
 	}
@@ -275,19 +432,22 @@ func (this *ProtocolParser) addNamespaceDef(
 	{
 		attr := this.getNodeAttr(node, "lang")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`namespace` node must contain a `lang` attribute")
 			return false
 		}
 		lang = attr.Value
 		if lang == "" {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_InvalidAttribute,
 				"`namespace` node `lang` attribute is invalid")
 			return false
 		}
 	}
 	if _, ok := protoDef.Namespaces[lang]; ok {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_DuplicatedName,
 			"`namespace` node `lang` attribute duplicated")
 		return false
 	}
@@ -295,7 +455,8 @@ func (this *ProtocolParser) addNamespaceDef(
 	// check namespace value
 	namespaceStr := node.InnerText()
 	if namespaceStr == "" {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidNamespace,
 			"`namespace` node value can not be empty")
 		return false
 	}
@@ -304,7 +465,8 @@ func (this *ProtocolParser) addNamespaceDef(
 	namespaceParts := strings.Split(namespaceStr, ".")
 	for _, part := range namespaceParts {
 		if this.isStrValidVarName(part) == false {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_InvalidNamespace,
 				"`namespace` node value is invalid")
 			return false
 		}
@@ -327,14 +489,16 @@ func (this *ProtocolParser) addEnumDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`enum` node must contain a `name` attribute")
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`enum` node `name` attribute is invalid")
 		return false
 	}
@@ -346,7 +510,8 @@ func (this *ProtocolParser) addEnumDef(
 			}
 		}
 		if ok {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_DuplicatedName,
 				"`enum` node `name` attribute duplicated")
 			return false
 		}
@@ -360,14 +525,13 @@ func (this *ProtocolParser) addEnumDef(
 			continue
 		}
 		if childNode.Data != "item" {
-			this.printNodeError(protoDef, childNode,
+			this.addNodeError(protoDef, childNode,
+				DiagnosticCode_UnexpectedNode,
 				"expect a `item` node")
-			return false
+			continue
 		}
 
-		if this.addEnumItemDef(protoDef, def, childNode) == false {
-			return false
-		}
+		this.addEnumItemDef(protoDef, def, childNode)
 	}
 
 	protoDef.Enums = append(protoDef.Enums, def)
@@ -384,19 +548,22 @@ func (this *ProtocolParser) addEnumItemDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`item` node must contain a `name` attribute")
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`item` node `name` attribute is invalid")
 		return false
 	}
 	if _, ok := enumDef.ItemNameIndex[name]; ok {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_DuplicatedName,
 			"`item` node `name` attribute duplicated")
 		return false
 	}
@@ -433,7 +600,8 @@ func (this *ProtocolParser) addEnumItemDef(
 			refDefName := parts[0]
 			refDef, ok := enumDef.ItemNameIndex[refDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedEnumItem,
 					"enum item `%s` is undefined", refDefName)
 				return false
 			}
@@ -448,13 +616,15 @@ func (this *ProtocolParser) addEnumItemDef(
 
 			refEnumDef, ok := protoDef.EnumNameIndex[refEnumDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedEnum,
 					"enum `%s` is undefined", refEnumDefName)
 				return false
 			}
 			refDef, ok := refEnumDef.ItemNameIndex[refDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedEnumItem,
 					"enum item `%s.%s` is undefined",
 					refEnumDefName, refDefName)
 				return false
@@ -471,21 +641,24 @@ func (this *ProtocolParser) addEnumItemDef(
 
 			refImportDef, ok := protoDef.ImportNameIndex[refProtoDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedProtocol,
 					"protocol `%s` is undefined", refProtoDefName)
 				return false
 			}
 			refProtoDef := refImportDef.ProtoDef
 			refEnumDef, ok := refProtoDef.EnumNameIndex[refEnumDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedEnum,
 					"enum `%s.%s` is undefined",
 					refProtoDefName, refEnumDefName)
 				return false
 			}
 			refDef, ok := refEnumDef.ItemNameIndex[refDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedEnumItem,
 					"enum item `%s.%s.%s` is undefined",
 					refProtoDefName, refEnumDefName, refDefName)
 				return false
@@ -495,7 +668,8 @@ func (this *ProtocolParser) addEnumItemDef(
 			def.RefEnumItemDef = refDef
 
 		} else {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_InvalidEnumValue,
 				"enum value `%s` is invalid", value)
 			return false
 		}
@@ -515,14 +689,16 @@ func (this *ProtocolParser) addStructDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`struct` node must contain a `name` attribute")
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`struct` node `name` attribute is invalid")
 		return false
 	}
@@ -534,7 +710,8 @@ func (this *ProtocolParser) addStructDef(
 			}
 		}
 		if ok {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_DuplicatedName,
 				"`struct` node `name` attribute duplicated")
 			return false
 		}
@@ -549,14 +726,13 @@ func (this *ProtocolParser) addStructDef(
 		}
 		if childNode.Data != "required" &&
 			childNode.Data != "optional" {
-			this.printNodeError(protoDef, childNode,
+			this.addNodeError(protoDef, childNode,
+				DiagnosticCode_UnexpectedNode,
 				"expect a `required` or `optional` node")
-			return false
+			continue
 		}
 
-		if this.addStructFieldDef(protoDef, def, childNode) == false {
-			return false
-		}
+		this.addStructFieldDef(protoDef, def, childNode)
 	}
 
 	if def.OptionalFieldCount > 0 {
@@ -577,19 +753,22 @@ func (this *ProtocolParser) addStructFieldDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`%s` node must contain a `name` attribute", node.Data)
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`%s` node `name` attribute is invalid", node.Data)
 		return false
 	}
 	if _, ok := structDef.FieldNameIndex[name]; ok {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_DuplicatedName,
 			"`%s` node `name` attribute duplicated", node.Data)
 		return false
 	}
@@ -599,7 +778,8 @@ func (this *ProtocolParser) addStructFieldDef(
 	{
 		attr := this.getNodeAttr(node, "type")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`%s` node must contain a `type` attribute", node.Data)
 			return false
 		}
@@ -671,7 +851,8 @@ func (this *ProtocolParser) addStructFieldDef(
 			ok := false
 			refImportDef, ok := protoDef.ImportNameIndex[refProtoDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedProtocol,
 					"protocol `%s` is undefined", refProtoDefName)
 				return false
 			}
@@ -679,7 +860,8 @@ func (this *ProtocolParser) addStructFieldDef(
 			refDefName = parts[1]
 
 		} else {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_InvalidType,
 				"type `%s` is invalid", fieldTypeStr)
 			return false
 		}
@@ -691,7 +873,8 @@ func (this *ProtocolParser) addStructFieldDef(
 			fieldType = StructFieldType_Struct
 			def.RefStructDef = refStructDef
 		} else {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_UndefinedType,
 				"type `%s` is undefined", refDefName)
 			return false
 		}
@@ -724,14 +907,16 @@ func (this *ProtocolParser) addEnumMapDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`enum_map` node must contain a `name` attribute")
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`enum_map` node `name` attribute is invalid")
 		return false
 	}
@@ -743,7 +928,8 @@ func (this *ProtocolParser) addEnumMapDef(
 			}
 		}
 		if ok {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_DuplicatedName,
 				"`enum_map` node `name` attribute duplicated")
 			return false
 		}
@@ -757,14 +943,13 @@ func (this *ProtocolParser) addEnumMapDef(
 			continue
 		}
 		if childNode.Data != "item" {
-			this.printNodeError(protoDef, childNode,
+			this.addNodeError(protoDef, childNode,
+				DiagnosticCode_UnexpectedNode,
 				"expect a `item` node")
-			return false
+			continue
 		}
 
-		if this.addEnumMapItemDef(protoDef, def, childNode) == false {
-			return false
-		}
+		this.addEnumMapItemDef(protoDef, def, childNode)
 	}
 
 	protoDef.EnumMaps = append(protoDef.EnumMaps, def)
@@ -781,19 +966,22 @@ func (this *ProtocolParser) addEnumMapItemDef(
 	{
 		attr := this.getNodeAttr(node, "name")
 		if attr == nil {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_MissingAttribute,
 				"`item` node must contain a `name` attribute")
 			return false
 		}
 		name = attr.Value
 	}
 	if this.isStrValidVarName(name) == false {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_InvalidAttribute,
 			"`item` node `name` attribute is invalid")
 		return false
 	}
 	if _, ok := enumMapDef.ItemNameIndex[name]; ok {
-		this.printNodeError(protoDef, node,
+		this.addNodeError(protoDef, node,
+			DiagnosticCode_DuplicatedName,
 			"`item` node `name` attribute duplicated")
 		return false
 	}
@@ -835,7 +1023,8 @@ func (this *ProtocolParser) addEnumMapItemDef(
 		// current enum
 		refDef, ok := enumMapDef.ItemNameIndex[value]
 		if ok == false {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_UndefinedEnumItem,
 				"enum_map item `%s` is undefined", value)
 			return false
 		}
@@ -846,7 +1035,7 @@ func (this *ProtocolParser) addEnumMapItemDef(
 
 	if len(enumMapDef.Items) > 0 &&
 		def.IntValue < enumMapDef.Items[len(enumMapDef.Items)-1].IntValue {
-		this.printNodeError(protoDef, node, ""+
+		this.addNodeError(protoDef, node, DiagnosticCode_DecreasingValue, ""+
 			"`item` node `value` attribute can not be "+
 			"less than previous one")
 		return false
@@ -870,7 +1059,8 @@ func (this *ProtocolParser) addEnumMapItemDef(
 			ok := false
 			refImportDef, ok := protoDef.ImportNameIndex[refProtoDefName]
 			if ok == false {
-				this.printNodeError(protoDef, node,
+				this.addNodeError(protoDef, node,
+					DiagnosticCode_UndefinedProtocol,
 					"protocol `%s` is undefined", refProtoDefName)
 				return false
 			}
@@ -878,14 +1068,16 @@ func (this *ProtocolParser) addEnumMapItemDef(
 			refDefName = parts[1]
 
 		} else {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_InvalidStruct,
 				"struct `%s` is invalid", structValue)
 			return false
 		}
 
 		refStructDef, ok := refProtoDef.StructNameIndex[refDefName]
 		if ok == false {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_UndefinedStruct,
 				"struct `%s` is undefined", refDefName)
 			return false
 		}
@@ -893,12 +1085,14 @@ func (this *ProtocolParser) addEnumMapItemDef(
 		def.RefStructDef = refStructDef
 
 		if _, ok := enumMapDef.IdToStructIndex[def.IntValue]; ok {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_IdAlreadyMapped,
 				"id `%d` is already mapped to a struct", def.IntValue)
 			return false
 		}
 		if _, ok := enumMapDef.StructToIdIndex[def.RefStructDef]; ok {
-			this.printNodeError(protoDef, node,
+			this.addNodeError(protoDef, node,
+				DiagnosticCode_StructAlreadyMapped,
 				"struct `%s` is already mapped to a id", def.RefStructDef.Name)
 			return false
 		}
@@ -968,9 +1162,11 @@ func (this *ProtocolParser) processImportedProtocols(
 
 		// check imported protocol is used
 		if _, ok := usedProtos[protoName]; ok == false {
-			fmt.Fprintf(os.Stderr,
-				"warning:%s:%d: protocol `%s` is not used but imported\n",
-				protoDef.FilePath, importDef.LineNumber, importDef.Name)
+			lineNumber, column := this.getTagPosition(
+				protoDef.FilePath, importDef.LineNumber, "import")
+			this.Diagnostics.Add(protoDef.FilePath, lineNumber, column,
+				DiagnosticSeverity_Warning, DiagnosticCode_ImportNotUsed,
+				"protocol `%s` is not used but imported", importDef.Name)
 		}
 
 		if _, ok := enumRefProtos[protoName]; ok {
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// parser of the text syntax of protocol files, e.g.
+//
+//	namespace cpp protocol.client;
+//	import "attr.xml";
+//	enum AttrType { MIN = 0; STR = MIN; AGI; }
+//	struct Attr { AttrType id; i32 value; optional string note; }
+//	enum_map MessageType { MSG_TEST = 1001 -> MsgTest; }
+//
+// the result is the same node tree as the xml syntax, so both are
+// checked by ProtocolParser in the same way. comments are kept as
+// comment nodes for lint annotations
+
+const ProtocolTextFileExtension = ".brex"
+
+func ProtocolFileIsText(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ProtocolTextFileExtension)
+}
+
+// ----------------------------------------------------------------------------
+type protocolTextTokenType int
+
+const (
+	protocolTextTokenType_EOF protocolTextTokenType = iota
+	// name, number or value, e.g. i32, attr.AttrType.MIN or -1
+	protocolTextTokenType_Word
+	protocolTextTokenType_String
+	protocolTextTokenType_Symbol
+	protocolTextTokenType_Comment
+)
+
+type protocolTextToken struct {
+	Type protocolTextTokenType
+	// without quotes for string, without delimiters for comment
+	Text       string
+	LineNumber int
+	Column     int
+	// line of the last character, differs for block comment
+	EndLineNumber int
+}
+
+// ----------------------------------------------------------------------------
+type ProtocolTextParser struct {
+	Diagnostics *DiagnosticList
+	// element node -> column of its first token, filled by Parse,
+	// nodes have line number only
+	NodeColumns map[*xmlquery.Node]int
+
+	filePath string
+	tokens   []*protocolTextToken
+	pos      int
+	// node new nodes and comments are added to
+	container *xmlquery.Node
+	// line of the last token consumed, a text node of new line is added
+	// before nodes starting on later lines like xml does
+	lastLineNumber int
+}
+
+func NewProtocolTextParser(diagnostics *DiagnosticList) *ProtocolTextParser {
+	newObj := new(ProtocolTextParser)
+	newObj.Diagnostics = diagnostics
+
+	return newObj
+}
+
+// return nil if failed, the first syntax error is reported
+func (this *ProtocolTextParser) Parse(
+	filePath string, fileText string) *xmlquery.Node {
+
+	this.filePath = filePath
+	this.tokens = nil
+	this.pos = 0
+	this.lastLineNumber = 0
+	this.NodeColumns = make(map[*xmlquery.Node]int)
+	if this.tokenize(fileText) == false {
+		return nil
+	}
+
+	doc := &xmlquery.Node{
+		Type: xmlquery.DocumentNode,
+	}
+	rootNode := &xmlquery.Node{
+		Type:       xmlquery.ElementNode,
+		Data:       "protocol",
+		LineNumber: 1,
+	}
+	xmlquery.AddChild(doc, rootNode)
+	this.container = rootNode
+
+	for this.peek().Type != protocolTextTokenType_EOF {
+		if this.parseStatement() == false {
+			return nil
+		}
+	}
+
+	return doc
+}
+
+func (this *ProtocolTextParser) addError(
+	lineNumber int, column int, format string, args ...any) {
+
+	this.Diagnostics.Add(this.filePath, lineNumber, column,
+		DiagnosticSeverity_Error, DiagnosticCode_TextSyntaxError,
+		"can not parse protocol file: "+format, args...)
+}
+
+func (this *ProtocolTextParser) addTokenError(
+	token *protocolTextToken, expected string) {
+
+	if token.Type == protocolTextTokenType_EOF {
+		this.addError(token.LineNumber, token.Column,
+			"expect %s, but reach end of file", expected)
+	} else {
+		this.addError(token.LineNumber, token.Column,
+			"expect %s, but get `%s`", expected, token.Text)
+	}
+}
+
+// ----------------------------------------------------------------------------
+func protocolTextIsWordChar(c byte) bool {
+	return c == '_' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9')
+}
+
+func (this *ProtocolTextParser) tokenize(text string) bool {
+	lineNumber := 1
+	lineStart := 0
+	i := 0
+
+	for i < len(text) {
+		c := text[i]
+		column := i - lineStart + 1
+
+		if c == '\n' {
+			lineNumber++
+			i++
+			lineStart = i
+		} else if c == ' ' || c == '\t' || c == '\r' ||
+			c == '\v' || c == '\f' {
+			i++
+		} else if strings.HasPrefix(text[i:], "//") {
+			start := i
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			this.addToken(protocolTextTokenType_Comment,
+				text[start+2:i], lineNumber, column, lineNumber)
+		} else if strings.HasPrefix(text[i:], "/*") {
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				this.addError(lineNumber, column, "comment is not closed")
+				return false
+			}
+			comment := text[i : i+2+end+2]
+			startLineNumber := lineNumber
+			if count := strings.Count(comment, "\n"); count > 0 {
+				lineNumber += count
+				lineStart = i + strings.LastIndex(comment, "\n") + 1
+			}
+			this.addToken(protocolTextTokenType_Comment,
+				comment[2:len(comment)-2], startLineNumber, column, lineNumber)
+			i += len(comment)
+		} else if protocolTextIsWordChar(c) ||
+			(c == '-' && i+1 < len(text) &&
+				text[i+1] >= '0' && text[i+1] <= '9') {
+			start := i
+			i++
+			for i < len(text) && protocolTextIsWordChar(text[i]) {
+				i++
+			}
+			this.addToken(protocolTextTokenType_Word,
+				text[start:i], lineNumber, column, lineNumber)
+		} else if c == '"' {
+			start := i
+			i++
+			for i < len(text) && text[i] != '"' && text[i] != '\n' {
+				i++
+			}
+			if i >= len(text) || text[i] != '"' {
+				this.addError(lineNumber, column, "string is not closed")
+				return false
+			}
+			i++
+			this.addToken(protocolTextTokenType_String,
+				text[start+1:i-1], lineNumber, column, lineNumber)
+		} else if strings.HasPrefix(text[i:], "->") {
+			this.addToken(protocolTextTokenType_Symbol,
+				"->", lineNumber, column, lineNumber)
+			i += 2
+		} else if strings.IndexByte("{};=", c) >= 0 {
+			this.addToken(protocolTextTokenType_Symbol,
+				text[i:i+1], lineNumber, column, lineNumber)
+			i++
+		} else {
+			this.addError(lineNumber, column,
+				"unexpected character `%c`", c)
+			return false
+		}
+	}
+	this.addToken(protocolTextTokenType_EOF, "",
+		lineNumber, len(text)-lineStart+1, lineNumber)
+
+	return true
+}
+
+func (this *ProtocolTextParser) addToken(tokenType protocolTextTokenType,
+	text string, lineNumber int, column int, endLineNumber int) {
+
+	this.tokens = append(this.tokens, &protocolTextToken{
+		Type:          tokenType,
+		Text:          text,
+		LineNumber:    lineNumber,
+		Column:        column,
+		EndLineNumber: endLineNumber,
+	})
+}
+
+// ----------------------------------------------------------------------------
+// comments before the next token are added to the current container
+func (this *ProtocolTextParser) peek() *protocolTextToken {
+	for this.tokens[this.pos].Type == protocolTextTokenType_Comment {
+		token := this.tokens[this.pos]
+		this.addNode(&xmlquery.Node{
+			Type:       xmlquery.CommentNode,
+			Data:       token.Text,
+			LineNumber: token.LineNumber,
+		})
+		this.lastLineNumber = token.EndLineNumber
+		this.pos++
+	}
+
+	return this.tokens[this.pos]
+}
+
+func (this *ProtocolTextParser) next() *protocolTextToken {
+	token := this.peek()
+	if token.Type != protocolTextTokenType_EOF {
+		this.lastLineNumber = token.EndLineNumber
+		this.pos++
+	}
+
+	return token
+}
+
+func (this *ProtocolTextParser) peekSymbol(symbol string) bool {
+	token := this.peek()
+	return token.Type == protocolTextTokenType_Symbol && token.Text == symbol
+}
+
+func (this *ProtocolTextParser) peekWord() bool {
+	return this.peek().Type == protocolTextTokenType_Word
+}
+
+func (this *ProtocolTextParser) expectSymbol(symbol string) bool {
+	token := this.next()
+	if token.Type != protocolTextTokenType_Symbol || token.Text != symbol {
+		this.addTokenError(token, "`"+symbol+"`")
+		return false
+	}
+
+	return true
+}
+
+func (this *ProtocolTextParser) expectWord(expected string) (string, bool) {
+	token := this.next()
+	if token.Type != protocolTextTokenType_Word {
+		this.addTokenError(token, expected)
+		return "", false
+	}
+
+	return token.Text, true
+}
+
+// ----------------------------------------------------------------------------
+func (this *ProtocolTextParser) addNode(node *xmlquery.Node) {
+	if node.LineNumber > this.lastLineNumber {
+		xmlquery.AddChild(this.container, &xmlquery.Node{
+			Type: xmlquery.TextNode,
+			Data: "\n",
+		})
+	}
+	xmlquery.AddChild(this.container, node)
+}
+
+// node is located at the line of the next token, which is not consumed
+// so that new line before it is known
+func (this *ProtocolTextParser) addElementNode(
+	tagName string) *xmlquery.Node {
+
+	token := this.peek()
+	node := &xmlquery.Node{
+		Type:       xmlquery.ElementNode,
+		Data:       tagName,
+		LineNumber: token.LineNumber,
+	}
+	this.addNode(node)
+	this.NodeColumns[node] = token.Column
+
+	return node
+}
+
+func (this *ProtocolTextParser) addTextNode(node *xmlquery.Node, text string) {
+	xmlquery.AddChild(node, &xmlquery.Node{
+		Type: xmlquery.TextNode,
+		Data: text,
+	})
+}
+
+func (this *ProtocolTextParser) parseStatement() bool {
+	token := this.peek()
+	if token.Type == protocolTextTokenType_Word {
+		if token.Text == "namespace" {
+			return this.parseNamespace()
+		} else if token.Text == "import" {
+			return this.parseImport()
+		} else if token.Text == "enum" {
+			return this.parseEnum()
+		} else if token.Text == "struct" {
+			return this.parseStruct()
+		} else if token.Text == "enum_map" {
+			return this.parseEnumMap()
+		}
+	}
+
+	this.addTokenError(token,
+		"`namespace`, `import`, `enum`, `struct` or `enum_map`")
+	return false
+}
+
+// e.g. namespace cpp protocol.client;
+func (this *ProtocolTextParser) parseNamespace() bool {
+	node := this.addElementNode("namespace")
+	this.next()
+
+	lang, ok := this.expectWord("language")
+	if ok == false {
+		return false
+	}
+	xmlquery.AddAttr(node, "lang", lang)
+
+	namespace, ok := this.expectWord("namespace")
+	if ok == false {
+		return false
+	}
+	this.addTextNode(node, namespace)
+
+	return this.expectSymbol(";")
+}
+
+// e.g. import "attr.xml"; or import attr.brex;
+func (this *ProtocolTextParser) parseImport() bool {
+	node := this.addElementNode("import")
+	this.next()
+
+	token := this.next()
+	if token.Type != protocolTextTokenType_String &&
+		token.Type != protocolTextTokenType_Word {
+		this.addTokenError(token, "file path")
+		return false
+	}
+	this.addTextNode(node, token.Text)
+
+	return this.expectSymbol(";")
+}
+
+// parse `Name {` and items until `}`, items are added to the node
+func (this *ProtocolTextParser) parseBlock(
+	tagName string, parseItem func() bool) bool {
+
+	node := this.addElementNode(tagName)
+	this.next()
+
+	name, ok := this.expectWord("name")
+	if ok == false {
+		return false
+	}
+	xmlquery.AddAttr(node, "name", name)
+
+	if this.expectSymbol("{") == false {
+		return false
+	}
+
+	parentContainer := this.container
+	this.container = node
+	for this.peekSymbol("}") == false {
+		if parseItem() == false {
+			return false
+		}
+	}
+	this.next()
+	this.container = parentContainer
+
+	return true
+}
+
+// e.g. enum AttrType { MIN = 0; STR = MIN; AGI; }
+func (this *ProtocolTextParser) parseEnum() bool {
+	return this.parseBlock("enum", func() bool {
+		token := this.peek()
+		if token.Type != protocolTextTokenType_Word {
+			this.addTokenError(token, "item name or `}`")
+			return false
+		}
+		node := this.addElementNode("item")
+		this.next()
+		xmlquery.AddAttr(node, "name", token.Text)
+
+		if this.peekSymbol("=") {
+			this.next()
+			value, ok := this.expectWord("item value")
+			if ok == false {
+				return false
+			}
+			xmlquery.AddAttr(node, "value", value)
+		}
+
+		return this.expectSymbol(";")
+	})
+}
+
+// e.g. i32, attr.Attr or list{attr.Attr}
+func (this *ProtocolTextParser) expectType() (string, bool) {
+	typ, ok := this.expectWord("type")
+	if ok == false {
+		return "", false
+	}
+	if typ != "list" || this.peekSymbol("{") == false {
+		return typ, true
+	}
+
+	this.next()
+	itemType, ok := this.expectWord("list item type")
+	if ok == false {
+		return "", false
+	}
+	if this.expectSymbol("}") == false {
+		return "", false
+	}
+
+	return "list{" + itemType + "}", true
+}
+
+// e.g. struct Attr { AttrType id; optional string note; }
+func (this *ProtocolTextParser) parseStruct() bool {
+	return this.parseBlock("struct", func() bool {
+		token := this.peek()
+		if token.Type != protocolTextTokenType_Word {
+			this.addTokenError(token, "field or `}`")
+			return false
+		}
+
+		// [optional|required] type name;
+		node := this.addElementNode("required")
+		typ, ok := this.expectType()
+		if ok == false {
+			return false
+		}
+		name, ok := this.expectType()
+		if ok == false {
+			return false
+		}
+		if this.peekWord() {
+			// a word after `type name` is either the name of
+			// `optional type name` or the next field after a missing `;`
+			if typ != "optional" && typ != "required" {
+				this.addTokenError(this.peek(), "`;`")
+				return false
+			}
+			node.Data = typ
+			typ = name
+			name = this.next().Text
+		}
+		xmlquery.AddAttr(node, "name", name)
+		xmlquery.AddAttr(node, "type", typ)
+
+		return this.expectSymbol(";")
+	})
+}
+
+// e.g. enum_map MessageType { MSG_TEST = 1001 -> MsgTest; MSG_EMPTY; }
+func (this *ProtocolTextParser) parseEnumMap() bool {
+	return this.parseBlock("enum_map", func() bool {
+		token := this.peek()
+		if token.Type != protocolTextTokenType_Word {
+			this.addTokenError(token, "item name or `}`")
+			return false
+		}
+		node := this.addElementNode("item")
+		this.next()
+		xmlquery.AddAttr(node, "name", token.Text)
+
+		if this.peekSymbol("=") {
+			this.next()
+			value, ok := this.expectWord("item value")
+			if ok == false {
+				return false
+			}
+			xmlquery.AddAttr(node, "value", value)
+		}
+		if this.peekSymbol("->") {
+			this.next()
+			structName, ok := this.expectWord("struct name")
+			if ok == false {
+				return false
+			}
+			xmlquery.AddAttr(node, "struct", structName)
+		}
+
+		return this.expectSymbol(";")
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 func UtilAtoi(str string) int {
@@ -60,8 +61,10 @@ func UtilCheckDirExists(dirPath string) bool {
 	return true
 }
 
+// write to a temp file in the same directory then rename it,
+// so an interrupted run never leaves a truncated file
 func UtilWriteAllText(filePath string, fileContent string) bool {
-	err := os.WriteFile(filePath, []byte(fileContent), 0644)
+	err := utilWriteFileAtomic(filePath, []byte(fileContent))
 	if err != nil {
 		fmt.Fprintf(os.Stderr,
 			"error: write file %s failed: %s\n",
@@ -71,3 +74,108 @@ func UtilWriteAllText(filePath string, fileContent string) bool {
 
 	return true
 }
+
+func utilWriteFileAtomic(filePath string, fileBin []byte) error {
+	tempFile, err := utilCreateTempFile(filePath)
+	if err != nil {
+		return err
+	}
+	tempFilePath := tempFile.Name()
+
+	_, err = tempFile.Write(fileBin)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	// keep mode of existing file
+	if err == nil {
+		if fileInfo, statErr := os.Stat(filePath); statErr == nil {
+			err = os.Chmod(tempFilePath, fileInfo.Mode().Perm())
+		}
+	}
+	if err == nil {
+		err = os.Rename(tempFilePath, filePath)
+	}
+	if err != nil {
+		os.Remove(tempFilePath)
+		return err
+	}
+
+	return nil
+}
+
+// temp file next to filePath, created with mode 0644 masked by umask
+// like os.WriteFile, os.CreateTemp always uses 0600
+func utilCreateTempFile(filePath string) (*os.File, error) {
+	for i := 0; ; i++ {
+		tempFilePath := filepath.Join(filepath.Dir(filePath),
+			fmt.Sprintf(".%s.%d.%d.tmp",
+				filepath.Base(filePath), os.Getpid(), i))
+		file, err := os.OpenFile(tempFilePath,
+			os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil && os.IsExist(err) && i < 10000 {
+			continue
+		}
+
+		return file, err
+	}
+}
+
+type WriteFileResult int
+
+const (
+	WriteFileResult_None WriteFileResult = iota
+	WriteFileResult_Created
+	WriteFileResult_Updated
+	WriteFileResult_Unchanged
+)
+
+// keep the file untouched if content is the same, so its mtime does not
+// trigger rebuilds, return WriteFileResult_None if failed
+func UtilWriteAllTextIfChanged(
+	filePath string, fileContent string) WriteFileResult {
+
+	result := WriteFileResult_Created
+	if oldFileBin, err := os.ReadFile(filePath); err == nil {
+		if string(oldFileBin) == fileContent {
+			return WriteFileResult_Unchanged
+		}
+		result = WriteFileResult_Updated
+	}
+
+	if UtilWriteAllText(filePath, fileContent) == false {
+		return WriteFileResult_None
+	}
+
+	return result
+}
+
+// e.g. message_type -> MessageType
+func UtilGetCamelCaseName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(strings.ToUpper(part[:1]))
+		sb.WriteString(part[1:])
+	}
+
+	return sb.String()
+}
+
+// e.g. AttrType -> ATTR_TYPE
+func UtilGetUpperSnakeCaseName(name string) string {
+	var sb strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			sb.WriteRune('_')
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
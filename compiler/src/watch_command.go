@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+func printWatchUsage() {
+	fmt.Fprintf(os.Stderr, ""+
+		"regenerate code when protocol files change\n"+
+		"usage: %s watch"+
+		"\n"+
+		"    [-c <project_file>] default is brexc.json if exists\n"+
+		"    [--interval <milliseconds>] poll interval, default is 500\n"+
+		"    [compiler options] override the project file, "+
+		"see `%s -h`\n"+
+		"protocol files found in directories and globs are expanded once, "+
+		"restart to pick up new files\n",
+		filepath.Base(os.Args[0]), filepath.Base(os.Args[0]))
+}
+
+func runWatchCommand(args []string) int {
+	// parse command line options
+	var optHelp bool
+	var optProjectFilePath string
+	var optInterval int
+	flagOptions := NewCompileOptions()
+
+	flagSet := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flagSet.BoolVarP(&optHelp, "help", "h", false, "")
+	flagSet.StringVarP(&optProjectFilePath, "project", "c", "", "")
+	flagSet.IntVar(&optInterval, "interval", 500, "")
+	flagOptions.AddFlags(flagSet)
+
+	if flagSet.Parse(args) != nil {
+		printWatchUsage()
+		return 1
+	}
+	if optHelp {
+		printWatchUsage()
+		return 0
+	}
+
+	// check command line options
+	// -- check option project_file
+	options := flagOptions
+	if optProjectFilePath == "" && UtilCheckFileExists("brexc.json") {
+		optProjectFilePath = "brexc.json"
+	}
+	if optProjectFilePath != "" {
+		if UtilCheckFileExists(optProjectFilePath) == false {
+			fmt.Fprintf(os.Stderr,
+				"error: can not find project file `%s`\n",
+				optProjectFilePath)
+			return 1
+		}
+		projectFile := LoadProjectFile(optProjectFilePath)
+		if projectFile == nil {
+			return 1
+		}
+		options = projectFile.ToCompileOptions()
+		options.OverrideByFlags(flagOptions, flagSet)
+	}
+
+	// -- required options
+	if len(options.ProtoFilePaths) == 0 ||
+		options.Language == "" {
+		printWatchUsage()
+		return 1
+	}
+
+	// -- check option interval
+	if optInterval <= 0 {
+		fmt.Fprintf(os.Stderr,
+			"error: interval `%d` is invalid\n",
+			optInterval)
+		return 1
+	}
+
+	task := NewCompileTask(options)
+	if task == nil {
+		return 1
+	}
+
+	watcher := NewProtocolWatcher(task)
+	defer watcher.Close()
+
+	watcher.Build(nil)
+	for {
+		time.Sleep(time.Duration(optInterval) * time.Millisecond)
+
+		changedFilePaths := watcher.CheckChanges()
+		if len(changedFilePaths) > 0 {
+			watcher.Build(changedFilePaths)
+		}
+	}
+}
+
+// keep the last parsed protocols, when files change only the changed
+// protocols and the ones importing them directly or indirectly are
+// parsed and generated again
+type ProtocolWatcher struct {
+	task *CompileTask
+	// nil if last build failed
+	descriptor *ProtocolDescriptor
+	// file full path -> last modify time
+	fileModTimes map[string]time.Time
+	// ProtocolDef.Name -> files generated last time
+	outputFiles map[string][]*OutputFile
+}
+
+func NewProtocolWatcher(task *CompileTask) *ProtocolWatcher {
+	newObj := new(ProtocolWatcher)
+	newObj.task = task
+	newObj.fileModTimes = make(map[string]time.Time)
+	newObj.outputFiles = make(map[string][]*OutputFile)
+
+	return newObj
+}
+
+func (this *ProtocolWatcher) Close() {
+	if this.descriptor != nil {
+		this.descriptor.Close()
+		this.descriptor = nil
+	}
+}
+
+// return full paths of changed files in stable order
+func (this *ProtocolWatcher) CheckChanges() []string {
+	changedFilePaths := make([]string, 0)
+	for filePath, modTime := range this.fileModTimes {
+		if this.getFileModTime(filePath).Equal(modTime) == false {
+			changedFilePaths = append(changedFilePaths, filePath)
+		}
+	}
+	sort.Strings(changedFilePaths)
+
+	return changedFilePaths
+}
+
+// build all if changedFilePaths is nil or last build failed
+func (this *ProtocolWatcher) Build(changedFilePaths []string) {
+	if changedFilePaths != nil {
+		fmt.Printf("[%s] changed:", time.Now().Format("15:04:05"))
+		for _, filePath := range changedFilePaths {
+			fmt.Printf(" %s", filepath.Base(filePath))
+		}
+		fmt.Printf("\n")
+	}
+
+	// protocols can be reused if not affected by changed files
+	var affectedProtoNames map[string]bool = nil
+	reusedProtos := make(map[string]*ProtocolDef)
+	if changedFilePaths != nil && this.descriptor != nil {
+		affectedProtoNames = this.getAffectedProtoNames(changedFilePaths)
+		for name, protoDef := range this.descriptor.ImportedProtos {
+			if affectedProtoNames[name] == false {
+				reusedProtos[name] = protoDef
+			}
+		}
+	}
+
+	// the old descriptor gives its reused protocols to the new one
+	if this.descriptor != nil {
+		for name, protoDef := range this.descriptor.ImportedProtos {
+			if _, ok := reusedProtos[name]; ok == false {
+				protoDef.Close()
+			}
+		}
+		this.descriptor = nil
+	}
+
+	parser := NewProtocolParser()
+	parser.DiagnosticsFormat = this.task.Options.DiagnosticsFormat
+	ok := parser.ParseFilesReusing(this.task.ProtoFilePaths,
+		this.task.Options.SearchPath, reusedProtos)
+
+	// watch every file the parser reached, even if it failed
+	this.updateFileModTimes(parser.Descriptor)
+
+	if ok == false {
+		parser.Close()
+		this.printWaiting()
+		return
+	}
+
+	protoDefs := getProtoDefsToGenerate(parser.Descriptor,
+		this.task.ProtoFilePaths, this.task.Options.WithImports)
+	if protoDefs == nil {
+		parser.Close()
+		this.printWaiting()
+		return
+	}
+	this.descriptor = parser.Descriptor
+
+	// generate affected protocols only
+	generatedFiles := make([]*OutputFile, 0)
+	for _, protoDef := range protoDefs {
+		if affectedProtoNames != nil &&
+			affectedProtoNames[protoDef.Name] == false {
+			continue
+		}
+
+		files := this.task.GenerateCode(
+			this.descriptor, []*ProtocolDef{protoDef}, protoDefs)
+		if files == nil {
+			this.descriptor.Close()
+			this.descriptor = nil
+			this.printWaiting()
+			return
+		}
+		this.outputFiles[protoDef.Name] = files
+		generatedFiles = append(generatedFiles, files...)
+	}
+	printOutputFilesReport(generatedFiles)
+
+	this.task.TouchUnchangedFiles(generatedFiles)
+
+	// dependency file lists outputs of all protocols
+	allOutputFiles := make([]*OutputFile, 0)
+	for _, protoDef := range protoDefs {
+		allOutputFiles = append(allOutputFiles,
+			this.outputFiles[protoDef.Name]...)
+	}
+	this.task.WriteDepFile(this.descriptor, allOutputFiles)
+
+	this.printWaiting()
+}
+
+// changed protocols and all protocols importing them
+func (this *ProtocolWatcher) getAffectedProtoNames(
+	changedFilePaths []string) map[string]bool {
+
+	// ProtocolDef.Name -> names of protocols importing it
+	importedBy := make(map[string][]string)
+	// file full path -> ProtocolDef.Name
+	fileProtoNames := make(map[string]string)
+	for name, protoDef := range this.descriptor.ImportedProtos {
+		fileProtoNames[protoDef.FilePath] = name
+		for _, importDef := range protoDef.Imports {
+			importedBy[importDef.Name] =
+				append(importedBy[importDef.Name], name)
+		}
+	}
+
+	affectedProtoNames := make(map[string]bool)
+	pending := make([]string, 0)
+	for _, filePath := range changedFilePaths {
+		if name, ok := fileProtoNames[filePath]; ok {
+			pending = append(pending, name)
+		}
+	}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if affectedProtoNames[name] {
+			continue
+		}
+		affectedProtoNames[name] = true
+		pending = append(pending, importedBy[name]...)
+	}
+
+	return affectedProtoNames
+}
+
+func (this *ProtocolWatcher) updateFileModTimes(
+	descriptor *ProtocolDescriptor) {
+
+	// files not reached this time, e.g. failed to load, are still watched
+	for filePath := range this.fileModTimes {
+		this.fileModTimes[filePath] = this.getFileModTime(filePath)
+	}
+	for _, filePath := range this.task.ProtoFilePaths {
+		fullPath := UtilGetFullPath(filePath)
+		this.fileModTimes[fullPath] = this.getFileModTime(fullPath)
+	}
+	if descriptor == nil {
+		return
+	}
+	for _, protoDef := range descriptor.ImportedProtos {
+		this.fileModTimes[protoDef.FilePath] =
+			this.getFileModTime(protoDef.FilePath)
+	}
+}
+
+// zero time if file does not exist
+func (this *ProtocolWatcher) getFileModTime(filePath string) time.Time {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+func (this *ProtocolWatcher) printWaiting() {
+	fmt.Printf("watching %d files, waiting for changes\n",
+		len(this.fileModTimes))
+}
@@ -13,6 +13,106 @@ BaseStruct::~BaseStruct()
 {
 }
 
+const void *BaseStruct::field(int index) const
+{
+    return const_cast<BaseStruct *>(this)->mutableField(index);
+}
+
+bool BaseStruct::hasField(int index) const
+{
+    const StructDescriptor *desc = descriptor();
+    if (index < 0 || index >= desc->field_count) {
+        return false;
+    }
+
+    int optional_index = desc->fields[index].optional_index;
+    if (optional_index < 0) {
+        return true;
+    }
+
+    const uint8_t *has_bits =
+        const_cast<BaseStruct *>(this)->mutableHasBits();
+
+    return has_bits[optional_index / 8] & (1 << (optional_index % 8));
+}
+
+void BaseStruct::setHasField(int index, bool has)
+{
+    const StructDescriptor *desc = descriptor();
+    if (index < 0 || index >= desc->field_count) {
+        return;
+    }
+
+    int optional_index = desc->fields[index].optional_index;
+    if (optional_index < 0) {
+        return;
+    }
+
+    uint8_t *has_bits = mutableHasBits();
+    if (has) {
+        has_bits[optional_index / 8] |= (1 << (optional_index % 8));
+    } else {
+        has_bits[optional_index / 8] &= ~(1 << (optional_index % 8));
+    }
+}
+
+std::string BaseStruct::to_json() const
+{
+    JsonValue output;
+    to_json_value(&output);
+
+    return output.write();
+}
+
+bool BaseStruct::from_json(const std::string &json)
+{
+    JsonValue input;
+    if (input.read(json) == false) {
+        return false;
+    }
+
+    return from_json_value(input);
+}
+
+bool BaseStruct::parse_text(const std::string &text)
+{
+    TextParser parser(text);
+    if (parse_text_fields(&parser) == false) {
+        return false;
+    }
+
+    return parser.atEnd();
+}
+
+std::string BaseStruct::dumpString(const std::string &val)
+{
+    std::string ret;
+    ret.reserve(val.size());
+
+    for (size_t i = 0; i < val.size(); ++i) {
+        char c = val[i];
+        if (c == '\\') {
+            ret.append("\\\\");
+        } else if (c == '"') {
+            ret.append("\\\"");
+        } else if (c == '\n') {
+            ret.append("\\n");
+        } else if (c == '\r') {
+            ret.append("\\r");
+        } else if (c == '\t') {
+            ret.append("\\t");
+        } else if ((uint8_t)c < 0x20 || c == 0x7f) {
+            char buffer[8];
+            ::snprintf(buffer, sizeof(buffer), "\\x%02hhX", c);
+            ret.append(buffer);
+        } else {
+            ret.push_back(c);
+        }
+    }
+
+    return ret;
+}
+
 std::string BaseStruct::dumpBytes(const std::string &val)
 {
     if (val.empty()) {
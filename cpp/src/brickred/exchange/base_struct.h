@@ -2,8 +2,13 @@
 #define BRICKRED_EXCHANGE_BASE_STRUCT_H
 
 #include <cstddef>
+#include <cstdint>
 #include <string>
 
+#include <brickred/exchange/descriptor.h>
+#include <brickred/exchange/json_value.h>
+#include <brickred/exchange/text_parser.h>
+
 namespace brickred::exchange {
 
 class BaseStruct {
@@ -18,8 +23,49 @@ public:
     virtual int decode(const char *buffer, size_t size) = 0;
     virtual std::string dump() const = 0;
 
+    virtual void to_json_value(JsonValue *output) const = 0;
+    virtual bool from_json_value(const JsonValue &input) = 0;
+    std::string to_json() const;
+    bool from_json(const std::string &json);
+
+    // read fields in the text format of dump() until end of text
+    // or '}' of current struct
+    virtual bool parse_text_fields(TextParser *parser) = 0;
+    bool parse_text(const std::string &text);
+
+    virtual const StructDescriptor *descriptor() const = 0;
+    // return the address of field at index in descriptor()->fields,
+    // or nullptr if index is out of range
+    virtual void *mutableField(int index) = 0;
+    const void *field(int index) const;
+
+    bool hasField(int index) const;
+    void setHasField(int index, bool has);
+
+    // T must match the c++ type of the field described by
+    // descriptor()->fields[index], e.g. std::vector<int32_t> for list{i32}
+    template <class T>
+    const T *getField(int index) const
+    {
+        return static_cast<const T *>(field(index));
+    }
+
+    template <class T>
+    bool setField(int index, const T &value)
+    {
+        T *p = static_cast<T *>(mutableField(index));
+        if (p == nullptr) {
+            return false;
+        }
+        *p = value;
+        setHasField(index, true);
+        return true;
+    }
+
 protected:
+    static std::string dumpString(const std::string &val);
     static std::string dumpBytes(const std::string &val);
+    virtual uint8_t *mutableHasBits() { return nullptr; }
 };
 
 } // namespace brickred::exchange
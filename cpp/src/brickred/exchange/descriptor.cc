@@ -0,0 +1,41 @@
+#include <brickred/exchange/descriptor.h>
+
+#include <cstring>
+
+namespace brickred::exchange {
+
+const EnumItemDescriptor *EnumDescriptor::findItemByName(
+    const char *name) const
+{
+    for (int i = 0; i < item_count; ++i) {
+        if (::strcmp(items[i].name, name) == 0) {
+            return &items[i];
+        }
+    }
+
+    return nullptr;
+}
+
+const EnumItemDescriptor *EnumDescriptor::findItemByValue(int value) const
+{
+    for (int i = 0; i < item_count; ++i) {
+        if (items[i].value == value) {
+            return &items[i];
+        }
+    }
+
+    return nullptr;
+}
+
+int StructDescriptor::findFieldIndex(const char *name) const
+{
+    for (int i = 0; i < field_count; ++i) {
+        if (::strcmp(fields[i].name, name) == 0) {
+            return i;
+        }
+    }
+
+    return -1;
+}
+
+} // namespace brickred::exchange
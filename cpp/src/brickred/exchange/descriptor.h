@@ -0,0 +1,73 @@
+#ifndef BRICKRED_EXCHANGE_DESCRIPTOR_H
+#define BRICKRED_EXCHANGE_DESCRIPTOR_H
+
+namespace brickred::exchange {
+
+class BaseStruct;
+
+enum class FieldType {
+    I8,
+    U8,
+    I16,
+    U16,
+    I32,
+    U32,
+    I64,
+    U64,
+    I16V,
+    U16V,
+    I32V,
+    U32V,
+    I64V,
+    U64V,
+    STRING,
+    BYTES,
+    BOOL,
+    ENUM,
+    STRUCT,
+};
+
+struct EnumItemDescriptor {
+    const char *name;
+    int value;
+};
+
+struct EnumDescriptor {
+    const char *name;
+    const char *full_name;
+    const EnumItemDescriptor *items;
+    int item_count;
+
+    const EnumItemDescriptor *findItemByName(const char *name) const;
+    const EnumItemDescriptor *findItemByValue(int value) const;
+};
+
+struct StructDescriptor;
+
+struct FieldDescriptor {
+    const char *name;
+    FieldType type;
+    bool is_list;
+    // -1 if field is required
+    int optional_index;
+    // only valid when type is ENUM
+    const EnumDescriptor *ref_enum;
+    // only valid when type is STRUCT
+    const StructDescriptor *ref_struct;
+};
+
+struct StructDescriptor {
+    using CreateFunc = BaseStruct *(*)();
+
+    const char *name;
+    const char *full_name;
+    const FieldDescriptor *fields;
+    int field_count;
+    CreateFunc create;
+
+    int findFieldIndex(const char *name) const;
+};
+
+} // namespace brickred::exchange
+
+#endif
@@ -0,0 +1,663 @@
+#include <brickred/exchange/json_value.h>
+
+#include <cstdio>
+#include <cstring>
+#include <utility>
+
+namespace brickred::exchange {
+
+static const int s_max_depth = 512;
+
+static const char s_base64_chars[] =
+    "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+
+static std::string encodeBase64(const std::string &val)
+{
+    std::string ret;
+    ret.reserve((val.size() + 2) / 3 * 4);
+
+    size_t i = 0;
+    for (; i + 2 < val.size(); i += 3) {
+        uint32_t n = (uint32_t)(uint8_t)val[i] << 16 |
+                     (uint32_t)(uint8_t)val[i + 1] << 8 |
+                     (uint32_t)(uint8_t)val[i + 2];
+        ret.push_back(s_base64_chars[(n >> 18) & 0x3f]);
+        ret.push_back(s_base64_chars[(n >> 12) & 0x3f]);
+        ret.push_back(s_base64_chars[(n >> 6) & 0x3f]);
+        ret.push_back(s_base64_chars[n & 0x3f]);
+    }
+    if (i + 1 == val.size()) {
+        uint32_t n = (uint32_t)(uint8_t)val[i] << 16;
+        ret.push_back(s_base64_chars[(n >> 18) & 0x3f]);
+        ret.push_back(s_base64_chars[(n >> 12) & 0x3f]);
+        ret.append("==");
+    } else if (i + 2 == val.size()) {
+        uint32_t n = (uint32_t)(uint8_t)val[i] << 16 |
+                     (uint32_t)(uint8_t)val[i + 1] << 8;
+        ret.push_back(s_base64_chars[(n >> 18) & 0x3f]);
+        ret.push_back(s_base64_chars[(n >> 12) & 0x3f]);
+        ret.push_back(s_base64_chars[(n >> 6) & 0x3f]);
+        ret.push_back('=');
+    }
+
+    return ret;
+}
+
+static int decodeBase64Char(char c)
+{
+    if (c >= 'A' && c <= 'Z') {
+        return c - 'A';
+    } else if (c >= 'a' && c <= 'z') {
+        return c - 'a' + 26;
+    } else if (c >= '0' && c <= '9') {
+        return c - '0' + 52;
+    } else if (c == '+') {
+        return 62;
+    } else if (c == '/') {
+        return 63;
+    } else {
+        return -1;
+    }
+}
+
+static bool decodeBase64(const std::string &val, std::string *output)
+{
+    std::string ret;
+    ret.reserve(val.size() / 4 * 3);
+
+    uint32_t n = 0;
+    int bits = 0;
+    size_t padding = 0;
+
+    for (size_t i = 0; i < val.size(); ++i) {
+        if (val[i] == '=') {
+            ++padding;
+            continue;
+        }
+        if (padding > 0) {
+            return false;
+        }
+
+        int c = decodeBase64Char(val[i]);
+        if (c < 0) {
+            return false;
+        }
+        n = (n << 6) | (uint32_t)c;
+        bits += 6;
+        if (bits >= 8) {
+            bits -= 8;
+            ret.push_back((char)((n >> bits) & 0xff));
+        }
+    }
+    if (padding > 2 || bits >= 6) {
+        return false;
+    }
+
+    output->swap(ret);
+    return true;
+}
+
+static void skipWhitespace(const char *&p, const char *end)
+{
+    while (p < end &&
+           (*p == ' ' || *p == '\t' || *p == '\n' || *p == '\r')) {
+        ++p;
+    }
+}
+
+static bool readHex4(const char *&p, const char *end, uint32_t *val)
+{
+    if (end - p < 4) {
+        return false;
+    }
+
+    uint32_t n = 0;
+    for (int i = 0; i < 4; ++i) {
+        char c = p[i];
+        n <<= 4;
+        if (c >= '0' && c <= '9') {
+            n |= c - '0';
+        } else if (c >= 'a' && c <= 'f') {
+            n |= c - 'a' + 10;
+        } else if (c >= 'A' && c <= 'F') {
+            n |= c - 'A' + 10;
+        } else {
+            return false;
+        }
+    }
+    p += 4;
+    *val = n;
+
+    return true;
+}
+
+static void appendUtf8(uint32_t code_point, std::string *output)
+{
+    if (code_point < 0x80) {
+        output->push_back((char)code_point);
+    } else if (code_point < 0x800) {
+        output->push_back((char)(0xc0 | (code_point >> 6)));
+        output->push_back((char)(0x80 | (code_point & 0x3f)));
+    } else if (code_point < 0x10000) {
+        output->push_back((char)(0xe0 | (code_point >> 12)));
+        output->push_back((char)(0x80 | ((code_point >> 6) & 0x3f)));
+        output->push_back((char)(0x80 | (code_point & 0x3f)));
+    } else {
+        output->push_back((char)(0xf0 | (code_point >> 18)));
+        output->push_back((char)(0x80 | ((code_point >> 12) & 0x3f)));
+        output->push_back((char)(0x80 | ((code_point >> 6) & 0x3f)));
+        output->push_back((char)(0x80 | (code_point & 0x3f)));
+    }
+}
+
+static bool readString(const char *&p, const char *end, std::string *output)
+{
+    // skip '"'
+    ++p;
+
+    for (;;) {
+        if (p >= end) {
+            return false;
+        }
+
+        char c = *p++;
+        if (c == '"') {
+            return true;
+        } else if ((uint8_t)c < 0x20) {
+            return false;
+        } else if (c != '\\') {
+            output->push_back(c);
+            continue;
+        }
+
+        if (p >= end) {
+            return false;
+        }
+        c = *p++;
+        if (c == '"' || c == '\\' || c == '/') {
+            output->push_back(c);
+        } else if (c == 'b') {
+            output->push_back('\b');
+        } else if (c == 'f') {
+            output->push_back('\f');
+        } else if (c == 'n') {
+            output->push_back('\n');
+        } else if (c == 'r') {
+            output->push_back('\r');
+        } else if (c == 't') {
+            output->push_back('\t');
+        } else if (c == 'u') {
+            uint32_t code_point;
+            if (readHex4(p, end, &code_point) == false) {
+                return false;
+            }
+            if (code_point >= 0xd800 && code_point <= 0xdbff) {
+                uint32_t low;
+                if (end - p < 2 || p[0] != '\\' || p[1] != 'u') {
+                    return false;
+                }
+                p += 2;
+                if (readHex4(p, end, &low) == false ||
+                    low < 0xdc00 || low > 0xdfff) {
+                    return false;
+                }
+                code_point = 0x10000 +
+                    ((code_point - 0xd800) << 10) + (low - 0xdc00);
+            } else if (code_point >= 0xdc00 && code_point <= 0xdfff) {
+                return false;
+            }
+            appendUtf8(code_point, output);
+        } else {
+            return false;
+        }
+    }
+}
+
+static bool readNumber(const char *&p, const char *end, std::string *output)
+{
+    const char *start = p;
+
+    if (p < end && *p == '-') {
+        ++p;
+    }
+    if (p >= end) {
+        return false;
+    }
+    if (*p == '0') {
+        ++p;
+    } else if (*p >= '1' && *p <= '9') {
+        while (p < end && *p >= '0' && *p <= '9') {
+            ++p;
+        }
+    } else {
+        return false;
+    }
+    if (p < end && *p == '.') {
+        ++p;
+        if (p >= end || *p < '0' || *p > '9') {
+            return false;
+        }
+        while (p < end && *p >= '0' && *p <= '9') {
+            ++p;
+        }
+    }
+    if (p < end && (*p == 'e' || *p == 'E')) {
+        ++p;
+        if (p < end && (*p == '+' || *p == '-')) {
+            ++p;
+        }
+        if (p >= end || *p < '0' || *p > '9') {
+            return false;
+        }
+        while (p < end && *p >= '0' && *p <= '9') {
+            ++p;
+        }
+    }
+
+    output->assign(start, p - start);
+    return true;
+}
+
+static bool readKeyword(const char *&p, const char *end, const char *keyword)
+{
+    size_t length = ::strlen(keyword);
+    if ((size_t)(end - p) < length ||
+        ::memcmp(p, keyword, length) != 0) {
+        return false;
+    }
+    p += length;
+
+    return true;
+}
+
+static bool parseUInt64(const std::string &text, uint64_t *val)
+{
+    if (text.empty()) {
+        return false;
+    }
+
+    uint64_t n = 0;
+    for (size_t i = 0; i < text.size(); ++i) {
+        char c = text[i];
+        if (c < '0' || c > '9') {
+            return false;
+        }
+        uint64_t digit = c - '0';
+        if (n > (UINT64_MAX - digit) / 10) {
+            return false;
+        }
+        n = n * 10 + digit;
+    }
+    *val = n;
+
+    return true;
+}
+
+static void writeString(const std::string &val, std::string *output)
+{
+    output->push_back('"');
+    for (size_t i = 0; i < val.size(); ++i) {
+        char c = val[i];
+        if (c == '"') {
+            output->append("\\\"");
+        } else if (c == '\\') {
+            output->append("\\\\");
+        } else if (c == '\b') {
+            output->append("\\b");
+        } else if (c == '\f') {
+            output->append("\\f");
+        } else if (c == '\n') {
+            output->append("\\n");
+        } else if (c == '\r') {
+            output->append("\\r");
+        } else if (c == '\t') {
+            output->append("\\t");
+        } else if ((uint8_t)c < 0x20) {
+            char buffer[8];
+            ::snprintf(buffer, sizeof(buffer), "\\u%04x", c);
+            output->append(buffer);
+        } else {
+            output->push_back(c);
+        }
+    }
+    output->push_back('"');
+}
+
+JsonValue::JsonValue() :
+    type_(Type::NUL),
+    bool_(false)
+{
+}
+
+JsonValue::~JsonValue()
+{
+}
+
+void JsonValue::setNull()
+{
+    type_ = Type::NUL;
+    str_.clear();
+    items_.clear();
+    names_.clear();
+}
+
+void JsonValue::setBool(bool val)
+{
+    setNull();
+    type_ = Type::BOOL;
+    bool_ = val;
+}
+
+void JsonValue::setInt(int64_t val)
+{
+    setNull();
+    type_ = Type::NUMBER;
+    str_ = std::to_string(val);
+}
+
+void JsonValue::setInt64(int64_t val)
+{
+    setNull();
+    type_ = Type::STRING;
+    str_ = std::to_string(val);
+}
+
+void JsonValue::setUInt64(uint64_t val)
+{
+    setNull();
+    type_ = Type::STRING;
+    str_ = std::to_string(val);
+}
+
+void JsonValue::setString(const std::string &val)
+{
+    setNull();
+    type_ = Type::STRING;
+    str_ = val;
+}
+
+void JsonValue::setBytes(const std::string &val)
+{
+    setNull();
+    type_ = Type::STRING;
+    str_ = encodeBase64(val);
+}
+
+void JsonValue::setArray()
+{
+    setNull();
+    type_ = Type::ARRAY;
+}
+
+void JsonValue::setObject()
+{
+    setNull();
+    type_ = Type::OBJECT;
+}
+
+JsonValue &JsonValue::append()
+{
+    items_.emplace_back();
+    return items_.back();
+}
+
+JsonValue &JsonValue::addMember(const std::string &name)
+{
+    names_.push_back(name);
+    items_.emplace_back();
+    return items_.back();
+}
+
+bool JsonValue::isStruct() const
+{
+    if (type_ == Type::OBJECT) {
+        return true;
+    }
+    if (type_ == Type::ARRAY && items_.empty()) {
+        return true;
+    }
+
+    return false;
+}
+
+const JsonValue *JsonValue::findMember(const std::string &name) const
+{
+    if (type_ != Type::OBJECT) {
+        return nullptr;
+    }
+
+    for (size_t i = 0; i < names_.size(); ++i) {
+        if (names_[i] == name) {
+            return &items_[i];
+        }
+    }
+
+    return nullptr;
+}
+
+bool JsonValue::hasMember(const std::string &name) const
+{
+    const JsonValue *member = findMember(name);
+    if (member == nullptr || member->type_ == Type::NUL) {
+        return false;
+    }
+
+    return true;
+}
+
+bool JsonValue::getBool(bool *val) const
+{
+    if (type_ != Type::BOOL) {
+        return false;
+    }
+    *val = bool_;
+
+    return true;
+}
+
+bool JsonValue::getInt64(int64_t *val) const
+{
+    if (type_ != Type::NUMBER && type_ != Type::STRING) {
+        return false;
+    }
+
+    uint64_t n;
+    if (str_.empty() == false && str_[0] == '-') {
+        if (parseUInt64(str_.substr(1), &n) == false ||
+            n > (uint64_t)INT64_MAX + 1) {
+            return false;
+        }
+        *val = (int64_t)(0 - n);
+    } else {
+        if (parseUInt64(str_, &n) == false ||
+            n > (uint64_t)INT64_MAX) {
+            return false;
+        }
+        *val = (int64_t)n;
+    }
+
+    return true;
+}
+
+bool JsonValue::getUInt64(uint64_t *val) const
+{
+    if (type_ != Type::NUMBER && type_ != Type::STRING) {
+        return false;
+    }
+
+    return parseUInt64(str_, val);
+}
+
+bool JsonValue::getString(std::string *val) const
+{
+    if (type_ != Type::STRING) {
+        return false;
+    }
+    *val = str_;
+
+    return true;
+}
+
+bool JsonValue::getBytes(std::string *val) const
+{
+    if (type_ != Type::STRING) {
+        return false;
+    }
+
+    return decodeBase64(str_, val);
+}
+
+std::string JsonValue::write() const
+{
+    std::string output;
+    writeToString(&output);
+
+    return output;
+}
+
+bool JsonValue::read(const std::string &json)
+{
+    const char *p = json.data();
+    const char *end = p + json.size();
+
+    JsonValue value;
+    if (value.readFromString(p, end, 0) == false) {
+        return false;
+    }
+    skipWhitespace(p, end);
+    if (p != end) {
+        return false;
+    }
+    *this = std::move(value);
+
+    return true;
+}
+
+void JsonValue::writeToString(std::string *output) const
+{
+    if (type_ == Type::NUL) {
+        output->append("null");
+    } else if (type_ == Type::BOOL) {
+        output->append(bool_ ? "true" : "false");
+    } else if (type_ == Type::NUMBER) {
+        output->append(str_);
+    } else if (type_ == Type::STRING) {
+        writeString(str_, output);
+    } else if (type_ == Type::ARRAY) {
+        output->push_back('[');
+        for (size_t i = 0; i < items_.size(); ++i) {
+            if (i > 0) {
+                output->push_back(',');
+            }
+            items_[i].writeToString(output);
+        }
+        output->push_back(']');
+    } else if (type_ == Type::OBJECT) {
+        output->push_back('{');
+        for (size_t i = 0; i < items_.size(); ++i) {
+            if (i > 0) {
+                output->push_back(',');
+            }
+            writeString(names_[i], output);
+            output->push_back(':');
+            items_[i].writeToString(output);
+        }
+        output->push_back('}');
+    }
+}
+
+bool JsonValue::readFromString(const char *&p, const char *end, int depth)
+{
+    if (depth > s_max_depth) {
+        return false;
+    }
+
+    skipWhitespace(p, end);
+    if (p >= end) {
+        return false;
+    }
+
+    if (*p == '{') {
+        setObject();
+        ++p;
+        skipWhitespace(p, end);
+        if (p < end && *p == '}') {
+            ++p;
+            return true;
+        }
+        for (;;) {
+            skipWhitespace(p, end);
+            if (p >= end || *p != '"') {
+                return false;
+            }
+            std::string name;
+            if (readString(p, end, &name) == false) {
+                return false;
+            }
+            skipWhitespace(p, end);
+            if (p >= end || *p != ':') {
+                return false;
+            }
+            ++p;
+            if (addMember(name).readFromString(p, end, depth + 1) == false) {
+                return false;
+            }
+            skipWhitespace(p, end);
+            if (p >= end) {
+                return false;
+            } else if (*p == ',') {
+                ++p;
+            } else if (*p == '}') {
+                ++p;
+                return true;
+            } else {
+                return false;
+            }
+        }
+    } else if (*p == '[') {
+        setArray();
+        ++p;
+        skipWhitespace(p, end);
+        if (p < end && *p == ']') {
+            ++p;
+            return true;
+        }
+        for (;;) {
+            if (append().readFromString(p, end, depth + 1) == false) {
+                return false;
+            }
+            skipWhitespace(p, end);
+            if (p >= end) {
+                return false;
+            } else if (*p == ',') {
+                ++p;
+            } else if (*p == ']') {
+                ++p;
+                return true;
+            } else {
+                return false;
+            }
+        }
+    } else if (*p == '"') {
+        setNull();
+        type_ = Type::STRING;
+        return readString(p, end, &str_);
+    } else if (*p == '-' || (*p >= '0' && *p <= '9')) {
+        setNull();
+        type_ = Type::NUMBER;
+        return readNumber(p, end, &str_);
+    } else if (readKeyword(p, end, "true")) {
+        setBool(true);
+        return true;
+    } else if (readKeyword(p, end, "false")) {
+        setBool(false);
+        return true;
+    } else if (readKeyword(p, end, "null")) {
+        setNull();
+        return true;
+    } else {
+        return false;
+    }
+}
+
+} // namespace brickred::exchange
@@ -0,0 +1,85 @@
+#ifndef BRICKRED_EXCHANGE_JSON_VALUE_H
+#define BRICKRED_EXCHANGE_JSON_VALUE_H
+
+#include <cstddef>
+#include <cstdint>
+#include <string>
+#include <vector>
+
+namespace brickred::exchange {
+
+// minimal json document used by the generated to_json()/from_json()
+// conventions shared with the php and c# runtime:
+// - 64-bit integers are written as decimal strings
+// - bytes are written as base64 strings
+class JsonValue {
+public:
+    enum class Type {
+        NUL,
+        BOOL,
+        NUMBER,
+        STRING,
+        ARRAY,
+        OBJECT,
+    };
+
+    JsonValue();
+    ~JsonValue();
+
+    Type type() const { return type_; }
+
+    void setNull();
+    void setBool(bool val);
+    void setInt(int64_t val);
+    void setInt64(int64_t val);
+    void setUInt64(uint64_t val);
+    void setString(const std::string &val);
+    void setBytes(const std::string &val);
+    void setArray();
+    void setObject();
+
+    // only valid when type is ARRAY
+    JsonValue &append();
+    // only valid when type is OBJECT
+    JsonValue &addMember(const std::string &name);
+
+    // number of items when type is ARRAY or OBJECT
+    size_t size() const { return items_.size(); }
+    const JsonValue &at(size_t index) const { return items_[index]; }
+    // true if type is OBJECT, or ARRAY without items, which php writes
+    // for a struct without any field set
+    bool isStruct() const;
+    // return nullptr if type is not OBJECT or member not found
+    const JsonValue *findMember(const std::string &name) const;
+    // return false if member not found or member is null
+    bool hasMember(const std::string &name) const;
+
+    // return false if type mismatch or value out of range
+    bool getBool(bool *val) const;
+    // accept both number and decimal string
+    bool getInt64(int64_t *val) const;
+    bool getUInt64(uint64_t *val) const;
+    bool getString(std::string *val) const;
+    bool getBytes(std::string *val) const;
+
+    std::string write() const;
+    bool read(const std::string &json);
+
+private:
+    void writeToString(std::string *output) const;
+    bool readFromString(const char *&p, const char *end, int depth);
+
+private:
+    Type type_;
+    bool bool_;
+    // text of number, content of string
+    std::string str_;
+    // items of array, members of object
+    std::vector<JsonValue> items_;
+    // names of members of object
+    std::vector<std::string> names_;
+};
+
+} // namespace brickred::exchange
+
+#endif
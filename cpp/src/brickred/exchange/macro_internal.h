@@ -3,6 +3,25 @@
 
 #include <cstdint>
 #include <cstring>
+#include <limits>
+#include <type_traits>
+
+namespace brickred::exchange {
+
+// integer read from json or text must fit in the type of field,
+// enum is checked against its underlying type
+template <class T>
+inline bool isIntInRange(int64_t n)
+{
+    if constexpr (std::is_enum_v<T>) {
+        return isIntInRange<std::underlying_type_t<T>>(n);
+    } else {
+        return n >= (int64_t)std::numeric_limits<T>::min() &&
+               n <= (int64_t)std::numeric_limits<T>::max();
+    }
+}
+
+} // namespace brickred::exchange
 
 #define READ_INT8(_var)             \
     do {                            \
@@ -294,4 +313,108 @@
         }                                          \
     } while (0)                                    \
 
+#define READ_JSON_VALUE(_json, _var, _get_func)             \
+    do {                                                    \
+        const brickred::exchange::JsonValue *v = _json;     \
+        if (v == nullptr || v->_get_func(&_var) == false) { \
+            return false;                                   \
+        }                                                   \
+    } while (0)                                             \
+
+#define READ_JSON_INT(_json, _var)                                          \
+    do {                                                                    \
+        int64_t n;                                                          \
+        READ_JSON_VALUE(_json, n, getInt64);                                \
+        if (brickred::exchange::isIntInRange<decltype(_var)>(n) == false) { \
+            return false;                                                   \
+        }                                                                   \
+        _var = (decltype(_var))n;                                           \
+    } while (0)                                                             \
+
+#define READ_JSON_INT64(_json, _var) READ_JSON_VALUE(_json, _var, getInt64)
+#define READ_JSON_UINT64(_json, _var) READ_JSON_VALUE(_json, _var, getUInt64)
+#define READ_JSON_BOOL(_json, _var) READ_JSON_VALUE(_json, _var, getBool)
+#define READ_JSON_STRING(_json, _var) READ_JSON_VALUE(_json, _var, getString)
+#define READ_JSON_BYTES(_json, _var) READ_JSON_VALUE(_json, _var, getBytes)
+
+#define READ_JSON_STRUCT(_json, _var)                            \
+    do {                                                         \
+        const brickred::exchange::JsonValue *v = _json;          \
+        if (v == nullptr || _var.from_json_value(*v) == false) { \
+            return false;                                        \
+        }                                                        \
+    } while (0)                                                  \
+
+#define READ_JSON_LIST(_json, _var, _read_func)                        \
+    do {                                                               \
+        const brickred::exchange::JsonValue *l = _json;                \
+        if (l == nullptr ||                                            \
+            l->type() != brickred::exchange::JsonValue::Type::ARRAY) { \
+            return false;                                              \
+        }                                                              \
+        _var.clear();                                                  \
+        _var.reserve(l->size());                                       \
+        for (size_t i = 0; i < l->size(); ++i) {                       \
+            decltype(_var)::value_type e;                              \
+            _read_func(&l->at(i), e);                                  \
+            _var.push_back(e);                                         \
+        }                                                              \
+    } while (0)                                                        \
+
+#define WRITE_JSON_INT(_json, _var) (_json).setInt((int64_t)(_var))
+#define WRITE_JSON_INT64(_json, _var) (_json).setInt64(_var)
+#define WRITE_JSON_UINT64(_json, _var) (_json).setUInt64(_var)
+#define WRITE_JSON_BOOL(_json, _var) (_json).setBool(_var)
+#define WRITE_JSON_STRING(_json, _var) (_json).setString(_var)
+#define WRITE_JSON_BYTES(_json, _var) (_json).setBytes(_var)
+#define WRITE_JSON_STRUCT(_json, _var) (_var).to_json_value(&(_json))
+
+#define WRITE_JSON_LIST(_json, _var, _write_func)  \
+    do {                                           \
+        brickred::exchange::JsonValue &l = _json;  \
+        l.setArray();                              \
+        for (size_t i = 0; i < _var.size(); ++i) { \
+            _write_func(l.append(), _var[i]);      \
+        }                                          \
+    } while (0)                                    \
+
+#define READ_TEXT_VALUE(_var, _read_func)         \
+    do {                                          \
+        if (parser->_read_func(&_var) == false) { \
+            return false;                         \
+        }                                         \
+    } while (0)                                   \
+
+#define READ_TEXT_INT(_var)                                                 \
+    do {                                                                    \
+        int64_t n;                                                          \
+        READ_TEXT_VALUE(n, readInt64);                                      \
+        if (brickred::exchange::isIntInRange<decltype(_var)>(n) == false) { \
+            return false;                                                   \
+        }                                                                   \
+        _var = (decltype(_var))n;                                           \
+    } while (0)                                                             \
+
+#define READ_TEXT_INT64(_var) READ_TEXT_VALUE(_var, readInt64)
+#define READ_TEXT_UINT64(_var) READ_TEXT_VALUE(_var, readUInt64)
+#define READ_TEXT_BOOL(_var) READ_TEXT_VALUE(_var, readBool)
+#define READ_TEXT_STRING(_var) READ_TEXT_VALUE(_var, readString)
+#define READ_TEXT_BYTES(_var) READ_TEXT_VALUE(_var, readBytes)
+
+#define READ_TEXT_STRUCT(_var)                         \
+    do {                                               \
+        if (parser->readStructBegin() == false ||      \
+            _var.parse_text_fields(parser) == false || \
+            parser->readStructEnd() == false) {        \
+            return false;                              \
+        }                                              \
+    } while (0)                                        \
+
+#define READ_TEXT_LIST_ITEM(_var, _read_func) \
+    do {                                      \
+        decltype(_var)::value_type e;         \
+        _read_func(e);                        \
+        _var.push_back(e);                    \
+    } while (0)                               \
+
 #endif
@@ -0,0 +1,326 @@
+#include <brickred/exchange/text_parser.h>
+
+namespace brickred::exchange {
+
+static bool isIdentifierChar(char c, bool first)
+{
+    if ((c >= 'a' && c <= 'z') ||
+        (c >= 'A' && c <= 'Z') ||
+        c == '_') {
+        return true;
+    }
+    if (first == false && c >= '0' && c <= '9') {
+        return true;
+    }
+
+    return false;
+}
+
+static int hexValue(char c)
+{
+    if (c >= '0' && c <= '9') {
+        return c - '0';
+    } else if (c >= 'a' && c <= 'f') {
+        return c - 'a' + 10;
+    } else if (c >= 'A' && c <= 'F') {
+        return c - 'A' + 10;
+    } else {
+        return -1;
+    }
+}
+
+static bool parseUInt64(const std::string &text, size_t start,
+                        uint64_t *val)
+{
+    if (start >= text.size()) {
+        return false;
+    }
+
+    uint64_t n = 0;
+    for (size_t i = start; i < text.size(); ++i) {
+        char c = text[i];
+        if (c < '0' || c > '9') {
+            return false;
+        }
+        uint64_t digit = c - '0';
+        if (n > (UINT64_MAX - digit) / 10) {
+            return false;
+        }
+        n = n * 10 + digit;
+    }
+    *val = n;
+
+    return true;
+}
+
+TextParser::TextParser(const std::string &text) :
+    text_(text),
+    pos_(0),
+    error_(false)
+{
+}
+
+TextParser::~TextParser()
+{
+}
+
+bool TextParser::readFieldName(std::string *name)
+{
+    if (error_) {
+        return false;
+    }
+
+    skipWhitespace();
+    if (pos_ >= text_.size() || text_[pos_] == '}') {
+        return false;
+    }
+    if (isIdentifierChar(text_[pos_], true) == false) {
+        return setError();
+    }
+
+    size_t start = pos_;
+    while (pos_ < text_.size() && isIdentifierChar(text_[pos_], false)) {
+        ++pos_;
+    }
+    name->assign(text_, start, pos_ - start);
+
+    skipWhitespace();
+    if (pos_ >= text_.size() || text_[pos_] != ':') {
+        return setError();
+    }
+    ++pos_;
+
+    return true;
+}
+
+bool TextParser::readInt64(int64_t *val)
+{
+    size_t start = pos_;
+    std::string token;
+    if (readToken(&token) == false) {
+        return false;
+    }
+
+    uint64_t n;
+    if (token[0] == '-') {
+        if (parseUInt64(token, 1, &n) == false ||
+            n > (uint64_t)INT64_MAX + 1) {
+            pos_ = start;
+            return setError();
+        }
+        *val = (int64_t)(0 - n);
+    } else {
+        if (parseUInt64(token, 0, &n) == false ||
+            n > (uint64_t)INT64_MAX) {
+            pos_ = start;
+            return setError();
+        }
+        *val = (int64_t)n;
+    }
+
+    return true;
+}
+
+bool TextParser::readUInt64(uint64_t *val)
+{
+    size_t start = pos_;
+    std::string token;
+    if (readToken(&token) == false) {
+        return false;
+    }
+    if (parseUInt64(token, 0, val) == false) {
+        pos_ = start;
+        return setError();
+    }
+
+    return true;
+}
+
+bool TextParser::readBool(bool *val)
+{
+    size_t start = pos_;
+    std::string token;
+    if (readToken(&token) == false) {
+        return false;
+    }
+
+    if (token == "1" || token == "true") {
+        *val = true;
+    } else if (token == "0" || token == "false") {
+        *val = false;
+    } else {
+        pos_ = start;
+        return setError();
+    }
+
+    return true;
+}
+
+bool TextParser::readString(std::string *val)
+{
+    if (error_) {
+        return false;
+    }
+
+    skipWhitespace();
+    if (pos_ >= text_.size() || text_[pos_] != '"') {
+        return setError();
+    }
+    ++pos_;
+
+    std::string ret;
+    for (;;) {
+        if (pos_ >= text_.size()) {
+            return setError();
+        }
+
+        char c = text_[pos_];
+        if (c == '"') {
+            ++pos_;
+            break;
+        } else if (c != '\\') {
+            ret.push_back(c);
+            ++pos_;
+            continue;
+        }
+
+        if (pos_ + 1 >= text_.size()) {
+            return setError();
+        }
+        c = text_[pos_ + 1];
+        if (c == '\\' || c == '"') {
+            ret.push_back(c);
+            pos_ += 2;
+        } else if (c == 'n') {
+            ret.push_back('\n');
+            pos_ += 2;
+        } else if (c == 'r') {
+            ret.push_back('\r');
+            pos_ += 2;
+        } else if (c == 't') {
+            ret.push_back('\t');
+            pos_ += 2;
+        } else if (c == 'x') {
+            if (pos_ + 3 >= text_.size()) {
+                return setError();
+            }
+            int high = hexValue(text_[pos_ + 2]);
+            int low = hexValue(text_[pos_ + 3]);
+            if (high < 0 || low < 0 || high >= 8) {
+                return setError();
+            }
+            ret.push_back((char)(high << 4 | low));
+            pos_ += 4;
+        } else {
+            return setError();
+        }
+    }
+    val->swap(ret);
+
+    return true;
+}
+
+bool TextParser::readBytes(std::string *val)
+{
+    size_t start = pos_;
+    std::string hex;
+    if (readString(&hex) == false) {
+        return false;
+    }
+
+    std::string ret;
+    for (size_t i = 0; i < hex.size(); i += 3) {
+        if (i + 2 > hex.size() ||
+            (i + 2 < hex.size() && hex[i + 2] != '-') ||
+            (i + 2 == hex.size() - 1)) {
+            pos_ = start;
+            return setError();
+        }
+        int high = hexValue(hex[i]);
+        int low = hexValue(hex[i + 1]);
+        if (high < 0 || low < 0) {
+            pos_ = start;
+            return setError();
+        }
+        ret.push_back((char)(high << 4 | low));
+    }
+    val->swap(ret);
+
+    return true;
+}
+
+bool TextParser::readStructBegin()
+{
+    if (error_) {
+        return false;
+    }
+
+    skipWhitespace();
+    if (pos_ >= text_.size() || text_[pos_] != '{') {
+        return setError();
+    }
+    ++pos_;
+
+    return true;
+}
+
+bool TextParser::readStructEnd()
+{
+    if (error_) {
+        return false;
+    }
+
+    skipWhitespace();
+    if (pos_ >= text_.size() || text_[pos_] != '}') {
+        return setError();
+    }
+    ++pos_;
+
+    return true;
+}
+
+bool TextParser::atEnd()
+{
+    skipWhitespace();
+    return pos_ >= text_.size();
+}
+
+void TextParser::skipWhitespace()
+{
+    while (pos_ < text_.size() &&
+           (text_[pos_] == ' ' || text_[pos_] == '\t' ||
+            text_[pos_] == '\n' || text_[pos_] == '\r')) {
+        ++pos_;
+    }
+}
+
+bool TextParser::readToken(std::string *token)
+{
+    if (error_) {
+        return false;
+    }
+
+    skipWhitespace();
+
+    size_t start = pos_;
+    while (pos_ < text_.size() &&
+           text_[pos_] != ' ' && text_[pos_] != '\t' &&
+           text_[pos_] != '\n' && text_[pos_] != '\r' &&
+           text_[pos_] != '}') {
+        ++pos_;
+    }
+    if (pos_ == start) {
+        return setError();
+    }
+    token->assign(text_, start, pos_ - start);
+
+    return true;
+}
+
+bool TextParser::setError()
+{
+    error_ = true;
+    return false;
+}
+
+} // namespace brickred::exchange
@@ -0,0 +1,54 @@
+#ifndef BRICKRED_EXCHANGE_TEXT_PARSER_H
+#define BRICKRED_EXCHANGE_TEXT_PARSER_H
+
+#include <cstddef>
+#include <cstdint>
+#include <string>
+
+namespace brickred::exchange {
+
+// reader of the text format produced by the generated dump()
+// - fields are written as `name: value` separated by whitespace
+// - list fields are written as one `name: value` per item
+// - optional fields not set are omitted
+// - integers and enums are decimal, bool is 1 or 0
+// - string is quoted, with \\ \" \n \r \t and \xHH escapes
+// - bytes is quoted uppercase hex separated by '-', e.g. "0A-FF"
+// - struct is written as `name: { fields }`
+class TextParser {
+public:
+    explicit TextParser(const std::string &text);
+    ~TextParser();
+
+    // return false when reach end of text or '}' of current struct,
+    // or when error occurred, check hasError() to distinguish
+    bool readFieldName(std::string *name);
+
+    bool readInt64(int64_t *val);
+    bool readUInt64(uint64_t *val);
+    bool readBool(bool *val);
+    bool readString(std::string *val);
+    bool readBytes(std::string *val);
+    bool readStructBegin();
+    bool readStructEnd();
+
+    // return true if only whitespace left
+    bool atEnd();
+    bool hasError() const { return error_; }
+    // byte offset where parsing stopped
+    size_t offset() const { return pos_; }
+
+private:
+    void skipWhitespace();
+    bool readToken(std::string *token);
+    bool setError();
+
+private:
+    const std::string &text_;
+    size_t pos_;
+    bool error_;
+};
+
+} // namespace brickred::exchange
+
+#endif
@@ -11,12 +11,69 @@ namespace Brickred.Exchange
         public abstract void EncodeToStream(CodecOutputStream s);
         public abstract void DecodeFromStream(CodecInputStream s);
         public abstract string Dump();
+        public abstract void ToJsonValue(JsonValue output);
+        public abstract void FromJsonValue(JsonValue input);
+        public abstract void ParseTextFields(TextParser parser);
+        public abstract StructDescriptor GetDescriptor();
+        // value type must match the c# type of the field described by
+        // GetDescriptor().Fields[index], e.g. List<int> for list{i32}
+        public abstract object GetField(int index);
+        protected abstract void SetFieldInternal(int index, object value);
+
+        protected virtual byte[] GetHasBits()
+        {
+            return null;
+        }
 
         public BaseStruct Clone()
         {
             return CloneInternal();
         }
 
+        public void SetField(int index, object value)
+        {
+            SetFieldInternal(index, value);
+            SetHasField(index, true);
+        }
+
+        public bool HasField(int index)
+        {
+            FieldDescriptor[] fields = GetDescriptor().Fields;
+            if (index < 0 || index >= fields.Length) {
+                return false;
+            }
+
+            int optionalIndex = fields[index].OptionalIndex;
+            if (optionalIndex < 0) {
+                return true;
+            }
+
+            return (GetHasBits()[optionalIndex / 8] &
+                    (1 << (optionalIndex % 8))) > 0;
+        }
+
+        public void SetHasField(int index, bool has)
+        {
+            FieldDescriptor[] fields = GetDescriptor().Fields;
+            if (index < 0 || index >= fields.Length) {
+                return;
+            }
+
+            int optionalIndex = fields[index].OptionalIndex;
+            if (optionalIndex < 0) {
+                return;
+            }
+
+            byte[] hasBits = GetHasBits();
+            if (has) {
+                hasBits[optionalIndex / 8] |=
+                    (byte)(1 << (optionalIndex % 8));
+            } else {
+                hasBits[optionalIndex / 8] &=
+                    (byte)(~(1 << (optionalIndex % 8)) & 0xff);
+            }
+        }
+
         public int Encode(byte[] buffer)
         {
             return Encode(buffer, 0, buffer.Length);
@@ -65,5 +122,61 @@ namespace Brickred.Exchange
 
             return s.GetReadSize();
         }
+
+        public string ToJson()
+        {
+            JsonValue output = new JsonValue();
+            ToJsonValue(output);
+
+            return output.Write();
+        }
+
+        public bool FromJson(string json)
+        {
+            try {
+                FromJsonValue(JsonValue.Read(json));
+            } catch (CodecException) {
+                return false;
+            }
+
+            return true;
+        }
+
+        public bool ParseText(string text)
+        {
+            try {
+                TextParser parser = new TextParser(text);
+                ParseTextFields(parser);
+                parser.CheckEnd();
+            } catch (CodecException) {
+                return false;
+            }
+
+            return true;
+        }
+
+        protected static string DumpString(string val)
+        {
+            StringBuilder sb = new StringBuilder();
+            foreach (char c in val) {
+                if (c == '\\') {
+                    sb.Append("\\\\");
+                } else if (c == '"') {
+                    sb.Append("\\\"");
+                } else if (c == '\n') {
+                    sb.Append("\\n");
+                } else if (c == '\r') {
+                    sb.Append("\\r");
+                } else if (c == '\t') {
+                    sb.Append("\\t");
+                } else if (c < 0x20 || c == 0x7f) {
+                    sb.AppendFormat("\\x{0:X2}", (int)c);
+                } else {
+                    sb.Append(c);
+                }
+            }
+
+            return sb.ToString();
+        }
     }
 }
@@ -0,0 +1,127 @@
+namespace Brickred.Exchange
+{
+    public enum FieldType
+    {
+        I8,
+        U8,
+        I16,
+        U16,
+        I32,
+        U32,
+        I64,
+        U64,
+        I16V,
+        U16V,
+        I32V,
+        U32V,
+        I64V,
+        U64V,
+        STRING,
+        BYTES,
+        BOOL,
+        ENUM,
+        STRUCT,
+    }
+
+    public sealed class EnumItemDescriptor
+    {
+        public readonly string Name;
+        public readonly int Value;
+
+        public EnumItemDescriptor(string name, int value)
+        {
+            Name = name;
+            Value = value;
+        }
+    }
+
+    public sealed class EnumDescriptor
+    {
+        public readonly string Name;
+        public readonly string FullName;
+        public readonly EnumItemDescriptor[] Items;
+
+        public EnumDescriptor(string name, string fullName,
+            EnumItemDescriptor[] items)
+        {
+            Name = name;
+            FullName = fullName;
+            Items = items;
+        }
+
+        public EnumItemDescriptor FindItemByName(string name)
+        {
+            for (int i = 0; i < Items.Length; ++i) {
+                if (Items[i].Name == name) {
+                    return Items[i];
+                }
+            }
+
+            return null;
+        }
+
+        public EnumItemDescriptor FindItemByValue(int value)
+        {
+            for (int i = 0; i < Items.Length; ++i) {
+                if (Items[i].Value == value) {
+                    return Items[i];
+                }
+            }
+
+            return null;
+        }
+    }
+
+    public sealed class FieldDescriptor
+    {
+        public readonly string Name;
+        public readonly FieldType Type;
+        public readonly bool IsList;
+        // -1 if field is required
+        public readonly int OptionalIndex;
+        // only valid when Type is ENUM
+        public readonly EnumDescriptor RefEnum;
+        // only valid when Type is STRUCT
+        public readonly StructDescriptor RefStruct;
+
+        public FieldDescriptor(string name, FieldType type, bool isList,
+            int optionalIndex, EnumDescriptor refEnum,
+            StructDescriptor refStruct)
+        {
+            Name = name;
+            Type = type;
+            IsList = isList;
+            OptionalIndex = optionalIndex;
+            RefEnum = refEnum;
+            RefStruct = refStruct;
+        }
+    }
+
+    public sealed class StructDescriptor
+    {
+        public readonly string Name;
+        public readonly string FullName;
+        public readonly FieldDescriptor[] Fields;
+        public readonly BaseStruct.CreateFunc Create;
+
+        public StructDescriptor(string name, string fullName,
+            FieldDescriptor[] fields, BaseStruct.CreateFunc create)
+        {
+            Name = name;
+            FullName = fullName;
+            Fields = fields;
+            Create = create;
+        }
+
+        public int FindFieldIndex(string name)
+        {
+            for (int i = 0; i < Fields.Length; ++i) {
+                if (Fields[i].Name == name) {
+                    return i;
+                }
+            }
+
+            return -1;
+        }
+    }
+}
@@ -0,0 +1,577 @@
+using System;
+using System.Collections.Generic;
+using System.Globalization;
+using System.Text;
+
+namespace Brickred.Exchange
+{
+    // minimal json document used by the generated ToJson()/FromJson()
+    // conventions shared with the php and c++ runtime:
+    // - 64-bit integers are written as decimal strings
+    // - bytes are written as base64 strings
+    public sealed class JsonValue
+    {
+        public enum ValueType
+        {
+            NULL,
+            BOOL,
+            NUMBER,
+            STRING,
+            ARRAY,
+            OBJECT,
+        }
+
+        private const int MaxDepth = 512;
+
+        private ValueType type_ = ValueType.NULL;
+        private bool bool_ = false;
+        // text of number, content of string
+        private string str_ = "";
+        // items of array, members of object
+        private List<JsonValue> items_ = new List<JsonValue>();
+        // names of members of object
+        private List<string> names_ = new List<string>();
+
+        public ValueType Type
+        {
+            get { return type_; }
+        }
+
+        // number of items when type is ARRAY or OBJECT
+        public int Count
+        {
+            get { return items_.Count; }
+        }
+
+        public void SetNull()
+        {
+            type_ = ValueType.NULL;
+            str_ = "";
+            items_.Clear();
+            names_.Clear();
+        }
+
+        public void SetBool(bool val)
+        {
+            SetNull();
+            type_ = ValueType.BOOL;
+            bool_ = val;
+        }
+
+        public void SetInt(long val)
+        {
+            SetNull();
+            type_ = ValueType.NUMBER;
+            str_ = val.ToString(CultureInfo.InvariantCulture);
+        }
+
+        public void SetInt64(long val)
+        {
+            SetNull();
+            type_ = ValueType.STRING;
+            str_ = val.ToString(CultureInfo.InvariantCulture);
+        }
+
+        public void SetUInt64(ulong val)
+        {
+            SetNull();
+            type_ = ValueType.STRING;
+            str_ = val.ToString(CultureInfo.InvariantCulture);
+        }
+
+        public void SetString(string val)
+        {
+            SetNull();
+            type_ = ValueType.STRING;
+            str_ = val;
+        }
+
+        public void SetBytes(byte[] val)
+        {
+            SetNull();
+            type_ = ValueType.STRING;
+            str_ = Convert.ToBase64String(val);
+        }
+
+        public void SetArray()
+        {
+            SetNull();
+            type_ = ValueType.ARRAY;
+        }
+
+        public void SetObject()
+        {
+            SetNull();
+            type_ = ValueType.OBJECT;
+        }
+
+        // only valid when type is ARRAY
+        public JsonValue Append()
+        {
+            JsonValue val = new JsonValue();
+            items_.Add(val);
+
+            return val;
+        }
+
+        // only valid when type is OBJECT
+        public JsonValue AddMember(string name)
+        {
+            JsonValue val = new JsonValue();
+            names_.Add(name);
+            items_.Add(val);
+
+            return val;
+        }
+
+        public JsonValue At(int index)
+        {
+            if (index < 0 || index >= items_.Count) {
+                throw new CodecException(
+                    "json array index out of range");
+            }
+
+            return items_[index];
+        }
+
+        // return null if type is not OBJECT or member not found
+        public JsonValue FindMember(string name)
+        {
+            if (type_ != ValueType.OBJECT) {
+                return null;
+            }
+
+            for (int i = 0; i < names_.Count; ++i) {
+                if (names_[i] == name) {
+                    return items_[i];
+                }
+            }
+
+            return null;
+        }
+
+        // return false if member not found or member is null
+        public bool HasMember(string name)
+        {
+            JsonValue member = FindMember(name);
+            if (member == null || member.type_ == ValueType.NULL) {
+                return false;
+            }
+
+            return true;
+        }
+
+        public JsonValue GetMember(string name)
+        {
+            JsonValue member = FindMember(name);
+            if (member == null) {
+                throw new CodecException(
+                    string.Format("json member `{0}` not found", name));
+            }
+
+            return member;
+        }
+
+        public void CheckType(ValueType type)
+        {
+            if (type_ != type) {
+                throw new CodecException(string.Format(
+                    "json value is not {0}", type.ToString().ToLower()));
+            }
+        }
+
+        // accept also empty array, which php writes for a struct without
+        // any field set
+        public void CheckStruct()
+        {
+            if (type_ != ValueType.OBJECT &&
+                (type_ != ValueType.ARRAY || items_.Count > 0)) {
+                throw new CodecException("json value is not object");
+            }
+        }
+
+        public bool GetBool()
+        {
+            if (type_ != ValueType.BOOL) {
+                throw new CodecException("json value is not bool");
+            }
+
+            return bool_;
+        }
+
+        // accept both number and decimal string
+        public long GetInt64()
+        {
+            long val;
+            if ((type_ != ValueType.NUMBER && type_ != ValueType.STRING) ||
+                long.TryParse(str_, NumberStyles.AllowLeadingSign,
+                    CultureInfo.InvariantCulture, out val) == false) {
+                throw new CodecException("json value is not integer");
+            }
+
+            return val;
+        }
+
+        // value must be in [min, max] of the type of field
+        public long GetInt(long min, long max)
+        {
+            long val = GetInt64();
+            if (val < min || val > max) {
+                throw new CodecException("json integer is out of range");
+            }
+
+            return val;
+        }
+
+        public ulong GetUInt64()
+        {
+            ulong val;
+            if ((type_ != ValueType.NUMBER && type_ != ValueType.STRING) ||
+                ulong.TryParse(str_, NumberStyles.None,
+                    CultureInfo.InvariantCulture, out val) == false) {
+                throw new CodecException("json value is not integer");
+            }
+
+            return val;
+        }
+
+        public string GetString()
+        {
+            if (type_ != ValueType.STRING) {
+                throw new CodecException("json value is not string");
+            }
+
+            return str_;
+        }
+
+        public byte[] GetBytes()
+        {
+            if (type_ != ValueType.STRING) {
+                throw new CodecException("json value is not string");
+            }
+
+            try {
+                return Convert.FromBase64String(str_);
+            } catch (FormatException) {
+                throw new CodecException("json value is not base64");
+            }
+        }
+
+        public T GetStruct<T>() where T : BaseStruct, new()
+        {
+            T val = new T();
+
+            val.FromJsonValue(this);
+
+            return val;
+        }
+
+        public string Write()
+        {
+            StringBuilder sb = new StringBuilder();
+            WriteToString(sb);
+
+            return sb.ToString();
+        }
+
+        public static JsonValue Read(string json)
+        {
+            int pos = 0;
+
+            JsonValue val = new JsonValue();
+            val.ReadFromString(json, ref pos, 0);
+            SkipWhitespace(json, ref pos);
+            if (pos != json.Length) {
+                throw InvalidJson(pos);
+            }
+
+            return val;
+        }
+
+        private static CodecException InvalidJson(int pos)
+        {
+            return new CodecException(
+                string.Format("invalid json at offset {0}", pos));
+        }
+
+        private static void WriteString(string val, StringBuilder sb)
+        {
+            sb.Append('"');
+            for (int i = 0; i < val.Length; ++i) {
+                char c = val[i];
+                if (c == '"') {
+                    sb.Append("\\\"");
+                } else if (c == '\\') {
+                    sb.Append("\\\\");
+                } else if (c == '\b') {
+                    sb.Append("\\b");
+                } else if (c == '\f') {
+                    sb.Append("\\f");
+                } else if (c == '\n') {
+                    sb.Append("\\n");
+                } else if (c == '\r') {
+                    sb.Append("\\r");
+                } else if (c == '\t') {
+                    sb.Append("\\t");
+                } else if (c < 0x20) {
+                    sb.AppendFormat("\\u{0:x4}", (int)c);
+                } else {
+                    sb.Append(c);
+                }
+            }
+            sb.Append('"');
+        }
+
+        private void WriteToString(StringBuilder sb)
+        {
+            if (type_ == ValueType.NULL) {
+                sb.Append("null");
+            } else if (type_ == ValueType.BOOL) {
+                sb.Append(bool_ ? "true" : "false");
+            } else if (type_ == ValueType.NUMBER) {
+                sb.Append(str_);
+            } else if (type_ == ValueType.STRING) {
+                WriteString(str_, sb);
+            } else if (type_ == ValueType.ARRAY) {
+                sb.Append('[');
+                for (int i = 0; i < items_.Count; ++i) {
+                    if (i > 0) {
+                        sb.Append(',');
+                    }
+                    items_[i].WriteToString(sb);
+                }
+                sb.Append(']');
+            } else if (type_ == ValueType.OBJECT) {
+                sb.Append('{');
+                for (int i = 0; i < items_.Count; ++i) {
+                    if (i > 0) {
+                        sb.Append(',');
+                    }
+                    WriteString(names_[i], sb);
+                    sb.Append(':');
+                    items_[i].WriteToString(sb);
+                }
+                sb.Append('}');
+            }
+        }
+
+        private static void SkipWhitespace(string json, ref int pos)
+        {
+            while (pos < json.Length &&
+                   (json[pos] == ' ' || json[pos] == '\t' ||
+                    json[pos] == '\n' || json[pos] == '\r')) {
+                ++pos;
+            }
+        }
+
+        private static int ReadHex4(string json, ref int pos)
+        {
+            if (json.Length - pos < 4) {
+                throw InvalidJson(pos);
+            }
+
+            int val;
+            if (int.TryParse(json.Substring(pos, 4),
+                    NumberStyles.AllowHexSpecifier,
+                    CultureInfo.InvariantCulture, out val) == false) {
+                throw InvalidJson(pos);
+            }
+            pos += 4;
+
+            return val;
+        }
+
+        private static string ReadString(string json, ref int pos)
+        {
+            StringBuilder sb = new StringBuilder();
+
+            // skip '"'
+            ++pos;
+
+            for (;;) {
+                if (pos >= json.Length) {
+                    throw InvalidJson(pos);
+                }
+
+                char c = json[pos++];
+                if (c == '"') {
+                    return sb.ToString();
+                } else if (c < 0x20) {
+                    throw InvalidJson(pos - 1);
+                } else if (c != '\\') {
+                    sb.Append(c);
+                    continue;
+                }
+
+                if (pos >= json.Length) {
+                    throw InvalidJson(pos);
+                }
+                c = json[pos++];
+                if (c == '"' || c == '\\' || c == '/') {
+                    sb.Append(c);
+                } else if (c == 'b') {
+                    sb.Append('\b');
+                } else if (c == 'f') {
+                    sb.Append('\f');
+                } else if (c == 'n') {
+                    sb.Append('\n');
+                } else if (c == 'r') {
+                    sb.Append('\r');
+                } else if (c == 't') {
+                    sb.Append('\t');
+                } else if (c == 'u') {
+                    sb.Append((char)ReadHex4(json, ref pos));
+                } else {
+                    throw InvalidJson(pos - 1);
+                }
+            }
+        }
+
+        private static string ReadNumber(string json, ref int pos)
+        {
+            int start = pos;
+
+            if (pos < json.Length && json[pos] == '-') {
+                ++pos;
+            }
+            if (pos >= json.Length) {
+                throw InvalidJson(pos);
+            }
+            if (json[pos] == '0') {
+                ++pos;
+            } else if (json[pos] >= '1' && json[pos] <= '9') {
+                while (pos < json.Length &&
+                       json[pos] >= '0' && json[pos] <= '9') {
+                    ++pos;
+                }
+            } else {
+                throw InvalidJson(pos);
+            }
+            if (pos < json.Length && json[pos] == '.') {
+                ++pos;
+                if (pos >= json.Length ||
+                    json[pos] < '0' || json[pos] > '9') {
+                    throw InvalidJson(pos);
+                }
+                while (pos < json.Length &&
+                       json[pos] >= '0' && json[pos] <= '9') {
+                    ++pos;
+                }
+            }
+            if (pos < json.Length &&
+                (json[pos] == 'e' || json[pos] == 'E')) {
+                ++pos;
+                if (pos < json.Length &&
+                    (json[pos] == '+' || json[pos] == '-')) {
+                    ++pos;
+                }
+                if (pos >= json.Length ||
+                    json[pos] < '0' || json[pos] > '9') {
+                    throw InvalidJson(pos);
+                }
+                while (pos < json.Length &&
+                       json[pos] >= '0' && json[pos] <= '9') {
+                    ++pos;
+                }
+            }
+
+            return json.Substring(start, pos - start);
+        }
+
+        private static bool ReadKeyword(string json, ref int pos,
+            string keyword)
+        {
+            if (string.CompareOrdinal(
+                    json, pos, keyword, 0, keyword.Length) != 0) {
+                return false;
+            }
+            pos += keyword.Length;
+
+            return true;
+        }
+
+        private void ReadFromString(string json, ref int pos, int depth)
+        {
+            if (depth > MaxDepth) {
+                throw InvalidJson(pos);
+            }
+
+            SkipWhitespace(json, ref pos);
+            if (pos >= json.Length) {
+                throw InvalidJson(pos);
+            }
+
+            char c = json[pos];
+            if (c == '{') {
+                SetObject();
+                ++pos;
+                SkipWhitespace(json, ref pos);
+                if (pos < json.Length && json[pos] == '}') {
+                    ++pos;
+                    return;
+                }
+                for (;;) {
+                    SkipWhitespace(json, ref pos);
+                    if (pos >= json.Length || json[pos] != '"') {
+                        throw InvalidJson(pos);
+                    }
+                    string name = ReadString(json, ref pos);
+                    SkipWhitespace(json, ref pos);
+                    if (pos >= json.Length || json[pos] != ':') {
+                        throw InvalidJson(pos);
+                    }
+                    ++pos;
+                    AddMember(name).ReadFromString(json, ref pos, depth + 1);
+                    SkipWhitespace(json, ref pos);
+                    if (pos >= json.Length) {
+                        throw InvalidJson(pos);
+                    } else if (json[pos] == ',') {
+                        ++pos;
+                    } else if (json[pos] == '}') {
+                        ++pos;
+                        return;
+                    } else {
+                        throw InvalidJson(pos);
+                    }
+                }
+            } else if (c == '[') {
+                SetArray();
+                ++pos;
+                SkipWhitespace(json, ref pos);
+                if (pos < json.Length && json[pos] == ']') {
+                    ++pos;
+                    return;
+                }
+                for (;;) {
+                    Append().ReadFromString(json, ref pos, depth + 1);
+                    SkipWhitespace(json, ref pos);
+                    if (pos >= json.Length) {
+                        throw InvalidJson(pos);
+                    } else if (json[pos] == ',') {
+                        ++pos;
+                    } else if (json[pos] == ']') {
+                        ++pos;
+                        return;
+                    } else {
+                        throw InvalidJson(pos);
+                    }
+                }
+            } else if (c == '"') {
+                SetString(ReadString(json, ref pos));
+            } else if (c == '-' || (c >= '0' && c <= '9')) {
+                string number = ReadNumber(json, ref pos);
+                SetNull();
+                type_ = ValueType.NUMBER;
+                str_ = number;
+            } else if (ReadKeyword(json, ref pos, "true")) {
+                SetBool(true);
+            } else if (ReadKeyword(json, ref pos, "false")) {
+                SetBool(false);
+            } else if (ReadKeyword(json, ref pos, "null")) {
+                SetNull();
+            } else {
+                throw InvalidJson(pos);
+            }
+        }
+    }
+}
@@ -0,0 +1,302 @@
+using System;
+using System.Globalization;
+using System.Text;
+
+namespace Brickred.Exchange
+{
+    // reader of the text format produced by the generated Dump()
+    // - fields are written as `name: value` separated by whitespace
+    // - list fields are written as one `name: value` per item
+    // - optional fields not set are omitted
+    // - integers and enums are decimal, bool is 1 or 0
+    // - string is quoted, with \\ \" \n \r \t and \xHH escapes
+    // - bytes is quoted uppercase hex separated by '-', e.g. "0A-FF"
+    // - struct is written as `name: { fields }`
+    public sealed class TextParser
+    {
+        private readonly string text_;
+        private int pos_;
+
+        public TextParser(string text)
+        {
+            text_ = text;
+            pos_ = 0;
+        }
+
+        // char offset where parsing stopped
+        public int Offset
+        {
+            get { return pos_; }
+        }
+
+        // return false when reach end of text or '}' of current struct
+        public bool ReadFieldName(out string name)
+        {
+            name = null;
+
+            SkipWhitespace();
+            if (pos_ >= text_.Length || text_[pos_] == '}') {
+                return false;
+            }
+            if (IsIdentifierChar(text_[pos_], true) == false) {
+                throw InvalidText();
+            }
+
+            int start = pos_;
+            while (pos_ < text_.Length &&
+                   IsIdentifierChar(text_[pos_], false)) {
+                ++pos_;
+            }
+            name = text_.Substring(start, pos_ - start);
+
+            SkipWhitespace();
+            if (pos_ >= text_.Length || text_[pos_] != ':') {
+                throw InvalidText();
+            }
+            ++pos_;
+
+            return true;
+        }
+
+        public long ReadInt64()
+        {
+            int start = pos_;
+            string token = ReadToken();
+
+            long val;
+            if (long.TryParse(token, NumberStyles.AllowLeadingSign,
+                    CultureInfo.InvariantCulture, out val) == false) {
+                pos_ = start;
+                throw InvalidText();
+            }
+
+            return val;
+        }
+
+        // value must be in [min, max] of the type of field
+        public long ReadInt(long min, long max)
+        {
+            int start = pos_;
+            long val = ReadInt64();
+            if (val < min || val > max) {
+                pos_ = start;
+                throw InvalidText();
+            }
+
+            return val;
+        }
+
+        public ulong ReadUInt64()
+        {
+            int start = pos_;
+            string token = ReadToken();
+
+            ulong val;
+            if (ulong.TryParse(token, NumberStyles.None,
+                    CultureInfo.InvariantCulture, out val) == false) {
+                pos_ = start;
+                throw InvalidText();
+            }
+
+            return val;
+        }
+
+        public bool ReadBool()
+        {
+            int start = pos_;
+            string token = ReadToken();
+
+            if (token == "1" || token == "true") {
+                return true;
+            } else if (token == "0" || token == "false") {
+                return false;
+            } else {
+                pos_ = start;
+                throw InvalidText();
+            }
+        }
+
+        public string ReadString()
+        {
+            SkipWhitespace();
+            if (pos_ >= text_.Length || text_[pos_] != '"') {
+                throw InvalidText();
+            }
+            ++pos_;
+
+            StringBuilder sb = new StringBuilder();
+            for (;;) {
+                if (pos_ >= text_.Length) {
+                    throw InvalidText();
+                }
+
+                char c = text_[pos_];
+                if (c == '"') {
+                    ++pos_;
+                    break;
+                } else if (c != '\\') {
+                    sb.Append(c);
+                    ++pos_;
+                    continue;
+                }
+
+                if (pos_ + 1 >= text_.Length) {
+                    throw InvalidText();
+                }
+                c = text_[pos_ + 1];
+                if (c == '\\' || c == '"') {
+                    sb.Append(c);
+                    pos_ += 2;
+                } else if (c == 'n') {
+                    sb.Append('\n');
+                    pos_ += 2;
+                } else if (c == 'r') {
+                    sb.Append('\r');
+                    pos_ += 2;
+                } else if (c == 't') {
+                    sb.Append('\t');
+                    pos_ += 2;
+                } else if (c == 'x') {
+                    if (pos_ + 3 >= text_.Length) {
+                        throw InvalidText();
+                    }
+                    int high = HexValue(text_[pos_ + 2]);
+                    int low = HexValue(text_[pos_ + 3]);
+                    if (high < 0 || low < 0 || high >= 8) {
+                        throw InvalidText();
+                    }
+                    sb.Append((char)(high << 4 | low));
+                    pos_ += 4;
+                } else {
+                    throw InvalidText();
+                }
+            }
+
+            return sb.ToString();
+        }
+
+        public byte[] ReadBytes()
+        {
+            int start = pos_;
+            string hex = ReadString();
+
+            if (hex.Length == 0) {
+                return new byte[0];
+            }
+            if (hex.Length % 3 != 2) {
+                pos_ = start;
+                throw InvalidText();
+            }
+
+            byte[] val = new byte[(hex.Length + 1) / 3];
+            for (int i = 0; i < val.Length; ++i) {
+                int high = HexValue(hex[i * 3]);
+                int low = HexValue(hex[i * 3 + 1]);
+                if (high < 0 || low < 0 ||
+                    (i * 3 + 2 < hex.Length && hex[i * 3 + 2] != '-')) {
+                    pos_ = start;
+                    throw InvalidText();
+                }
+                val[i] = (byte)(high << 4 | low);
+            }
+
+            return val;
+        }
+
+        public T ReadStruct<T>() where T : BaseStruct, new()
+        {
+            ReadChar('{');
+            T val = new T();
+            val.ParseTextFields(this);
+            ReadChar('}');
+
+            return val;
+        }
+
+        // throw if anything but whitespace left
+        public void CheckEnd()
+        {
+            SkipWhitespace();
+            if (pos_ < text_.Length) {
+                throw InvalidText();
+            }
+        }
+
+        public CodecException UnknownField(string name)
+        {
+            return new CodecException(string.Format(
+                "unknown field `{0}` at offset {1}", name, pos_));
+        }
+
+        private CodecException InvalidText()
+        {
+            return new CodecException(
+                string.Format("invalid text at offset {0}", pos_));
+        }
+
+        private static bool IsIdentifierChar(char c, bool first)
+        {
+            if ((c >= 'a' && c <= 'z') ||
+                (c >= 'A' && c <= 'Z') ||
+                c == '_') {
+                return true;
+            }
+            if (first == false && c >= '0' && c <= '9') {
+                return true;
+            }
+
+            return false;
+        }
+
+        private static int HexValue(char c)
+        {
+            if (c >= '0' && c <= '9') {
+                return c - '0';
+            } else if (c >= 'a' && c <= 'f') {
+                return c - 'a' + 10;
+            } else if (c >= 'A' && c <= 'F') {
+                return c - 'A' + 10;
+            } else {
+                return -1;
+            }
+        }
+
+        private static bool IsWhitespace(char c)
+        {
+            return c == ' ' || c == '\t' || c == '\n' || c == '\r';
+        }
+
+        private void SkipWhitespace()
+        {
+            while (pos_ < text_.Length && IsWhitespace(text_[pos_])) {
+                ++pos_;
+            }
+        }
+
+        private void ReadChar(char expected)
+        {
+            SkipWhitespace();
+            if (pos_ >= text_.Length || text_[pos_] != expected) {
+                throw InvalidText();
+            }
+            ++pos_;
+        }
+
+        private string ReadToken()
+        {
+            SkipWhitespace();
+
+            int start = pos_;
+            while (pos_ < text_.Length &&
+                   IsWhitespace(text_[pos_]) == false &&
+                   text_[pos_] != '}') {
+                ++pos_;
+            }
+            if (pos_ == start) {
+                throw InvalidText();
+            }
+
+            return text_.Substring(start, pos_ - start);
+        }
+    }
+}
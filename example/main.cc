@@ -1,5 +1,8 @@
+#include <cstring>
 #include <fstream>
 #include <iostream>
+#include <iterator>
+#include <string>
 #include <vector>
 
 #include "message_type.h"
@@ -8,11 +11,73 @@
 using namespace brickred::exchange;
 using namespace protocol::client;
 
-int main()
+static bool readFile(const std::string &file_name, std::string *content)
 {
+    std::ifstream fs(file_name, std::ios::binary);
+    if (fs.is_open() == false) {
+        return false;
+    }
+    content->assign(std::istreambuf_iterator<char>(fs),
+                    std::istreambuf_iterator<char>());
+
+    return fs.bad() == false;
+}
+
+static bool writeFile(const std::string &file_name,
+                      const char *buffer, size_t size)
+{
+    std::ofstream fs(file_name, std::ios::binary);
+    fs.write(buffer, size);
+    fs.close();
+
+    return fs.bad() == false;
+}
+
+// decode json written by language `from`, then encode to
+// cpp_from_<from>.bin which must be the same as cpp.bin
+static int jsonToBin(const std::string &from)
+{
+    std::string json;
+
+    // php writes [] for struct without any field
+    MsgTest4 msg4;
+    if (readFile(from + "_empty.json", &json) == false ||
+        msg4.from_json(json) == false) {
+        std::cerr << "decode " << from << "_empty.json failed" << std::endl;
+        return 1;
+    }
+
+    MsgTest msg;
+    if (readFile(from + ".json", &json) == false ||
+        msg.from_json(json) == false) {
+        std::cerr << "decode " << from << ".json failed" << std::endl;
+        return 1;
+    }
+
+    std::vector<char> buffer(10 * 1024 * 1024);
+    int encode_size = msg.encode(&buffer[0], buffer.size());
+    if (-1 == encode_size) {
+        std::cerr << "buffer is too small" << std::endl;
+        return 1;
+    }
+    if (writeFile("cpp_from_" + from + ".bin",
+                  &buffer[0], encode_size) == false) {
+        return 1;
+    }
+
+    return 0;
+}
+
+int main(int argc, char *argv[])
+{
+    if (argc > 1) {
+        return jsonToBin(argv[1]);
+    }
+
     std::vector<char> buffer(10 * 1024 * 1024);
     int id = 0;
     int encode_size = 0;
+    std::string json;
 
     // encode message to buffer
     {
@@ -431,6 +496,8 @@ int main()
         }
         // get message id from type
         id = MessageType::id<MsgTest>::value;
+        // encode json
+        json = msg.to_json();
     }
 
     // decode message from buffer
@@ -817,10 +884,59 @@ int main()
         delete msg;
     }
 
-    std::ofstream fs("cpp.bin", std::ios::binary);
-    fs.write((char *)&buffer[0], encode_size);
-    fs.close();
-    if (fs.bad()) {
+    // parse dump text back, must encode to the same binary
+    {
+        MsgTest msg;
+        msg.decode(&buffer[0], encode_size);
+        MsgTest msg_parsed;
+        std::vector<char> buffer_parsed(buffer.size());
+        if (msg_parsed.parse_text(msg.dump()) == false ||
+            msg_parsed.encode(&buffer_parsed[0],
+                              buffer_parsed.size()) != encode_size ||
+            ::memcmp(&buffer[0], &buffer_parsed[0], encode_size) != 0) {
+            std::cerr << "parse dump text failed" << std::endl;
+            return 1;
+        }
+
+        // integer out of range of field type is rejected
+        Attr attr;
+        if (attr.parse_text("id: 0 value: 2147483648") ||
+            attr.parse_text("id: -2147483649 value: 0") ||
+            attr.parse_text("id: 0 value: 2147483647") == false) {
+            std::cerr << "text integer range check failed" << std::endl;
+            return 1;
+        }
+    }
+
+    // get and set field by name
+    {
+        MsgTest5 msg;
+        int index = msg.descriptor()->findFieldIndex("c2");
+
+        std::cout << "c2 index = " << index << std::endl
+                  << "has c2 = " << msg.hasField(index) << std::endl;
+        msg.setField<int32_t>(index, 100);
+        std::cout << "has c2 = " << msg.hasField(index) << std::endl
+                  << "c2 = " << *msg.getField<int32_t>(index) << std::endl;
+    }
+
+    // integer out of range of field type is rejected
+    {
+        Attr attr;
+        if (attr.from_json("{\"id\":0,\"value\":2147483648}") ||
+            attr.from_json("{\"id\":-2147483649,\"value\":0}") ||
+            attr.from_json("{\"id\":0,\"value\":2147483647}") == false) {
+            std::cerr << "json integer range check failed" << std::endl;
+            return 1;
+        }
+    }
+
+    if (writeFile("cpp.bin", &buffer[0], encode_size) == false ||
+        writeFile("cpp.json", json.data(), json.size()) == false) {
+        return 1;
+    }
+    json = MsgTest4().to_json();
+    if (writeFile("cpp_empty.json", json.data(), json.size()) == false) {
         return 1;
     }
 
@@ -6,11 +6,62 @@ using System.Text;
 
 public class App
 {
-    public static int Main()
+    // decode json written by language `from`, then encode to
+    // csharp_from_<from>.bin which must be the same as csharp.bin
+    private static int JsonToBin(string from)
     {
+        string json;
+
+        // php writes [] for struct without any field
+        MsgTest4 msg4 = new MsgTest4();
+        try {
+            json = File.ReadAllText(from + "_empty.json");
+        } catch {
+            return 1;
+        }
+        if (msg4.FromJson(json) == false) {
+            Console.WriteLine("decode {0}_empty.json failed", from);
+            return 1;
+        }
+
+        MsgTest msg = new MsgTest();
+        try {
+            json = File.ReadAllText(from + ".json");
+        } catch {
+            return 1;
+        }
+        if (msg.FromJson(json) == false) {
+            Console.WriteLine("decode {0}.json failed", from);
+            return 1;
+        }
+
+        byte[] buffer = new byte[10 * 1024 * 1024];
+        int encode_size = msg.Encode(buffer);
+        if (-1 == encode_size) {
+            Console.WriteLine("buffer is too small");
+            return 1;
+        }
+        byte[] bin = new byte[encode_size];
+        Buffer.BlockCopy(buffer, 0, bin, 0, encode_size);
+        try {
+            File.WriteAllBytes("csharp_from_" + from + ".bin", bin);
+        } catch {
+            return 1;
+        }
+
+        return 0;
+    }
+
+    public static int Main(string[] args)
+    {
+        if (args.Length > 0) {
+            return JsonToBin(args[0]);
+        }
+
         byte[] buffer = new byte[10 * 1024 * 1024];
         int id = 0;
         int encode_size = 0;
+        string json = null;
 
         // encode message to buffer
         {
@@ -429,6 +480,8 @@ public class App
             }
             // get message id from type
             id = MessageType.GetId<MsgTest>();
+            // encode json
+            json = msg.ToJson();
         }
 
         // decode message from buffer
@@ -814,10 +867,64 @@ public class App
             Console.Write(s);
         }
 
+        // parse dump text back, must encode to the same binary
+        {
+            MsgTest msg = new MsgTest();
+            msg.Decode(buffer, 0, encode_size);
+            MsgTest msg_parsed = new MsgTest();
+            byte[] buffer_parsed = new byte[buffer.Length];
+            bool same = msg_parsed.ParseText(msg.Dump()) &&
+                msg_parsed.Encode(buffer_parsed) == encode_size;
+            for (int i = 0; same && i < encode_size; ++i) {
+                same = buffer[i] == buffer_parsed[i];
+            }
+            if (same == false) {
+                Console.WriteLine("parse dump text failed");
+                return 1;
+            }
+
+            // integer out of range of field type is rejected
+            Attr attr = new Attr();
+            if (attr.ParseText("id: 0 value: 2147483648") ||
+                attr.ParseText("id: -2147483649 value: 0") ||
+                attr.ParseText("id: 0 value: 2147483647") == false) {
+                Console.WriteLine("text integer range check failed");
+                return 1;
+            }
+        }
+
+        // get and set field by name
+        {
+            MsgTest5 msg = new MsgTest5();
+            int index = msg.GetDescriptor().FindFieldIndex("c2");
+
+            StringBuilder s = new StringBuilder();
+            s.AppendFormat("c2 index = {0}\n", index);
+            s.AppendFormat("has c2 = {0}\n", msg.HasField(index) ? 1 : 0);
+            msg.SetField(index, 100);
+            s.AppendFormat("has c2 = {0}\n", msg.HasField(index) ? 1 : 0);
+            s.AppendFormat("c2 = {0}\n", (int)msg.GetField(index));
+
+            Console.Write(s);
+        }
+
+        // integer out of range of field type is rejected
+        {
+            Attr attr = new Attr();
+            if (attr.FromJson("{\"id\":0,\"value\":2147483648}") ||
+                attr.FromJson("{\"id\":-2147483649,\"value\":0}") ||
+                attr.FromJson("{\"id\":0,\"value\":2147483647}") == false) {
+                Console.WriteLine("json integer range check failed");
+                return 1;
+            }
+        }
+
         byte[] bin = new byte[encode_size];
         Buffer.BlockCopy(buffer, 0, bin, 0, encode_size);
         try {
             File.WriteAllBytes("csharp.bin", bin);
+            File.WriteAllText("csharp.json", json);
+            File.WriteAllText("csharp_empty.json", new MsgTest4().ToJson());
         } catch {
             return 1;
         }
@@ -13,8 +13,36 @@ use Brickred\Exchange\Int64;
 use Brickred\Exchange\UInt64;
 use Protocol\Client\AttrType;
 use Protocol\Client\MsgTest;
+use Protocol\Client\MsgTest4;
+use Protocol\Client\MsgTest5;
 use Protocol\Client\MessageType;
 
+// decode json written by language $argv[1], then encode to
+// php_from_<lang>.bin which must be the same as php.bin
+if ($argc > 1) {
+    $from = $argv[1];
+
+    $json = file_get_contents("{$from}_empty.json");
+    if ($json === false) {
+        exit(1);
+    }
+    $msg4 = new MsgTest4();
+    $msg4->fromJson($json);
+
+    $json = file_get_contents("$from.json");
+    if ($json === false) {
+        exit(1);
+    }
+    $msg = new MsgTest();
+    $msg->fromJson($json);
+
+    if (file_put_contents("php_from_$from.bin", $msg->encode()) === false) {
+        exit(1);
+    }
+
+    exit(0);
+}
+
 $msg = new MsgTest();
 // i8
 $msg->a1 = 0x7f;
@@ -891,7 +919,28 @@ $msg->fromArray($array);
 $msg = MessageType::create($id);
 $msg->fromJson($json);
 
-if (file_put_contents("php.bin", $bin) === false) {
+// parse dump text back, must encode to the same binary
+$msg = MessageType::create($id);
+$msg->decode($bin);
+$msg_parsed = new MsgTest();
+$msg_parsed->fromText($msg->dump());
+if ($msg_parsed->encode() !== $bin) {
+    exit(1);
+}
+
+// get and set field by name
+$msg = new MsgTest5();
+$index = MsgTest5::descriptor()->findFieldIndex('c2');
+
+echo "c2 index = $index\n".
+     "has c2 = ".(int)$msg->hasField($index)."\n";
+$msg->setField($index, 100);
+echo "has c2 = ".(int)$msg->hasField($index)."\n".
+     "c2 = ".$msg->getField($index)."\n";
+
+if (file_put_contents("php.bin", $bin) === false ||
+    file_put_contents("php.json", $json) === false ||
+    file_put_contents("php_empty.json", (new MsgTest4())->toJson()) === false) {
     exit(1);
 }
 
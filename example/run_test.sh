@@ -35,11 +35,7 @@ cp "$script_path"/main.php .
 if [ $? -ne 0 ]; then exit 1; fi
 
 # cpp test
-./brexc -f attr.xml -l cpp
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_test.xml -l cpp
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_type.xml -l cpp
+./brexc -f message_type.xml --with-imports -l cpp
 if [ $? -ne 0 ]; then exit 1; fi
 g++ -I "$script_path"/../cpp/src \
     -o "cpp_test" \
@@ -47,17 +43,16 @@ g++ -I "$script_path"/../cpp/src \
     attr.cc \
     message_test.cc \
     message_type.cc \
-    "$script_path"/../cpp/src/brickred/exchange/base_struct.cc
+    "$script_path"/../cpp/src/brickred/exchange/base_struct.cc \
+    "$script_path"/../cpp/src/brickred/exchange/descriptor.cc \
+    "$script_path"/../cpp/src/brickred/exchange/json_value.cc \
+    "$script_path"/../cpp/src/brickred/exchange/text_parser.cc
 if [ $? -ne 0 ]; then exit 1; fi
 ./cpp_test > cpp.text
 if [ $? -ne 0 ]; then exit 1; fi
 
 # csharp test
-./brexc -f attr.xml -l csharp
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_test.xml -l csharp
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_type.xml -l csharp
+./brexc -f message_type.xml --with-imports -l csharp
 if [ $? -ne 0 ]; then exit 1; fi
 mcs -out:csharp_test.exe \
     main.cs \
@@ -67,17 +62,16 @@ mcs -out:csharp_test.exe \
     "$script_path"/../csharp/src/Brickred.Exchange/BaseStruct.cs \
     "$script_path"/../csharp/src/Brickred.Exchange/CodecException.cs \
     "$script_path"/../csharp/src/Brickred.Exchange/CodecInputStream.cs \
-    "$script_path"/../csharp/src/Brickred.Exchange/CodecOutputStream.cs
+    "$script_path"/../csharp/src/Brickred.Exchange/CodecOutputStream.cs \
+    "$script_path"/../csharp/src/Brickred.Exchange/Descriptor.cs \
+    "$script_path"/../csharp/src/Brickred.Exchange/JsonValue.cs \
+    "$script_path"/../csharp/src/Brickred.Exchange/TextParser.cs
 if [ $? -ne 0 ]; then exit 1; fi
 ./csharp_test.exe > csharp.text
 if [ $? -ne 0 ]; then exit 1; fi
 
 # php test
-./brexc -f attr.xml -l php
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_test.xml -l php
-if [ $? -ne 0 ]; then exit 1; fi
-./brexc -f message_type.xml -l php
+./brexc -f message_type.xml --with-imports -l php
 if [ $? -ne 0 ]; then exit 1; fi
 php main.php > php.text
 if [ $? -ne 0 ]; then exit 1; fi
@@ -89,6 +83,8 @@ md5sum csharp.text
 if [ $? -ne 0 ]; then exit 1; fi
 md5sum php.text
 if [ $? -ne 0 ]; then exit 1; fi
+cmp cpp.text csharp.text && cmp cpp.text php.text
+if [ $? -ne 0 ]; then exit 1; fi
 
 # check bin md5
 md5sum cpp.bin
@@ -97,5 +93,96 @@ md5sum csharp.bin
 if [ $? -ne 0 ]; then exit 1; fi
 md5sum php.bin
 if [ $? -ne 0 ]; then exit 1; fi
+cmp cpp.bin csharp.bin && cmp cpp.bin php.bin
+if [ $? -ne 0 ]; then exit 1; fi
+
+# check json written by each language is decoded by all languages
+for from in cpp csharp php; do
+    ./cpp_test $from && cmp cpp.bin cpp_from_$from.bin
+    if [ $? -ne 0 ]; then exit 1; fi
+    ./csharp_test.exe $from && cmp csharp.bin csharp_from_$from.bin
+    if [ $? -ne 0 ]; then exit 1; fi
+    php main.php $from && cmp php.bin php_from_$from.bin
+    if [ $? -ne 0 ]; then exit 1; fi
+done
+
+# check brexc decode and encode by schema
+./brexc decode -f message_test.xml -t MsgTest -i cpp.bin > brexc.json
+if [ $? -ne 0 ]; then exit 1; fi
+./brexc encode -f message_test.xml -t MsgTest -i brexc.json -o brexc.bin
+if [ $? -ne 0 ]; then exit 1; fi
+cmp cpp.bin brexc.bin
+if [ $? -ne 0 ]; then exit 1; fi
+for from in cpp csharp php; do
+    ./brexc encode -f message_test.xml -t MsgTest \
+        -i $from.json -o brexc_from_$from.bin &&
+        cmp cpp.bin brexc_from_$from.bin
+    if [ $? -ne 0 ]; then exit 1; fi
+    # php writes [] for struct without any field
+    ./brexc encode -f message_test.xml -t MsgTest4 \
+        -i ${from}_empty.json -o brexc_from_${from}_empty.bin
+    if [ $? -ne 0 ]; then exit 1; fi
+done
+
+# check example protocols are formatted
+./brexc fmt --check -f attr.xml -f message_test.xml -f message_type.xml
+if [ $? -ne 0 ]; then exit 1; fi
+# formatted file is left as it is
+cp "$script_path"/compat/old/compat.xml fmt_test.xml
+if [ $? -ne 0 ]; then exit 1; fi
+./brexc fmt -f fmt_test.xml && ./brexc fmt --check -f fmt_test.xml
+if [ $? -ne 0 ]; then exit 1; fi
+
+# check text syntax generates the same code, the example protocols are
+# converted to .brex files line by line
+mkdir -p brex xml_gen brex_gen
+if [ $? -ne 0 ]; then exit 1; fi
+for name in attr message_test message_type; do
+    tr -d '\r' < $name.xml | sed -E \
+        -e '/^<\/?protocol>$/d' \
+        -e 's|^<namespace lang="(\w+)">(.*)</namespace>$|namespace \1 \2;|' \
+        -e 's|^<import>(\w+)\.xml</import>$|import "\1.brex";|' \
+        -e 's|^<(enum\|struct\|enum_map) name="(\w+)">$|\1 \2 {|' \
+        -e 's|^</(enum\|struct\|enum_map)>$|}|' \
+        -e 's|^( *)<(required\|optional) name="(\w+)" +type="([^"]+)"/>$|\1\2 \4 \3;|' \
+        -e 's|^( *)<item name="(\w+)"/>$|\1\2;|' \
+        -e 's|^( *)<item name="(\w+)" +value="([^"]+)"/>$|\1\2 = \3;|' \
+        -e 's|^( *)<item name="(\w+)" +struct="([^"]+)"/>$|\1\2 -> \3;|' \
+        -e 's|^( *)<item name="(\w+)" +value="([^"]+)" +struct="([^"]+)"/>$|\1\2 = \3 -> \4;|' \
+        > brex/$name.brex
+    if [ $? -ne 0 ]; then exit 1; fi
+done
+./brexc -f message_type.xml --with-imports -l cpp,csharp,php -o xml_gen
+if [ $? -ne 0 ]; then exit 1; fi
+# imports are found in current dir
+(cd brex && ../brexc -f message_type.brex --with-imports \
+    -l cpp,csharp,php -o ../brex_gen)
+if [ $? -ne 0 ]; then exit 1; fi
+diff -r xml_gen brex_gen
+if [ $? -ne 0 ]; then exit 1; fi
+
+# check compat rules, each case is a new version of compat/old
+cp -r "$script_path"/compat .
+if [ $? -ne 0 ]; then exit 1; fi
+cd compat
+if [ $? -ne 0 ]; then exit 1; fi
+for case_dir in */; do
+    case_name="${case_dir%/}"
+    if [ "$case_name" = "old" ]; then continue; fi
+    ../brexc compat --old old --new "$case_name" -f compat.xml \
+        2> "$case_name".out
+    status=$?
+    # breaking changes exit with 1, warnings only with 0
+    grep -q ': error: ' "$case_name"/expect.txt
+    if [ $? -eq 0 ]; then expect_status=1; else expect_status=0; fi
+    if [ $status -ne $expect_status ]; then
+        echo "compat $case_name: exit code $status, expect $expect_status"
+        exit 1
+    fi
+    diff -u "$case_name"/expect.txt "$case_name".out
+    if [ $? -ne 0 ]; then exit 1; fi
+done
+cd "$test_dir"
+if [ $? -ne 0 ]; then exit 1; fi
 
 exit 0
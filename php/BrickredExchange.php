@@ -149,6 +149,465 @@ final class CodecException extends \Exception
 {
 }
 
+final class FieldType
+{
+    const I8 = 0;
+    const U8 = 1;
+    const I16 = 2;
+    const U16 = 3;
+    const I32 = 4;
+    const U32 = 5;
+    const I64 = 6;
+    const U64 = 7;
+    const I16V = 8;
+    const U16V = 9;
+    const I32V = 10;
+    const U32V = 11;
+    const I64V = 12;
+    const U64V = 13;
+    const STRING = 14;
+    const BYTES = 15;
+    const BOOL = 16;
+    const ENUM = 17;
+    const STRUCT = 18;
+}
+
+final class EnumItemDescriptor
+{
+    public $name;
+    public $value;
+
+    public function __construct($name, $value)
+    {
+        $this->name = $name;
+        $this->value = $value;
+    }
+}
+
+final class EnumDescriptor
+{
+    public $name;
+    public $full_name;
+    public $items;
+
+    public function __construct($name, $full_name, $items)
+    {
+        $this->name = $name;
+        $this->full_name = $full_name;
+        $this->items = $items;
+    }
+
+    public function findItemByName($name)
+    {
+        foreach ($this->items as $item) {
+            if ($item->name === $name) {
+                return $item;
+            }
+        }
+
+        return null;
+    }
+
+    public function findItemByValue($value)
+    {
+        foreach ($this->items as $item) {
+            if ($item->value === $value) {
+                return $item;
+            }
+        }
+
+        return null;
+    }
+}
+
+final class FieldDescriptor
+{
+    public $name;
+    public $type;
+    public $is_list;
+    // -1 if field is required
+    public $optional_index;
+    // only valid when type is FieldType::ENUM
+    public $ref_enum;
+    // only valid when type is FieldType::STRUCT
+    public $ref_struct;
+
+    public function __construct($name, $type, $is_list, $optional_index,
+                                $ref_enum, $ref_struct)
+    {
+        $this->name = $name;
+        $this->type = $type;
+        $this->is_list = $is_list;
+        $this->optional_index = $optional_index;
+        $this->ref_enum = $ref_enum;
+        $this->ref_struct = $ref_struct;
+    }
+}
+
+final class StructDescriptor
+{
+    public $name;
+    public $full_name;
+    public $class_name;
+    public $fields;
+
+    public function __construct($name, $full_name, $class_name, $fields)
+    {
+        $this->name = $name;
+        $this->full_name = $full_name;
+        $this->class_name = $class_name;
+        $this->fields = $fields;
+    }
+
+    public function create()
+    {
+        return new $this->class_name();
+    }
+
+    public function findFieldIndex($name)
+    {
+        foreach ($this->fields as $index => $field) {
+            if ($field->name === $name) {
+                return $index;
+            }
+        }
+
+        return -1;
+    }
+}
+
+abstract class BaseStruct
+{
+    abstract public static function descriptor();
+    abstract public function dump();
+    abstract public function parseTextFields($parser);
+
+    public function fromText($text)
+    {
+        $parser = new TextParser($text);
+        $this->parseTextFields($parser);
+        $parser->checkEnd();
+    }
+
+    protected static function dumpString($var)
+    {
+        $ret = '';
+        for ($i = 0; $i < strlen($var); ++$i) {
+            $c = $var[$i];
+            if ($c === '\\') {
+                $ret .= '\\\\';
+            } else if ($c === '"') {
+                $ret .= '\\"';
+            } else if ($c === "\n") {
+                $ret .= '\\n';
+            } else if ($c === "\r") {
+                $ret .= '\\r';
+            } else if ($c === "\t") {
+                $ret .= '\\t';
+            } else if (ord($c) < 0x20 || ord($c) === 0x7f) {
+                $ret .= sprintf('\\x%02X', ord($c));
+            } else {
+                $ret .= $c;
+            }
+        }
+
+        return $ret;
+    }
+
+    protected static function dumpBytes($var)
+    {
+        return implode('-', str_split(strtoupper(bin2hex($var)), 2));
+    }
+
+    public function getField($index)
+    {
+        $fields = static::descriptor()->fields;
+        if (!isset($fields[$index])) {
+            return null;
+        }
+
+        return $this->{$fields[$index]->name};
+    }
+
+    public function setField($index, $value)
+    {
+        $fields = static::descriptor()->fields;
+        if (!isset($fields[$index])) {
+            return;
+        }
+
+        $this->{$fields[$index]->name} = $value;
+        $this->setHasField($index, true);
+    }
+
+    public function hasField($index)
+    {
+        $fields = static::descriptor()->fields;
+        if (!isset($fields[$index])) {
+            return false;
+        }
+        if ($fields[$index]->optional_index < 0) {
+            return true;
+        }
+
+        return $this->{'has_'.$fields[$index]->name}();
+    }
+
+    public function setHasField($index, $has)
+    {
+        $fields = static::descriptor()->fields;
+        if (!isset($fields[$index])) {
+            return;
+        }
+        if ($fields[$index]->optional_index < 0) {
+            return;
+        }
+
+        if ($has) {
+            $this->{'set_has_'.$fields[$index]->name}();
+        } else {
+            $this->{'clear_has_'.$fields[$index]->name}();
+        }
+    }
+}
+
+// reader of the text format produced by the generated dump()
+// - fields are written as `name: value` separated by whitespace
+// - list fields are written as one `name: value` per item
+// - optional fields not set are omitted
+// - integers and enums are decimal, bool is 1 or 0
+// - string is quoted, with \\ \" \n \r \t and \xHH escapes
+// - bytes is quoted uppercase hex separated by '-', e.g. "0A-FF"
+// - struct is written as `name: { fields }`
+final class TextParser
+{
+    private $text_;
+    private $pos_;
+
+    public function __construct($text)
+    {
+        $this->text_ = $text;
+        $this->pos_ = 0;
+    }
+
+    // byte offset where parsing stopped
+    public function offset()
+    {
+        return $this->pos_;
+    }
+
+    // return null when reach end of text or '}' of current struct
+    public function readFieldName()
+    {
+        $this->skipWhitespace();
+        if ($this->pos_ >= strlen($this->text_) ||
+            $this->text_[$this->pos_] === '}') {
+            return null;
+        }
+        if (preg_match('/\G[A-Za-z_][A-Za-z0-9_]*/',
+                       $this->text_, $matches, 0, $this->pos_) !== 1) {
+            throw $this->invalidText();
+        }
+        $name = $matches[0];
+        $this->pos_ += strlen($name);
+
+        $this->skipWhitespace();
+        if ($this->pos_ >= strlen($this->text_) ||
+            $this->text_[$this->pos_] !== ':') {
+            throw $this->invalidText();
+        }
+        ++$this->pos_;
+
+        return $name;
+    }
+
+    public function readInt()
+    {
+        $start = $this->pos_;
+        $token = $this->readToken();
+        if (preg_match('/^-?[0-9]+$/', $token) !== 1) {
+            $this->pos_ = $start;
+            throw $this->invalidText();
+        }
+
+        return $token + 0;
+    }
+
+    public function readInt64()
+    {
+        $start = $this->pos_;
+        $token = $this->readToken();
+        if (preg_match('/^-?[0-9]+$/', $token) !== 1 ||
+            bccomp($token, '9223372036854775807') > 0 ||
+            bccomp($token, '-9223372036854775808') < 0) {
+            $this->pos_ = $start;
+            throw $this->invalidText();
+        }
+
+        return new Int64($token);
+    }
+
+    public function readUInt64()
+    {
+        $start = $this->pos_;
+        $token = $this->readToken();
+        if (preg_match('/^[0-9]+$/', $token) !== 1 ||
+            bccomp($token, '18446744073709551615') > 0) {
+            $this->pos_ = $start;
+            throw $this->invalidText();
+        }
+
+        return new UInt64($token);
+    }
+
+    public function readBool()
+    {
+        $start = $this->pos_;
+        $token = $this->readToken();
+        if ($token === '1' || $token === 'true') {
+            return true;
+        } else if ($token === '0' || $token === 'false') {
+            return false;
+        } else {
+            $this->pos_ = $start;
+            throw $this->invalidText();
+        }
+    }
+
+    public function readString()
+    {
+        $this->skipWhitespace();
+        $length = strlen($this->text_);
+        if ($this->pos_ >= $length ||
+            $this->text_[$this->pos_] !== '"') {
+            throw $this->invalidText();
+        }
+        ++$this->pos_;
+
+        $ret = '';
+        for (;;) {
+            if ($this->pos_ >= $length) {
+                throw $this->invalidText();
+            }
+
+            $c = $this->text_[$this->pos_];
+            if ($c === '"') {
+                ++$this->pos_;
+                break;
+            } else if ($c !== '\\') {
+                $ret .= $c;
+                ++$this->pos_;
+                continue;
+            }
+
+            if ($this->pos_ + 1 >= $length) {
+                throw $this->invalidText();
+            }
+            $c = $this->text_[$this->pos_ + 1];
+            if ($c === '\\' || $c === '"') {
+                $ret .= $c;
+                $this->pos_ += 2;
+            } else if ($c === 'n') {
+                $ret .= "\n";
+                $this->pos_ += 2;
+            } else if ($c === 'r') {
+                $ret .= "\r";
+                $this->pos_ += 2;
+            } else if ($c === 't') {
+                $ret .= "\t";
+                $this->pos_ += 2;
+            } else if ($c === 'x') {
+                $hex = substr($this->text_, $this->pos_ + 2, 2);
+                if (preg_match('/^[0-7][0-9A-Fa-f]$/', $hex) !== 1) {
+                    throw $this->invalidText();
+                }
+                $ret .= chr(hexdec($hex));
+                $this->pos_ += 4;
+            } else {
+                throw $this->invalidText();
+            }
+        }
+
+        return $ret;
+    }
+
+    public function readBytes()
+    {
+        $start = $this->pos_;
+        $hex = $this->readString();
+        if ($hex === '') {
+            return '';
+        }
+        if (preg_match('/^[0-9A-Fa-f]{2}(-[0-9A-Fa-f]{2})*$/',
+                       $hex) !== 1) {
+            $this->pos_ = $start;
+            throw $this->invalidText();
+        }
+
+        return hex2bin(str_replace('-', '', $hex));
+    }
+
+    public function readStruct($struct_name)
+    {
+        $this->readChar('{');
+        $var = new $struct_name();
+        $var->parseTextFields($this);
+        $this->readChar('}');
+
+        return $var;
+    }
+
+    // throw if anything but whitespace left
+    public function checkEnd()
+    {
+        $this->skipWhitespace();
+        if ($this->pos_ < strlen($this->text_)) {
+            throw $this->invalidText();
+        }
+    }
+
+    public function unknownField($name)
+    {
+        return new CodecException(
+            "unknown field `$name` at offset {$this->pos_}");
+    }
+
+    private function invalidText()
+    {
+        return new CodecException(
+            "invalid text at offset {$this->pos_}");
+    }
+
+    private function skipWhitespace()
+    {
+        $this->pos_ += strspn($this->text_, " \t\n\r", $this->pos_);
+    }
+
+    private function readChar($expected)
+    {
+        $this->skipWhitespace();
+        if ($this->pos_ >= strlen($this->text_) ||
+            $this->text_[$this->pos_] !== $expected) {
+            throw $this->invalidText();
+        }
+        ++$this->pos_;
+    }
+
+    private function readToken()
+    {
+        $this->skipWhitespace();
+        $length = strcspn($this->text_, " \t\n\r}", $this->pos_);
+        if ($length === 0) {
+            throw $this->invalidText();
+        }
+
+        $token = substr($this->text_, $this->pos_, $length);
+        $this->pos_ += $length;
+
+        return $token;
+    }
+}
+
 final class Codec
 {
     public static function openStreamForBuffer($buf)